@@ -1,12 +1,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"runtime/debug"
+	"os/signal"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"math/rand/v2"
@@ -16,22 +19,20 @@ import (
 )
 
 func main() {
-	// Get fluent configuration from environment variables
-	fluentCfg, err := loadFluentConfigFromEnv()
+	// Get logger configuration from environment variables
+	loggerCfg, err := loadLoggerConfigFromEnv()
 	if err != nil {
 		panic(fmt.Errorf("failed to create wrapped logger: %w", err))
 	}
 
-	loggerCfg := &observability.SugaredLoggerConfig{
-		FluentConfig: fluentCfg,
-		LogLevel:     "DEBUG",
-	}
-
 	logger, err := observability.NewSugaredLogger(loggerCfg)
 	if err != nil {
 		panic(fmt.Errorf("failed to create logger: %w", err))
 	}
-	defer logger.Close()
+
+	// Cancel the workers on SIGINT/SIGTERM.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	var wg sync.WaitGroup
 	wg.Add(10)
@@ -44,7 +45,7 @@ func main() {
 					logger.Errorw("goroutine panic",
 						"goroutine", id,
 						"recover", r,
-						"stack", string(debug.Stack()),
+						observability.StackFrames(),
 					)
 				}
 			}()
@@ -52,7 +53,11 @@ func main() {
 			defer wg.Done()
 
 			r := rand.IntN(1000)
-			time.Sleep(time.Duration(r) * time.Millisecond)
+			select {
+			case <-time.After(time.Duration(r) * time.Millisecond):
+			case <-ctx.Done():
+				return
+			}
 
 			ticker := time.NewTicker(10 * time.Second)
 			defer ticker.Stop()
@@ -63,15 +68,110 @@ func main() {
 					logger.Infow("log collected",
 						"goroutine", id,
 					)
+				case <-ctx.Done():
+					return
 				}
 			}
 		}(i)
 	}
 
 	wg.Wait()
+
+	// Close only once the workers are done, so their last entries are
+	// flushed rather than rejected.
+	logger.Infow("shutting down")
+	if err := logger.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to close logger: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func loadLoggerConfigFromEnv() (*observability.SugaredLoggerConfig, error) {
+	fluentCfg, err := loadFluentConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &observability.SugaredLoggerConfig{
+		FluentConfig: fluentCfg,
+		Tag:          "app.logs",
+		LogLevel:     "DEBUG",
+	}
+
+	if tag := os.Getenv("FLUENT_TAG"); tag != "" {
+		if err := observability.ValidateTag(tag); err != nil {
+			return nil, fmt.Errorf("invalid Tag: %w", err)
+		}
+		cfg.Tag = tag
+	}
+
+	if lvl := os.Getenv("FLUENT_LOG_LEVEL"); lvl != "" {
+		if _, err := observability.ParseLevel(lvl); err != nil {
+			return nil, fmt.Errorf("invalid LogLevel: %w", err)
+		}
+		cfg.LogLevel = lvl
+	}
+
+	if cfg.InternalDebug, err = parseBool("FLUENT_INTERNAL_DEBUG"); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// knownEnv lists the FLUENT_-prefixed variables the worker reads.
+var knownEnv = map[string]bool{
+	"FLUENT_TAG":                      true,
+	"FLUENT_LOG_LEVEL":                true,
+	"FLUENT_INTERNAL_DEBUG":           true,
+	"FLUENT_STRICT_ENV":               true,
+	"FLUENT_NETWORK":                  true,
+	"FLUENT_SOCKET_PATH":              true,
+	"FLUENT_HOST":                     true,
+	"FLUENT_PORT":                     true,
+	"FLUENT_TIMEOUT":                  true,
+	"FLUENT_WRITE_TIMEOUT":            true,
+	"FLUENT_BUFFER_LIMIT":             true,
+	"FLUENT_ASYNC":                    true,
+	"FLUENT_FORCE_STOP_ASYNC_SEND":    true,
+	"FLUENT_SUB_SECOND_PRECISION":     true,
+	"FLUENT_MARSHAL_AS_JSON":          true,
+	"FLUENT_REQUEST_ACK":              true,
+	"FLUENT_TLS_INSECURE_SKIP_VERIFY": true,
+	"FLUENT_ASYNC_RECONNECT_INTERVAL": true,
+	"FLUENT_TAG_PREFIX":               true,
+}
+
+// checkUnknownEnv reports FLUENT_-prefixed variables that are not in
+// knownEnv, which are most likely typos.
+func checkUnknownEnv() error {
+	var unknown []string
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(name, "FLUENT_") && !knownEnv[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown environment variables: %s", strings.Join(unknown, ", "))
+	}
+	return nil
 }
 
 func loadFluentConfigFromEnv() (fluent.Config, error) {
+	// Strict mode is opt-in: other tools may set FLUENT_-prefixed
+	// variables of their own.
+	strict, err := parseBool("FLUENT_STRICT_ENV")
+	if err != nil {
+		return fluent.Config{}, err
+	}
+	if strict {
+		if err := checkUnknownEnv(); err != nil {
+			return fluent.Config{}, err
+		}
+	}
+
 	cfg := fluent.Config{
 		// Set default values from specification
 		FluentNetwork: "tcp",
@@ -88,14 +188,14 @@ func loadFluentConfigFromEnv() (fluent.Config, error) {
 	if env := os.Getenv("FLUENT_NETWORK"); env != "" {
 		cfg.FluentNetwork = env
 	}
-	if !map[string]bool{"tcp": true, "tls": true, "unix": true}[cfg.FluentNetwork] {
+	if !map[string]bool{"tcp": true, "tls": true, "unix": true, "unixgram": true}[cfg.FluentNetwork] {
 		return fluent.Config{}, fmt.Errorf("invalid FluentNetwork: %s", cfg.FluentNetwork)
 	}
 
-	if cfg.FluentNetwork == "unix" {
+	if cfg.FluentNetwork == "unix" || cfg.FluentNetwork == "unixgram" {
 		cfg.FluentSocketPath = os.Getenv("FLUENT_SOCKET_PATH")
 		if cfg.FluentSocketPath == "" {
-			return fluent.Config{}, fmt.Errorf("FluentSocketPath required for unix network")
+			return fluent.Config{}, fmt.Errorf("FluentSocketPath required for %s network", cfg.FluentNetwork)
 		}
 	} else {
 		if host := os.Getenv("FLUENT_HOST"); host != "" {
@@ -112,7 +212,7 @@ func loadFluentConfigFromEnv() (fluent.Config, error) {
 
 	// Timeouts
 	if timeoutStr := os.Getenv("FLUENT_TIMEOUT"); timeoutStr != "" {
-		timeout, err := time.ParseDuration(timeoutStr)
+		timeout, err := parseDuration(timeoutStr)
 		if err != nil {
 			return fluent.Config{}, fmt.Errorf("invalid Timeout: %w", err)
 		}
@@ -120,7 +220,7 @@ func loadFluentConfigFromEnv() (fluent.Config, error) {
 	}
 
 	if writeTimeoutStr := os.Getenv("FLUENT_WRITE_TIMEOUT"); writeTimeoutStr != "" {
-		writeTimeout, err := time.ParseDuration(writeTimeoutStr)
+		writeTimeout, err := parseDuration(writeTimeoutStr)
 		if err != nil {
 			return fluent.Config{}, fmt.Errorf("invalid WriteTimeout: %w", err)
 		}
@@ -136,7 +236,6 @@ func loadFluentConfigFromEnv() (fluent.Config, error) {
 		cfg.BufferLimit = limit
 	}
 
-	var err error
 	if cfg.Async, err = parseBool("FLUENT_ASYNC"); err != nil {
 		return fluent.Config{}, err
 	}
@@ -184,3 +283,17 @@ func parseBool(envVar string) (bool, error) {
 		return false, fmt.Errorf("invalid boolean value for %s", envVar)
 	}
 }
+
+// parseDuration parses a duration with a unit suffix, e.g. "10s" or
+// "250ms". As a fallback for settings carried over from tools that take
+// plain numbers, a bare integer is read as seconds: "10" is 10s.
+func parseDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err == nil {
+		return d, nil
+	}
+	if secs, convErr := strconv.ParseInt(s, 10, 64); convErr == nil {
+		return time.Duration(secs) * time.Second, nil
+	}
+	return 0, err
+}
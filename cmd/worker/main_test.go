@@ -0,0 +1,190 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearFluentEnv unsets every FLUENT_ variable for the duration of the
+// test, so the environment of the test run does not leak in.
+func clearFluentEnv(t *testing.T) {
+	t.Helper()
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(name, "FLUENT_") {
+			t.Setenv(name, "")
+			os.Unsetenv(name)
+		}
+	}
+}
+
+func TestLoadLoggerConfigFromEnvDefaults(t *testing.T) {
+	clearFluentEnv(t)
+
+	cfg, err := loadLoggerConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Tag != "app.logs" {
+		t.Errorf("Tag = %q, want app.logs", cfg.Tag)
+	}
+	if cfg.LogLevel != "DEBUG" {
+		t.Errorf("LogLevel = %q, want DEBUG", cfg.LogLevel)
+	}
+}
+
+func TestLoadLoggerConfigFromEnvTag(t *testing.T) {
+	tests := []struct {
+		tag     string
+		wantErr bool
+	}{
+		{"billing.api", false},
+		{"app_1.worker-2", false},
+		{"billing api", true},
+		{"billing..api", true},
+		{"billing.", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			clearFluentEnv(t)
+			t.Setenv("FLUENT_TAG", tt.tag)
+
+			cfg, err := loadLoggerConfigFromEnv()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("FLUENT_TAG=%q accepted", tt.tag)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Tag != tt.tag {
+				t.Errorf("Tag = %q, want %q", cfg.Tag, tt.tag)
+			}
+		})
+	}
+}
+
+func TestLoadLoggerConfigFromEnvLogLevel(t *testing.T) {
+	tests := []struct {
+		level   string
+		wantErr bool
+	}{
+		{"info", false},
+		{"ERROR", false},
+		{"WARNING", false},
+		{"verbose", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			clearFluentEnv(t)
+			t.Setenv("FLUENT_LOG_LEVEL", tt.level)
+
+			cfg, err := loadLoggerConfigFromEnv()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("FLUENT_LOG_LEVEL=%q accepted", tt.level)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.LogLevel != tt.level {
+				t.Errorf("LogLevel = %q, want %q", cfg.LogLevel, tt.level)
+			}
+		})
+	}
+}
+
+func TestLoadFluentConfigFromEnvNetwork(t *testing.T) {
+	tests := []struct {
+		network, socketPath string
+		wantErr             bool
+	}{
+		{"tcp", "", false},
+		{"unix", "/run/fluent.sock", false},
+		{"unixgram", "/run/fluent.sock", false},
+		{"unix", "", true},
+		{"unixgram", "", true},
+		{"udp", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.network+"_"+tt.socketPath, func(t *testing.T) {
+			clearFluentEnv(t)
+			t.Setenv("FLUENT_NETWORK", tt.network)
+			t.Setenv("FLUENT_SOCKET_PATH", tt.socketPath)
+
+			cfg, err := loadFluentConfigFromEnv()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("FLUENT_NETWORK=%q FLUENT_SOCKET_PATH=%q accepted", tt.network, tt.socketPath)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.FluentNetwork != tt.network || cfg.FluentSocketPath != tt.socketPath {
+				t.Errorf("network %q at %q, want %q at %q", cfg.FluentNetwork, cfg.FluentSocketPath, tt.network, tt.socketPath)
+			}
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"10", 10 * time.Second, false},
+		{"0", 0, false},
+		{"10s", 10 * time.Second, false},
+		{"10ms", 10 * time.Millisecond, false},
+		{"1m30s", 90 * time.Second, false},
+		{"", 0, true},
+		{"ten", 0, true},
+		{"10x", 0, true},
+		{"1.5", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseDuration(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseDuration(%q) = %v, want an error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("parseDuration(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFluentConfigFromEnvTimeouts(t *testing.T) {
+	clearFluentEnv(t)
+	t.Setenv("FLUENT_TIMEOUT", "5")
+	t.Setenv("FLUENT_WRITE_TIMEOUT", "250ms")
+
+	cfg, err := loadFluentConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Timeout != 5*time.Second || cfg.WriteTimeout != 250*time.Millisecond {
+		t.Errorf("Timeout, WriteTimeout = %v, %v, want 5s, 250ms", cfg.Timeout, cfg.WriteTimeout)
+	}
+
+	t.Setenv("FLUENT_TIMEOUT", "soon")
+	if _, err := loadFluentConfigFromEnv(); err == nil {
+		t.Error("FLUENT_TIMEOUT=soon accepted")
+	}
+}
@@ -0,0 +1,62 @@
+package observability
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/fluent/fluent-logger-golang/fluent"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const startupBannerMessage = "logger started"
+
+// emitStartupBanner posts the info entry configured by EmitStartupBanner.
+// Like the close marker, it bypasses the level filter. It lists the
+// effective settings that shape the log stream; values that may hold
+// secrets or code (writers, callbacks, redaction patterns) are only
+// reported as set or not.
+func (l *SugaredLogger) emitStartupBanner() error {
+	cfg := l.cfg
+	fc := cfg.FluentConfig
+	hostname, _ := os.Hostname()
+
+	fields := []zapcore.Field{
+		zap.Int("pid", os.Getpid()),
+		zap.String("hostname", hostname),
+		zap.Dict("config",
+			zap.String("tag", cfg.Tag),
+			zap.String("level", l.fluent.level.Level().String()),
+			zap.String("network", dialNetwork(fc)),
+			zap.String("address", fluentAddress(fc)),
+			zap.Int("shards", len(cfg.Shards)),
+			zap.Bool("async", fc.Async),
+			zap.Duration("timeout", fc.Timeout),
+			zap.Duration("write_timeout", fc.WriteTimeout),
+			zap.Int("queue_size", cfg.QueueSize),
+			zap.Int("offline_buffer", cfg.OfflineBuffer),
+			zap.Bool("sampling", cfg.Sampling != nil),
+			zap.Bool("fallback", cfg.Fallback != nil),
+			zap.Bool("redaction", len(cfg.RedactPatterns) > 0),
+			zap.Bool("close_marker", cfg.CloseMarker),
+		),
+	}
+	if !cfg.IncludeBuildInfo {
+		fields = append(fields, buildInfoFields()...)
+	}
+
+	now := time.Now()
+	record := l.core.encode(zapcore.Entry{
+		Level:   zapcore.InfoLevel,
+		Time:    now,
+		Message: cfg.MessagePrefix + startupBannerMessage,
+	}, fields)
+	return l.fluent.post(context.Background(), l.fluent.tag, now, record)
+}
+
+// dialNetwork returns the network the fluent client uses for cfg.
+func dialNetwork(cfg fluent.Config) string {
+	network, _ := dialTarget(cfg)
+	return network
+}
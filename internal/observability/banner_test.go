@@ -0,0 +1,58 @@
+package observability
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestStartupBannerOmitsSecrets(t *testing.T) {
+	fallback := bytes.NewBufferString("fallback-s3cr3t")
+	l, p := newTestLogger(t, &SugaredLoggerConfig{
+		Tag:            "app.logs",
+		Fallback:       fallback,
+		RedactPatterns: []*regexp.Regexp{regexp.MustCompile(`s3cr3t-[0-9]+`)},
+		OnOverflow:     func(map[string]interface{}) {},
+	})
+	if err := l.emitStartupBanner(); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := p.last(t)
+	if rec["message"] != startupBannerMessage {
+		t.Errorf("message = %v, want %q", rec["message"], startupBannerMessage)
+	}
+	if rec["pid"] != int64(os.Getpid()) {
+		t.Errorf("pid = %v, want %d", rec["pid"], os.Getpid())
+	}
+	if hostname, _ := os.Hostname(); rec["hostname"] != hostname {
+		t.Errorf("hostname = %v, want %q", rec["hostname"], hostname)
+	}
+
+	config, ok := rec["config"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("config = %#v, want a map", rec["config"])
+	}
+	if config["tag"] != "app.logs" {
+		t.Errorf("config.tag = %v, want app.logs", config["tag"])
+	}
+	for _, key := range []string{"fallback", "redaction"} {
+		if config[key] != true {
+			t.Errorf("config.%s = %#v, want true", key, config[key])
+		}
+	}
+	if config["sampling"] != false {
+		t.Errorf("config.sampling = %#v, want false", config["sampling"])
+	}
+
+	raw, err := json.Marshal(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "s3cr3t") {
+		t.Errorf("banner leaks a configured value: %s", raw)
+	}
+}
@@ -0,0 +1,37 @@
+package observability
+
+import (
+	"sort"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SetBaseFields replaces the fields added to every subsequent entry of this
+// logger and of every logger derived from the same root, e.g. to update a
+// deployment_color field during a blue/green rollout. Fields attached with
+// With, WithComponent and the like are kept and take precedence over base
+// fields of the same key. Entries being written while the fields are
+// replaced carry either the old or the new set, never a mix. Passing nil
+// removes the base fields.
+func (l *SugaredLogger) SetBaseFields(fields map[string]interface{}) {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	base := make([]zapcore.Field, len(keys))
+	for i, k := range keys {
+		base[i] = zap.Any(k, fields[k])
+	}
+	l.fluent.base.Store(&base)
+}
+
+// baseFields returns the fields set by SetBaseFields.
+func (f *FluentLogger) baseFields() []zapcore.Field {
+	if p := f.base.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package observability
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestSetBaseFields(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+	child := l.WithComponent("billing").With("deployment_color", "child")
+
+	l.SetBaseFields(map[string]interface{}{"deployment_color": "blue", "region": "eu"})
+	l.Infow("first")
+	child.Infow("child")
+	l.SetBaseFields(map[string]interface{}{"deployment_color": "green"})
+	l.Infow("second")
+	l.SetBaseFields(nil)
+	l.Infow("third")
+
+	recs := p.records()
+	if len(recs) != 4 {
+		t.Fatalf("got %d records, want 4", len(recs))
+	}
+	if recs[0]["deployment_color"] != "blue" || recs[0]["region"] != "eu" {
+		t.Errorf("first record = %v, want the first base fields", recs[0])
+	}
+	if recs[1]["deployment_color"] != "child" || recs[1]["region"] != "eu" {
+		t.Errorf("child record = %v, want its own field over the base field", recs[1])
+	}
+	if _, ok := recs[2]["region"]; ok || recs[2]["deployment_color"] != "green" {
+		t.Errorf("second record = %v, want only the replaced base fields", recs[2])
+	}
+	if _, ok := recs[3]["deployment_color"]; ok {
+		t.Errorf("third record = %v, want no base fields", recs[3])
+	}
+}
+
+func TestSetBaseFieldsConcurrent(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				color := fmt.Sprint(i, j)
+				l.SetBaseFields(map[string]interface{}{"color": color, "copy": color})
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.Infow("entry")
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, rec := range p.records() {
+		if rec["color"] != rec["copy"] {
+			t.Fatalf("record %v mixes two base field sets", rec)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package observability
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	b := newCircuitBreaker(2, 20*time.Millisecond)
+
+	b.record(false)
+	if !b.allow() || b.State() != CircuitClosed {
+		t.Fatalf("state %s after one failure, want closed", b.State())
+	}
+	b.record(false)
+	if b.allow() || b.State() != CircuitOpen {
+		t.Fatalf("state %s after reaching the threshold, want open and no delivery", b.State())
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if !b.allow() {
+		t.Fatal("no probe allowed after the cooldown")
+	}
+	if b.State() != CircuitHalfOpen {
+		t.Errorf("state %s during the probe, want half-open", b.State())
+	}
+	if b.allow() {
+		t.Error("a second delivery was allowed while the probe is pending")
+	}
+	b.record(false)
+	if b.allow() || b.State() != CircuitOpen {
+		t.Fatalf("state %s after a failed probe, want open", b.State())
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if !b.allow() {
+		t.Fatal("no probe allowed after the second cooldown")
+	}
+	b.record(true)
+	if !b.allow() || b.State() != CircuitClosed {
+		t.Errorf("state %s after a successful probe, want closed", b.State())
+	}
+	b.record(false)
+	if b.State() != CircuitClosed {
+		t.Error("a successful probe did not reset the failure count")
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	b := newCircuitBreaker(0, time.Second)
+	for i := 0; i < 10; i++ {
+		b.record(false)
+	}
+	if !b.allow() || b.State() != CircuitClosed {
+		t.Errorf("disabled breaker: allow %v, state %s", b.allow(), b.State())
+	}
+}
+
+func TestCircuitBreakerSkipsDelivery(t *testing.T) {
+	l, _ := newTestLogger(t, &SugaredLoggerConfig{BreakerThreshold: 2, BreakerCooldown: 20 * time.Millisecond})
+	p := &flakyPoster{fails: 3, err: errors.New("connection refused")}
+	l.fluent.logger = p
+	line := []byte(`{"severity":"info","message":"m"}` + "\n")
+
+	for i := 0; i < 2; i++ {
+		_, _ = l.fluent.Write(line)
+	}
+	if _, err := l.fluent.Write(line); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("write while open = %v, want ErrCircuitOpen", err)
+	}
+	if n := p.attempts.Load(); n != 2 {
+		t.Errorf("%d delivery attempts, want 2: the open circuit must not reach the transport", n)
+	}
+	if s := l.CircuitState(); s != CircuitOpen {
+		t.Errorf("CircuitState = %s, want open", s)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	_, _ = l.fluent.Write(line)
+	if s := l.CircuitState(); s != CircuitOpen || p.attempts.Load() != 3 {
+		t.Errorf("after a failed probe: state %s, %d attempts; want open, 3", s, p.attempts.Load())
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, err := l.fluent.Write(line); err != nil {
+		t.Fatalf("probe write: %v", err)
+	}
+	if s := l.CircuitState(); s != CircuitClosed {
+		t.Errorf("CircuitState = %s after a successful probe, want closed", s)
+	}
+	if n := len(p.records()); n != 1 {
+		t.Errorf("%d records delivered, want the probe", n)
+	}
+}
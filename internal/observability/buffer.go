@@ -0,0 +1,321 @@
+package observability
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// bufferedRecord is a record held by the offline buffer.
+type bufferedRecord struct {
+	tag    string
+	time   time.Time
+	record map[string]interface{}
+	// size is the approximate encoded size of record, for MaxQueueBytes.
+	size int64
+}
+
+// offlineBuffer holds records in memory, oldest first, while they cannot be
+// delivered. Besides the overall limit, tags may have limits of their own,
+// so a burst on one tag evicts that tag's oldest records rather than
+// everyone's, and the approximate size of all records may be capped. Once
+// closed it holds nothing: records pushed to it are handed straight back
+// as evicted.
+//
+// A nil *offlineBuffer holds nothing.
+type offlineBuffer struct {
+	limit     int
+	tagLimits map[string]int
+	maxBytes  int64
+
+	mu     sync.Mutex
+	items  []bufferedRecord
+	counts map[string]int
+	closed bool
+	// bytes is the total size of items. It is only written under mu, but
+	// may be read without it.
+	bytes atomic.Int64
+}
+
+func newOfflineBuffer(limit int, tagLimits map[string]int, maxBytes int64) *offlineBuffer {
+	if limit <= 0 {
+		return nil
+	}
+	b := &offlineBuffer{limit: limit, maxBytes: maxBytes, counts: make(map[string]int)}
+	for tag, n := range tagLimits {
+		if n > 0 {
+			if b.tagLimits == nil {
+				b.tagLimits = make(map[string]int)
+			}
+			b.tagLimits[tag] = n
+		}
+	}
+	return b
+}
+
+// push appends r and returns the records evicted to stay within the limit.
+func (b *offlineBuffer) push(r bufferedRecord) []bufferedRecord {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return []bufferedRecord{r}
+	}
+	if b.maxBytes > 0 {
+		r.size = approxSize(r.record)
+	}
+	b.items = append(b.items, r)
+	b.counts[r.tag]++
+	b.bytes.Add(r.size)
+	return b.trim()
+}
+
+// requeue puts records taken for delivery back in front of the buffer and
+// returns the records evicted to stay within the limit.
+func (b *offlineBuffer) requeue(rs []bufferedRecord) []bufferedRecord {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return rs
+	}
+	b.items = append(rs[:len(rs):len(rs)], b.items...)
+	for _, r := range rs {
+		b.counts[r.tag]++
+		b.bytes.Add(r.size)
+	}
+	return b.trim()
+}
+
+// trim evicts the oldest records of every tag beyond its limit, then the
+// oldest records beyond the overall limit, then the oldest records until
+// the buffer fits maxBytes. Callers must hold b.mu.
+func (b *offlineBuffer) trim() []bufferedRecord {
+	var evicted []bufferedRecord
+	excess := make(map[string]int)
+	for tag, limit := range b.tagLimits {
+		if n := b.counts[tag] - limit; n > 0 {
+			excess[tag] = n
+		}
+	}
+	if len(excess) > 0 {
+		kept := b.items[:0]
+		for _, r := range b.items {
+			if excess[r.tag] > 0 {
+				excess[r.tag]--
+				evicted = append(evicted, r)
+				continue
+			}
+			kept = append(kept, r)
+		}
+		b.items = kept
+	}
+
+	n := max(len(b.items)-b.limit, 0)
+	if b.maxBytes > 0 {
+		var bytes int64
+		for _, r := range b.items[n:] {
+			bytes += r.size
+		}
+		for ; n < len(b.items) && bytes > b.maxBytes; n++ {
+			bytes -= b.items[n].size
+		}
+	}
+	if n > 0 {
+		evicted = append(evicted, b.items[:n]...)
+		b.items = b.items[n:]
+	}
+	for _, r := range evicted {
+		b.counts[r.tag]--
+		b.bytes.Add(-r.size)
+	}
+	return evicted
+}
+
+// take removes and returns all buffered records.
+func (b *offlineBuffer) take() []bufferedRecord {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	items := b.items
+	b.items = nil
+	clear(b.counts)
+	b.bytes.Store(0)
+	return items
+}
+
+// close empties the buffer for good and returns what it held.
+func (b *offlineBuffer) close() []bufferedRecord {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	items := b.items
+	b.items = nil
+	clear(b.counts)
+	b.bytes.Store(0)
+	b.closed = true
+	return items
+}
+
+func (b *offlineBuffer) len() int {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.items)
+}
+
+// hold keeps an undeliverable record in the offline buffer, or hands it to
+// the fallback sink if no buffer is configured. Records evicted from a full
+// buffer go to the fallback sink. It reports whether the record was kept.
+func (f *FluentLogger) hold(tag string, t time.Time, entry map[string]interface{}) bool {
+	if f.buffer == nil {
+		return f.divert(tag, t, entry)
+	}
+	for _, r := range f.buffer.push(bufferedRecord{tag: tag, time: t, record: entry}) {
+		f.divert(r.tag, r.time, r.record)
+	}
+	return true
+}
+
+// resend re-posts buffered records in order until one fails; that one and
+// the rest go back to the buffer. Only one resend runs at a time, and none
+// while the circuit is not closed. Callers must hold f.mu for reading.
+func (f *FluentLogger) resend() {
+	if f.buffer.len() == 0 || f.breaker.State() != CircuitClosed {
+		return
+	}
+	if !f.resending.CompareAndSwap(false, true) {
+		return
+	}
+	defer f.resending.Store(false)
+
+	_, _ = f.replay(context.Background())
+}
+
+// replay posts buffered records oldest first until one fails or ctx is
+// done; the remaining records go back to the buffer. It returns the number
+// of records delivered. Callers must hold f.mu for reading and own
+// f.resending.
+func (f *FluentLogger) replay(ctx context.Context) (int, error) {
+	pending := f.buffer.take()
+	for i, r := range pending {
+		err := ctx.Err()
+		if err == nil {
+			err = f.send(f.logger, r.tag, r.time, r.record)
+			f.breaker.record(err == nil)
+			f.observe(r.tag, err)
+		}
+		if err != nil {
+			for _, e := range f.buffer.requeue(pending[i:]) {
+				f.divert(e.tag, e.time, e.record)
+			}
+			return i, err
+		}
+	}
+	return len(pending), nil
+}
+
+// releaseBuffered makes a last delivery attempt for buffered records before
+// the transport is closed and diverts whatever is left to the fallback sink.
+func (f *FluentLogger) releaseBuffered() {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if !f.closed.Load() {
+		f.resend()
+	}
+	for _, r := range f.buffer.close() {
+		f.divert(r.tag, r.time, r.record)
+	}
+}
+
+// DrainBuffered removes and returns the records held by the offline buffer
+// without sending them, e.g. to hand them to a sidecar during a rolling
+// deploy. Drained records are no longer resent, nor diverted by Close. It
+// returns nil when OfflineBuffer is not configured.
+func (l *SugaredLogger) DrainBuffered() []map[string]interface{} {
+	items := l.fluent.buffer.take()
+	if len(items) == 0 {
+		return nil
+	}
+	records := make([]map[string]interface{}, len(items))
+	for i, r := range items {
+		records[i] = r.record
+	}
+	return records
+}
+
+// ReplayBuffered delivers the records held by the offline buffer oldest
+// first, e.g. once Fluentd has been confirmed healthy, without waiting for
+// the next successful write to trigger a resend. Unlike the automatic
+// resend it does not wait for the circuit to close. It stops at the first
+// failure, or when ctx is done, and leaves the remaining records buffered.
+// It returns the number of records delivered.
+func (l *SugaredLogger) ReplayBuffered(ctx context.Context) (replayed int, err error) {
+	f := l.fluent
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.closed.Load() {
+		return 0, ErrLoggerClosed
+	}
+	if !f.resending.CompareAndSwap(false, true) {
+		return 0, ErrReplayInProgress
+	}
+	defer f.resending.Store(false)
+
+	replayed, err = f.replay(ctx)
+	f.self.info("buffered records replayed", "replayed", replayed, "remaining", f.buffer.len())
+	return replayed, err
+}
+
+// size returns the approximate encoded size of the buffered records.
+func (b *offlineBuffer) size() int64 {
+	if b == nil {
+		return 0
+	}
+	return b.bytes.Load()
+}
+
+// QueueBytes returns the approximate encoded size, in bytes, of the records
+// held by the offline buffer.
+func (l *SugaredLogger) QueueBytes() int64 {
+	return l.fluent.buffer.size()
+}
+
+// approxSize estimates the encoded size of v, a record or one of its
+// values, without encoding it: strings count their length, numbers and
+// other scalars a fixed 8 bytes, plus a little framing per element.
+func approxSize(v interface{}) int64 {
+	switch v := v.(type) {
+	case string:
+		return int64(len(v)) + 2
+	case []byte:
+		return int64(len(v)) + 2
+	case map[string]interface{}:
+		n := int64(2)
+		for k, e := range v {
+			n += int64(len(k)) + 3 + approxSize(e)
+		}
+		return n
+	case []interface{}:
+		n := int64(2)
+		for _, e := range v {
+			n += approxSize(e) + 1
+		}
+		return n
+	case nil, bool:
+		return 4
+	default:
+		return 8
+	}
+}
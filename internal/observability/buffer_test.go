@@ -0,0 +1,288 @@
+package observability
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var errFluentdDown = errors.New("fluentd down")
+
+// messagesOf returns the messages of recs, in order.
+func messagesOf(recs []map[string]interface{}) []interface{} {
+	msgs := make([]interface{}, len(recs))
+	for i, r := range recs {
+		msgs[i] = r["message"]
+	}
+	return msgs
+}
+
+func TestOfflineBufferResendsInOrder(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{OfflineBuffer: 10})
+	p.setErr(errFluentdDown)
+	l.Infow("a")
+	l.Infow("b")
+	if n := l.fluent.buffer.len(); n != 2 {
+		t.Fatalf("%d records buffered, want 2", n)
+	}
+
+	p.setErr(nil)
+	l.Infow("c")
+	if got, want := messagesOf(p.records()), []interface{}{"c", "a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("delivered %v, want %v", got, want)
+	}
+	if n := l.fluent.buffer.len(); n != 0 {
+		t.Errorf("%d records left in the buffer", n)
+	}
+}
+
+func TestOfflineBufferEvictsOldestToFallback(t *testing.T) {
+	var fallback bytes.Buffer
+	l, p := newTestLogger(t, &SugaredLoggerConfig{OfflineBuffer: 2, Fallback: &fallback})
+	p.setErr(errFluentdDown)
+	l.Infow("a")
+	l.Infow("b")
+	l.Infow("c")
+
+	if lines := strings.Count(fallback.String(), "\n"); lines != 1 || !strings.Contains(fallback.String(), `"a"`) {
+		t.Errorf("fallback = %q, want only the oldest record", fallback.String())
+	}
+	if got := messagesOf(l.DrainBuffered()); !reflect.DeepEqual(got, []interface{}{"b", "c"}) {
+		t.Errorf("buffered %v, want [b c]", got)
+	}
+}
+
+func TestDrainBuffered(t *testing.T) {
+	var fallback bytes.Buffer
+	l, p := newTestLogger(t, &SugaredLoggerConfig{OfflineBuffer: 10, Fallback: &fallback})
+	p.setErr(errFluentdDown)
+	l.Infow("a")
+	l.Infow("b")
+
+	if got := messagesOf(l.DrainBuffered()); !reflect.DeepEqual(got, []interface{}{"a", "b"}) {
+		t.Errorf("DrainBuffered = %v, want [a b]", got)
+	}
+	if recs := l.DrainBuffered(); recs != nil {
+		t.Errorf("second DrainBuffered = %v, want nil", recs)
+	}
+
+	p.setErr(nil)
+	l.Infow("c")
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := messagesOf(p.records()); !reflect.DeepEqual(got, []interface{}{"c"}) {
+		t.Errorf("delivered %v, want drained records not to be resent", got)
+	}
+	if fallback.Len() != 0 {
+		t.Errorf("fallback = %q, want drained records not to be diverted", fallback.String())
+	}
+}
+
+func TestDrainBufferedWithoutBuffer(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+	p.setErr(errFluentdDown)
+	l.Infow("a")
+	if recs := l.DrainBuffered(); recs != nil {
+		t.Errorf("DrainBuffered = %v, want nil without OfflineBuffer", recs)
+	}
+}
+
+// pushAll pushes a record per tag of tags into b and returns the tags of
+// the evicted records.
+func pushAll(b *offlineBuffer, tags ...string) []string {
+	var evicted []string
+	for _, tag := range tags {
+		for _, r := range b.push(bufferedRecord{tag: tag, record: map[string]interface{}{}}) {
+			evicted = append(evicted, r.tag)
+		}
+	}
+	return evicted
+}
+
+// bufferedTags returns the tags of the records held by b, oldest first.
+func bufferedTags(b *offlineBuffer) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	tags := make([]string, len(b.items))
+	for i, r := range b.items {
+		tags[i] = r.tag
+	}
+	return tags
+}
+
+func TestTagBufferLimits(t *testing.T) {
+	b := newOfflineBuffer(5, map[string]int{"noisy": 2, "off": 0}, 0)
+	evicted := pushAll(b, "noisy", "app", "noisy", "noisy", "app", "noisy")
+
+	if want := []string{"noisy", "noisy"}; !reflect.DeepEqual(evicted, want) {
+		t.Errorf("evicted %v, want the two oldest noisy records", evicted)
+	}
+	if got, want := bufferedTags(b), []string{"app", "noisy", "app", "noisy"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("buffered %v, want %v", got, want)
+	}
+
+	// The overall limit still applies to every tag, limited or not
+	evicted = pushAll(b, "app", "off")
+	if want := []string{"app"}; !reflect.DeepEqual(evicted, want) {
+		t.Errorf("evicted %v, want the oldest record overall", evicted)
+	}
+	if got, want := bufferedTags(b), []string{"noisy", "app", "noisy", "app", "off"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("buffered %v, want %v", got, want)
+	}
+}
+
+func TestTagBufferLimitsThroughLogger(t *testing.T) {
+	var fallback bytes.Buffer
+	l, p := newTestLogger(t, &SugaredLoggerConfig{
+		OfflineBuffer:   10,
+		TagBufferLimits: map[string]int{"app.noisy": 1},
+		Fallback:        &fallback,
+	})
+	p.setErr(errFluentdDown)
+	noisy := l.WithTag("app.noisy")
+	noisy.Infow("n1")
+	l.Infow("a1")
+	noisy.Infow("n2")
+
+	if got := messagesOf(l.DrainBuffered()); !reflect.DeepEqual(got, []interface{}{"a1", "n2"}) {
+		t.Errorf("buffered %v, want [a1 n2]", got)
+	}
+	if !strings.Contains(fallback.String(), `"n1"`) {
+		t.Errorf("fallback = %q, want the evicted noisy record", fallback.String())
+	}
+}
+
+// limitPoster delivers its first n posts, then fails with err.
+type limitPoster struct {
+	fakePoster
+	n   int
+	err error
+}
+
+func (p *limitPoster) PostWithTime(tag string, tm time.Time, message interface{}) error {
+	p.mu.Lock()
+	full := len(p.messages) >= p.n
+	p.mu.Unlock()
+	if full {
+		return p.err
+	}
+	return p.fakePoster.PostWithTime(tag, tm, message)
+}
+
+func TestReplayBufferedStopsAtFirstFailure(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{OfflineBuffer: 10})
+	p.setErr(errFluentdDown)
+	for _, msg := range []string{"a", "b", "c"} {
+		l.Infow(msg)
+	}
+
+	replay := &limitPoster{n: 1, err: errFluentdDown}
+	l.fluent.logger = replay
+	n, err := l.ReplayBuffered(context.Background())
+	if n != 1 || !errors.Is(err, errFluentdDown) {
+		t.Fatalf("ReplayBuffered = %d, %v, want 1, %v", n, err, errFluentdDown)
+	}
+	if got := messagesOf(replay.records()); !reflect.DeepEqual(got, []interface{}{"a"}) {
+		t.Errorf("replayed %v, want [a]", got)
+	}
+	if got := messagesOf(l.DrainBuffered()); !reflect.DeepEqual(got, []interface{}{"b", "c"}) {
+		t.Errorf("left buffered %v, want [b c] in order", got)
+	}
+}
+
+func TestReplayBuffered(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{OfflineBuffer: 10, BreakerThreshold: 1, BreakerCooldown: time.Hour})
+	p.setErr(errFluentdDown)
+	l.Infow("a")
+	l.Infow("b")
+	if s := l.CircuitState(); s != CircuitOpen {
+		t.Fatalf("CircuitState = %s, want open", s)
+	}
+
+	// ReplayBuffered does not wait for the circuit to close
+	p.setErr(nil)
+	n, err := l.ReplayBuffered(context.Background())
+	if n != 2 || err != nil {
+		t.Fatalf("ReplayBuffered = %d, %v, want 2, nil", n, err)
+	}
+	if got := messagesOf(p.records()); !reflect.DeepEqual(got, []interface{}{"a", "b"}) {
+		t.Errorf("delivered %v, want [a b]", got)
+	}
+}
+
+func TestReplayBufferedCanceled(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{OfflineBuffer: 10})
+	p.setErr(errFluentdDown)
+	l.Infow("a")
+	p.setErr(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if n, err := l.ReplayBuffered(ctx); n != 0 || !errors.Is(err, context.Canceled) {
+		t.Errorf("ReplayBuffered = %d, %v, want 0, context.Canceled", n, err)
+	}
+	if n := l.fluent.buffer.len(); n != 1 {
+		t.Errorf("%d records buffered, want the record kept", n)
+	}
+
+	_ = l.Close()
+	if _, err := l.ReplayBuffered(context.Background()); !errors.Is(err, ErrLoggerClosed) {
+		t.Errorf("ReplayBuffered after Close = %v, want ErrLoggerClosed", err)
+	}
+}
+
+func TestMaxQueueBytes(t *testing.T) {
+	record := func(msg string) map[string]interface{} {
+		return map[string]interface{}{"message": msg}
+	}
+	size := approxSize(record("aaaa"))
+	b := newOfflineBuffer(100, nil, 2*size)
+
+	for _, msg := range []string{"aaaa", "bbbb"} {
+		if evicted := b.push(bufferedRecord{tag: "app", record: record(msg)}); len(evicted) != 0 {
+			t.Fatalf("evicted %d records within the byte limit", len(evicted))
+		}
+	}
+	if got := b.size(); got != 2*size {
+		t.Errorf("size = %d, want %d", got, 2*size)
+	}
+
+	evicted := b.push(bufferedRecord{tag: "app", record: record("cccccccc")})
+	if len(evicted) != 2 || evicted[0].record["message"] != "aaaa" || evicted[1].record["message"] != "bbbb" {
+		t.Errorf("evicted %v, want the two oldest records to make room", evicted)
+	}
+	if got, want := b.size(), approxSize(record("cccccccc")); got != want {
+		t.Errorf("size = %d after eviction, want %d", got, want)
+	}
+
+	b.take()
+	if got := b.size(); got != 0 {
+		t.Errorf("size = %d after take, want 0", got)
+	}
+}
+
+func TestQueueBytes(t *testing.T) {
+	var fallback bytes.Buffer
+	l, p := newTestLogger(t, &SugaredLoggerConfig{OfflineBuffer: 100, MaxQueueBytes: 1 << 10, Fallback: &fallback})
+	p.setErr(errFluentdDown)
+	l.Infow("small")
+	if got := l.QueueBytes(); got <= 0 || got > 1<<10 {
+		t.Fatalf("QueueBytes = %d, want the size of one small record", got)
+	}
+
+	l.Infow("large", "payload", strings.Repeat("x", 2<<10))
+	if got := messagesOf(l.DrainBuffered()); len(got) != 0 {
+		t.Errorf("buffered %v, want nothing: the large record alone exceeds MaxQueueBytes", got)
+	}
+	if lines := strings.Count(fallback.String(), "\n"); lines != 2 {
+		t.Errorf("fallback got %d records, want both evicted", lines)
+	}
+	if got := l.QueueBytes(); got != 0 {
+		t.Errorf("QueueBytes = %d after draining, want 0", got)
+	}
+}
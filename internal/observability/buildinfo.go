@@ -0,0 +1,27 @@
+package observability
+
+import (
+	"runtime/debug"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// buildInfoFields returns the static fields added by IncludeBuildInfo:
+// go_version and, when the binary was built with VCS stamping, vcs.revision
+// and vcs.time. It returns nil if build info is unavailable.
+func buildInfoFields() []zapcore.Field {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+
+	fields := []zapcore.Field{zap.String("go_version", info.GoVersion)}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision", "vcs.time":
+			fields = append(fields, zap.String(s.Key, s.Value))
+		}
+	}
+	return fields
+}
@@ -0,0 +1,76 @@
+package observability
+
+import (
+	"path"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// CallerStyle selects how the caller field is written:
+//
+//	CallerShort            observability/core.go:42
+//	CallerFull             /src/app/internal/observability/core.go:42
+//	CallerPackageRelative  internal/observability/core.go:42
+//
+// CallerPackageRelative writes the import path of the calling package, with
+// the main module path trimmed, followed by the file name. Unlike
+// CallerShort it tells apart files of the same name in packages of the
+// same name, and unlike CallerFull it does not depend on where the binary
+// was built.
+type CallerStyle int
+
+const (
+	CallerShort CallerStyle = iota
+	CallerFull
+	CallerPackageRelative
+)
+
+// encoder returns the zapcore.CallerEncoder for the style.
+func (s CallerStyle) encoder() zapcore.CallerEncoder {
+	switch s {
+	case CallerFull:
+		return zapcore.FullCallerEncoder
+	case CallerPackageRelative:
+		return packageRelativeCallerEncoder
+	default:
+		return zapcore.ShortCallerEncoder
+	}
+}
+
+func packageRelativeCallerEncoder(caller zapcore.EntryCaller, enc zapcore.PrimitiveArrayEncoder) {
+	pkg := callerPackage(caller.Function)
+	if !caller.Defined || pkg == "" {
+		zapcore.ShortCallerEncoder(caller, enc)
+		return
+	}
+	if mod := mainModule(); mod != "" && strings.HasPrefix(pkg, mod+"/") {
+		pkg = strings.TrimPrefix(pkg, mod+"/")
+	}
+	enc.AppendString(pkg + "/" + path.Base(caller.File) + ":" + strconv.Itoa(caller.Line))
+}
+
+// callerPackage returns the import path of the package a fully qualified
+// function name, such as example.com/app/pkg.(*T).Method, belongs to.
+func callerPackage(function string) string {
+	// The package path ends at the first dot after the last slash.
+	slash := strings.LastIndexByte(function, '/')
+	dot := strings.IndexByte(function[slash+1:], '.')
+	if dot < 0 {
+		return ""
+	}
+	return function[:slash+1+dot]
+}
+
+// mainModule returns the path of the main module, or "" if build info is
+// unavailable.
+var mainModule = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	return info.Main.Path
+})
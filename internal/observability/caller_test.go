@@ -0,0 +1,102 @@
+package observability
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestCallerStyle(t *testing.T) {
+	tests := []struct {
+		style CallerStyle
+		want  *regexp.Regexp
+	}{
+		{CallerShort, regexp.MustCompile(`^observability/caller_test\.go:\d+$`)},
+		{CallerFull, regexp.MustCompile(`^/.+/internal/observability/caller_test\.go:\d+$`)},
+		{CallerPackageRelative, regexp.MustCompile(`/observability/caller_test\.go:\d+$`)},
+	}
+	for _, tt := range tests {
+		l, p := newTestLogger(t, &SugaredLoggerConfig{AddCaller: true, CallerStyle: tt.style})
+		l.Infow("m")
+
+		caller, _ := p.last(t)["caller"].(string)
+		if !tt.want.MatchString(caller) {
+			t.Errorf("style %d: caller = %q, want %v", tt.style, caller, tt.want)
+		}
+	}
+}
+
+func TestPackageRelativeCallerTrimsMainModule(t *testing.T) {
+	defer func(f func() string) { mainModule = f }(mainModule)
+	mainModule = func() string { return "github.com/niquet/go-fluentd-logger-poc" }
+
+	l, p := newTestLogger(t, &SugaredLoggerConfig{AddCaller: true, CallerStyle: CallerPackageRelative})
+	l.Infow("m")
+
+	caller, _ := p.last(t)["caller"].(string)
+	if !strings.HasPrefix(caller, "internal/observability/caller_test.go:") {
+		t.Errorf("caller = %q, want internal/observability/caller_test.go:<line>", caller)
+	}
+}
+
+func TestCallerPackage(t *testing.T) {
+	tests := map[string]string{
+		"example.com/app/pkg.(*T).Method": "example.com/app/pkg",
+		"example.com/app/pkg.Func.func1":  "example.com/app/pkg",
+		"main.main":                       "main",
+		"nodot":                           "",
+	}
+	for function, want := range tests {
+		if got := callerPackage(function); got != want {
+			t.Errorf("callerPackage(%q) = %q, want %q", function, got, want)
+		}
+	}
+}
+
+type funcNameHandler struct{ l *SugaredLogger }
+
+func (h *funcNameHandler) handle() { h.l.WithComponent("billing").Infow("handled") }
+
+func logFromHelper(l *SugaredLogger) { l.Ctx(context.Background()).Infow("helper") }
+
+func TestIncludeFuncName(t *testing.T) {
+	const pkg = "github.com/niquet/go-fluentd-logger-poc/internal/observability."
+	l, p := newTestLogger(t, &SugaredLoggerConfig{IncludeFuncName: true})
+
+	l.Infow("direct")
+	(&funcNameHandler{l}).handle()
+	logFromHelper(l)
+
+	want := []string{
+		pkg + "TestIncludeFuncName",
+		pkg + "(*funcNameHandler).handle",
+		pkg + "logFromHelper",
+	}
+	recs := p.records()
+	for i, w := range want {
+		if got := recs[i][defaultFuncNameKey]; got != w {
+			t.Errorf("%s: func = %v, want %s", recs[i]["message"], got, w)
+		}
+		if _, ok := recs[i]["caller"]; !ok {
+			t.Errorf("%s: no caller field, want IncludeFuncName to imply AddCaller", recs[i]["message"])
+		}
+	}
+}
+
+func TestIncludeFuncNameKeyFromEncoderConfig(t *testing.T) {
+	enc := ECSEncoderConfig()
+	l, p := newTestLogger(t, &SugaredLoggerConfig{IncludeFuncName: true, EncoderConfig: &enc})
+	l.Infow("m")
+	if got, _ := p.last(t)[enc.FunctionKey].(string); !strings.HasSuffix(got, ".TestIncludeFuncNameKeyFromEncoderConfig") {
+		t.Errorf("%s = %q, want this test", enc.FunctionKey, got)
+	}
+}
+
+func TestIncludeFuncNameOff(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+	l.Infow("m")
+	if _, ok := p.last(t)[defaultFuncNameKey]; ok {
+		t.Error("func field added without IncludeFuncName")
+	}
+}
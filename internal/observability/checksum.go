@@ -0,0 +1,118 @@
+package observability
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// addChecksum stores in entry[field] the checksum of the rest of entry. If
+// the checksum cannot be computed, entry is left without the field.
+func addChecksum(entry map[string]interface{}, field string) error {
+	delete(entry, field)
+	sum, err := checksum(entry)
+	if err != nil {
+		return fmt.Errorf("log checksum failed: %w", err)
+	}
+	entry[field] = sum
+	return nil
+}
+
+// checksum returns the hex SHA-256 of the canonical form of entry: the
+// JSON encoding, which sorts the keys of every object, of entry with every
+// number converted to float64. The number conversion makes the checksum
+// independent of how a number is represented, so that it survives an
+// int becoming a float64, 3 being rendered as 3.0 or json.Number being
+// used, as happens when records go through msgpack or JSON decoders. In
+// exchange, integers beyond 2^53 are only covered to float64 precision.
+func checksum(entry map[string]interface{}) (string, error) {
+	canonical, err := json.Marshal(canonicalNumbers(entry))
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// canonicalNumbers returns a copy of v, a record value, with every number
+// converted to float64, including those nested in maps, slices and arrays.
+func canonicalNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil, string, bool, []byte:
+		return v
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			out[k] = canonicalNumbers(e)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			out[i] = canonicalNumbers(e)
+		}
+		return out
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return nil
+		}
+		out := make([]interface{}, rv.Len())
+		for i := range out {
+			out[i] = canonicalNumbers(rv.Index(i).Interface())
+		}
+		return out
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return v
+		}
+		out := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			out[iter.Key().String()] = canonicalNumbers(iter.Value().Interface())
+		}
+		return out
+	}
+	return v
+}
+
+// VerifyChecksum reports whether entry, a record written with
+// ChecksumField set to field, still matches the checksum it carries. It
+// returns an error if the record has no checksum or cannot be encoded.
+// entry may come from any decoder: numbers are compared as float64
+// whatever their type, so ints decoded as floats and json.Number both
+// verify.
+func VerifyChecksum(entry map[string]interface{}, field string) (bool, error) {
+	want, ok := entry[field].(string)
+	if !ok {
+		return false, fmt.Errorf("record has no checksum field %q", field)
+	}
+
+	rest := make(map[string]interface{}, len(entry))
+	for k, v := range entry {
+		if k != field {
+			rest[k] = v
+		}
+	}
+	got, err := checksum(rest)
+	if err != nil {
+		return false, err
+	}
+	return got == want, nil
+}
@@ -0,0 +1,109 @@
+package observability
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestChecksumRoundTrip(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{ChecksumField: "sha256"})
+	l.Infow("paid", "amount", 12.5, "order", map[string]interface{}{"id": 9007199254740993, "items": []string{"a", "b"}})
+
+	rec := p.last(t)
+	ok, err := VerifyChecksum(rec, "sha256")
+	if err != nil || !ok {
+		t.Fatalf("VerifyChecksum = %v, %v, want true", ok, err)
+	}
+
+	// As read back from a JSON archive
+	b, err := json.Marshal(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var archived map[string]interface{}
+	if err := dec.Decode(&archived); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyChecksum(archived, "sha256"); err != nil || !ok {
+		t.Errorf("VerifyChecksum of the archived record = %v, %v, want true", ok, err)
+	}
+
+	archived["amount"] = json.Number("1250")
+	if ok, err := VerifyChecksum(archived, "sha256"); err != nil || ok {
+		t.Errorf("VerifyChecksum of a tampered record = %v, %v, want false", ok, err)
+	}
+}
+
+func TestChecksumSurvivesDecoders(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{ChecksumField: "sha256"})
+	l.Infow("paid", "count", 3, "amount", 12.5, "ids", []int{1, 2}, "order", map[string]interface{}{"id": int64(1<<53 + 1)})
+	rec := p.last(t)
+
+	b, err := msgp.AppendIntf(nil, rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromMsgpack, _, err := msgp.ReadMapStrIntfBytes(b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	j, err := json.Marshal(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromJSON map[string]interface{}
+	if err := json.Unmarshal(j, &fromJSON); err != nil {
+		t.Fatal(err)
+	}
+	if _, isFloat := fromJSON["count"].(float64); !isFloat {
+		t.Fatalf("count decoded as %T, want float64", fromJSON["count"])
+	}
+
+	for name, archived := range map[string]map[string]interface{}{"msgpack": fromMsgpack, "json": fromJSON} {
+		if ok, err := VerifyChecksum(archived, "sha256"); err != nil || !ok {
+			t.Errorf("VerifyChecksum after a %s round trip = %v, %v, want true", name, ok, err)
+		}
+	}
+
+	fromMsgpack["count"] = json.Number("3.0")
+	fromMsgpack["amount"] = json.Number("1.25e1")
+	if ok, err := VerifyChecksum(fromMsgpack, "sha256"); err != nil || !ok {
+		t.Errorf("VerifyChecksum with numbers rendered as 3.0 and 1.25e1 = %v, %v, want true", ok, err)
+	}
+	fromMsgpack["count"] = int64(4)
+	if ok, err := VerifyChecksum(fromMsgpack, "sha256"); err != nil || ok {
+		t.Errorf("VerifyChecksum of a tampered record = %v, %v, want false", ok, err)
+	}
+}
+
+func TestVerifyChecksumWithoutField(t *testing.T) {
+	if _, err := VerifyChecksum(map[string]interface{}{"message": "m"}, "sha256"); err == nil {
+		t.Error("VerifyChecksum accepted a record without a checksum")
+	}
+}
+
+func TestChecksumUnencodableRecord(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{ChecksumField: "sha256"})
+	var self bytes.Buffer
+	l.fluent.self = &selfLogger{w: &self}
+
+	l.Infow("ratio", "value", math.NaN())
+	recs := p.records()
+	if len(recs) != 1 {
+		t.Fatalf("%d records posted, want the record without its checksum", len(recs))
+	}
+	if _, ok := recs[0]["sha256"]; ok {
+		t.Error("record carries a checksum")
+	}
+	if !strings.Contains(self.String(), "record posted without checksum") {
+		t.Errorf("self-log = %q, want a warning", self.String())
+	}
+}
@@ -0,0 +1,78 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// stuckPoster accepts posts but blocks in Close until released, like a
+// client whose flush hangs on an unresponsive Fluentd.
+type stuckPoster struct {
+	fakePoster
+	release chan struct{}
+}
+
+func (p *stuckPoster) Close() error {
+	<-p.release
+	return nil
+}
+
+func newStuckLogger(t *testing.T, cfg *SugaredLoggerConfig) *SugaredLogger {
+	t.Helper()
+	if cfg == nil {
+		cfg = &SugaredLoggerConfig{}
+	}
+	cfg.FluentConfig.Timeout = time.Minute
+	l, _ := newTestLogger(t, cfg)
+	p := &stuckPoster{release: make(chan struct{})}
+	l.fluent.logger = p
+	t.Cleanup(func() { close(p.release) })
+	return l
+}
+
+func TestCloseContextCanceled(t *testing.T) {
+	l := newStuckLogger(t, nil)
+	l.Infow("m")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	err := l.CloseContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("CloseContext = %v, want context.Canceled", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("CloseContext took %v with a canceled context", d)
+	}
+	if err := l.Close(); err != nil {
+		t.Errorf("second Close = %v, want nil", err)
+	}
+}
+
+func TestShutdownContextAbortsClose(t *testing.T) {
+	sc, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l := newStuckLogger(t, &SugaredLoggerConfig{ShutdownContext: sc})
+
+	done := make(chan error, 1)
+	go func() { done <- l.Close() }()
+
+	select {
+	case err := <-done:
+		t.Fatalf("Close returned %v before the flush was aborted", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// A second signal cancels the shutdown context
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Close = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close did not return after ShutdownContext was canceled")
+	}
+}
@@ -0,0 +1,91 @@
+package observability
+
+import (
+	"os"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/term"
+)
+
+// consoleLevel is the lowest level AutoConsole prints; debug output stays
+// in Fluent only.
+const consoleLevel = zapcore.InfoLevel
+
+// isTerminal reports whether f is attached to a terminal.
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// newConsoleCore returns a core printing colored, human-readable entries to
+// stdout at consoleLevel and above, as long as level also enables them.
+func newConsoleCore(level zapcore.LevelEnabler) zapcore.Core {
+	enc := zapcore.EncoderConfig{
+		TimeKey:          "time",
+		LevelKey:         "level",
+		NameKey:          "logger",
+		CallerKey:        "caller",
+		MessageKey:       "msg",
+		StacktraceKey:    "stacktrace",
+		LineEnding:       zapcore.DefaultLineEnding,
+		EncodeLevel:      zapcore.CapitalColorLevelEncoder,
+		EncodeTime:       zapcore.TimeEncoderOfLayout("15:04:05.000"),
+		EncodeDuration:   zapcore.StringDurationEncoder,
+		EncodeCaller:     zapcore.ShortCallerEncoder,
+		ConsoleSeparator: "  ",
+	}
+	enabled := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+		return lvl >= consoleLevel && level.Enabled(lvl)
+	})
+	return zapcore.NewCore(zapcore.NewConsoleEncoder(enc), zapcore.Lock(os.Stdout), enabled)
+}
+
+// teeCore writes entries to the Fluent core and a console core. Unlike
+// zapcore.NewTee it keeps the fluentCore reachable, so derived loggers can
+// still replace it (see withCore).
+type teeCore struct {
+	fluent  *fluentCore
+	console zapcore.Core
+}
+
+// fluentCoreOf returns the fluentCore behind c, if any.
+func fluentCoreOf(c zapcore.Core) (*fluentCore, bool) {
+	switch c := c.(type) {
+	case *fluentCore:
+		return c, true
+	case *teeCore:
+		return c.fluent, true
+	}
+	return nil, false
+}
+
+func (t *teeCore) Enabled(lvl zapcore.Level) bool {
+	return t.fluent.Enabled(lvl) || t.console.Enabled(lvl)
+}
+
+func (t *teeCore) With(fields []zapcore.Field) zapcore.Core {
+	return &teeCore{
+		fluent:  t.fluent.With(fields).(*fluentCore),
+		console: t.console.With(fields),
+	}
+}
+
+func (t *teeCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	// The console goes first, so an entry is on screen even when delivery
+	// to Fluent stalls.
+	ce = t.console.Check(ent, ce)
+	return t.fluent.Check(ent, ce)
+}
+
+func (t *teeCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	err := t.console.Write(ent, fields)
+	if fluentErr := t.fluent.Write(ent, fields); fluentErr != nil {
+		err = fluentErr
+	}
+	return err
+}
+
+func (t *teeCore) Sync() error {
+	_ = t.console.Sync()
+	return t.fluent.Sync()
+}
@@ -0,0 +1,354 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// fluentCore is a zapcore.Core that turns entries straight into Fluent
+// records. It replaces the JSON encoder + WriteSyncer pipeline, which
+// serialized every entry only for FluentLogger.Write to parse it again.
+type fluentCore struct {
+	zapcore.LevelEnabler
+	out    *FluentLogger
+	cfg    *SugaredLoggerConfig
+	enc    *zapcore.EncoderConfig
+	fields []zapcore.Field
+	// tag is the Fluent tag records are posted under; WithTag changes it
+	// for derived loggers. ctxTag is set when it came from the context of
+	// a Ctx logger, which TagFieldKey fields do not override.
+	tag    string
+	ctxTag bool
+	// ctx is the context a Ctx-derived logger was created with. Its
+	// deadline bounds how long sync writes may block.
+	ctx context.Context
+}
+
+func newFluentCore(out *FluentLogger, cfg *SugaredLoggerConfig, enc *zapcore.EncoderConfig, lvl zapcore.LevelEnabler) *fluentCore {
+	return &fluentCore{
+		LevelEnabler: lvl,
+		out:          out,
+		cfg:          cfg,
+		enc:          enc,
+		tag:          out.tag,
+		ctx:          context.Background(),
+	}
+}
+
+// With implements zapcore.Core. Context fields are kept unencoded and added
+// to each record on Write.
+func (c *fluentCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = append(c.fields[:len(c.fields):len(c.fields)], fields...)
+	return &clone
+}
+
+// withContext returns a copy of the core whose writes honor ctx.
+func (c *fluentCore) withContext(ctx context.Context) *fluentCore {
+	clone := *c
+	clone.ctx = ctx
+	return &clone
+}
+
+// withTag returns a copy of the core that posts under tag.
+func (c *fluentCore) withTag(tag string) *fluentCore {
+	clone := *c
+	clone.tag = tag
+	clone.ctxTag = false
+	return &clone
+}
+
+// withContextTag returns a copy of the core that posts under tag, taken
+// from the context of a Ctx logger.
+func (c *fluentCore) withContextTag(tag string) *fluentCore {
+	clone := c.withTag(tag)
+	clone.ctxTag = true
+	return clone
+}
+
+// Enabled implements zapcore.LevelEnabler. Levels listed in DiscardLevels
+// are never enabled, whatever the logger's level.
+func (c *fluentCore) Enabled(lvl zapcore.Level) bool {
+	return c.LevelEnabler.Enabled(lvl) && !c.out.discards(lvl)
+}
+
+// Check implements zapcore.Core. With SampleKeyFields, sampling is left to
+// Write, which sees the fields.
+func (c *fluentCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) && (c.out.sampler.keyed() || c.out.sampler.sample(ent)) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write implements zapcore.Core.
+func (c *fluentCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if c.out.sampler.keyed() && !c.out.sampler.sampleFields(ent, c.fields, fields) {
+		return nil
+	}
+	// The prefix is added here, once per entry, rather than by derived
+	// loggers, so it cannot be applied twice.
+	ent.Message = c.cfg.MessagePrefix + ent.Message
+	c, fields = c.tagOverride(fields)
+	c, fields, err := c.dedupe(fields)
+	if err != nil {
+		c.out.drop(c.tag)
+		return err
+	}
+
+	if s, ok := c.serializer(fields); ok {
+		err = c.writeSerialized(s, ent, fields)
+	} else {
+		err = c.writeRecord(ent, fields)
+	}
+	if err == nil {
+		c.out.countWrite()
+	}
+
+	if c.terminates(ent.Level) {
+		// Since we are crashing the program, sync the output.
+		_ = c.Sync()
+	} else if ent.Level > zapcore.ErrorLevel {
+		_ = c.out.flush()
+	}
+	return err
+}
+
+// tagOverride strips a TagFieldKey field from the call-site fields and
+// returns a core posting under its tag, unless the core's tag came from a
+// context. An invalid tag is reported to the self-logger and the core's own
+// tag is kept.
+func (c *fluentCore) tagOverride(fields []zapcore.Field) (*fluentCore, []zapcore.Field) {
+	for i, f := range fields {
+		if f.Key != TagFieldKey || f.Type != zapcore.StringType {
+			continue
+		}
+		rest := make([]zapcore.Field, 0, len(fields)-1)
+		rest = append(append(rest, fields[:i]...), fields[i+1:]...)
+		if c.ctxTag {
+			return c, rest
+		}
+		if err := ValidateTag(f.String); err != nil {
+			c.out.self.warn("invalid tag ignored", "tag", f.String, "error", err)
+			return c, rest
+		}
+		return c.withTag(f.String), rest
+	}
+	return c, fields
+}
+
+// writeRecord posts ent through the generic path.
+func (c *fluentCore) writeRecord(ent zapcore.Entry, fields []zapcore.Field) error {
+	record, err := c.record(ent, fields)
+	if err != nil {
+		c.out.drop(c.tag)
+		return err
+	}
+	if c.cfg.IncludeGoroutineID {
+		record[goroutineIDKey] = goroutineID()
+	}
+	if c.cfg.DualTimestamp {
+		c.addDualTimestamp(record, ent.Time)
+	}
+	if c.out.syncFlushes(ent.Level) {
+		if err := c.out.postSync(c.ctx, c.tag, ent.Time, record); err != nil {
+			return err
+		}
+		return c.out.flush()
+	}
+	return c.out.post(c.ctx, c.tag, ent.Time, record)
+}
+
+// addDualTimestamp adds the DualTimestamp field to record: Unix seconds if
+// the primary timestamp is a string, an RFC 3339 string otherwise.
+func (c *fluentCore) addDualTimestamp(record map[string]interface{}, t time.Time) {
+	key := c.cfg.DualTimestampKey
+	if key == "" {
+		key = defaultDualTimestampKey
+	}
+	if _, ok := record[c.enc.TimeKey].(string); ok {
+		record[key] = float64(t.UnixNano()) / float64(time.Second)
+	} else {
+		record[key] = t.Format(time.RFC3339Nano)
+	}
+}
+
+// terminates reports whether zap panics or exits after writing an entry at
+// lvl. Syncing closes the transport, so it is reserved for those entries.
+func (c *fluentCore) terminates(lvl zapcore.Level) bool {
+	return lvl > zapcore.DPanicLevel || lvl == zapcore.DPanicLevel && c.cfg.Development
+}
+
+// Sync implements zapcore.Core.
+func (c *fluentCore) Sync() error {
+	return c.out.Sync()
+}
+
+// encode builds the record for ent using the key layout of the encoder
+// config, followed by base, context and call-site fields.
+func (c *fluentCore) encode(ent zapcore.Entry, fields []zapcore.Field) map[string]interface{} {
+	enc := newMapEncoder(c.enc, 6+len(c.fields)+len(fields))
+	cfg := c.enc
+
+	if cfg.TimeKey != "" {
+		enc.fields[cfg.TimeKey] = encodeTime(cfg, ent.Time)
+	}
+	if cfg.LevelKey != "" && cfg.EncodeLevel != nil {
+		v := &sliceEncoder{cfg: cfg}
+		cfg.EncodeLevel(ent.Level, v)
+		enc.fields[cfg.LevelKey] = v.value()
+	}
+	if key := c.cfg.SeverityNumberKey; key != "" {
+		enc.fields[key] = c.cfg.SeverityScheme.number(ent.Level)
+	}
+	if cfg.NameKey != "" && ent.LoggerName != "" {
+		enc.fields[cfg.NameKey] = ent.LoggerName
+	}
+	if cfg.CallerKey != "" && ent.Caller.Defined && cfg.EncodeCaller != nil {
+		v := &sliceEncoder{cfg: cfg}
+		cfg.EncodeCaller(ent.Caller, v)
+		enc.fields[cfg.CallerKey] = v.value()
+	}
+	if cfg.FunctionKey != "" && ent.Caller.Defined {
+		enc.fields[cfg.FunctionKey] = ent.Caller.Function
+	}
+	if cfg.MessageKey != "" {
+		enc.fields[cfg.MessageKey] = ent.Message
+	}
+	if cfg.StacktraceKey != "" && ent.Stack != "" {
+		enc.fields[cfg.StacktraceKey] = ent.Stack
+	}
+
+	for _, f := range c.out.baseFields() {
+		c.addField(enc, ent.Level, f)
+	}
+	for _, f := range c.fields {
+		c.addField(enc, ent.Level, f)
+	}
+	for _, f := range fields {
+		c.addField(enc, ent.Level, f)
+	}
+	return enc.fields
+}
+
+// record builds the record for ent. While an encoder is set with
+// SetEncoder, the entry is rendered by it and decoded back into a map;
+// otherwise it is built directly by encode.
+func (c *fluentCore) record(ent zapcore.Entry, fields []zapcore.Field) (map[string]interface{}, error) {
+	h := c.out.encoder.Load()
+	if h == nil {
+		return c.encode(ent, fields), nil
+	}
+
+	base := c.out.baseFields()
+	all := make([]zapcore.Field, 0, len(base)+len(c.fields)+len(fields))
+	for _, fs := range [][]zapcore.Field{base, c.fields} {
+		for _, f := range fs {
+			if f, ok := c.resolveField(ent.Level, f); ok {
+				all = append(all, f)
+			}
+		}
+	}
+	for _, f := range fields {
+		if f, ok := c.resolveField(ent.Level, f); ok {
+			all = append(all, f)
+		}
+	}
+
+	buf, err := h.enc.EncodeEntry(ent, all)
+	if err != nil {
+		return nil, fmt.Errorf("log encode failed: %w", err)
+	}
+	defer buf.Free()
+	record, err := c.out.decode(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	if key := c.cfg.SeverityNumberKey; key != "" {
+		record[key] = c.cfg.SeverityScheme.number(ent.Level)
+	}
+	return record, nil
+}
+
+func (c *fluentCore) addField(enc *mapEncoder, lvl zapcore.Level, f zapcore.Field) {
+	if f, ok := c.resolveField(lvl, f); ok {
+		f.AddTo(enc)
+	}
+}
+
+// resolveField applies DebugField, LazyField, ValueTransformer and
+// PreferStringer to f, reporting false if f is to be left out of an entry
+// at lvl.
+func (c *fluentCore) resolveField(lvl zapcore.Level, f zapcore.Field) (zapcore.Field, bool) {
+	if dv, ok := f.Interface.(debugValue); ok && f.Type == zapcore.ReflectType {
+		if lvl > zapcore.DebugLevel {
+			return f, false
+		}
+		f = zap.Any(f.Key, dv.v)
+	}
+	if lv, ok := f.Interface.(lazyValue); ok && f.Type == zapcore.ReflectType {
+		f = zap.Any(f.Key, lv.fn())
+	}
+	if c.cfg.ValueTransformer != nil {
+		if v, ok := fieldValue(f); ok {
+			f = zap.Any(f.Key, c.cfg.ValueTransformer(f.Key, v))
+		}
+	}
+	if c.cfg.PreferStringer && f.Type == zapcore.ReflectType {
+		f = zap.Any(f.Key, preferStringer(f.Interface, 0))
+	}
+	return f, true
+}
+
+// fieldValue recovers the Go value a field was constructed from. It reports
+// false for fields that carry no value of their own, such as namespaces.
+func fieldValue(f zapcore.Field) (interface{}, bool) {
+	switch f.Type {
+	case zapcore.BoolType:
+		return f.Integer == 1, true
+	case zapcore.DurationType:
+		return time.Duration(f.Integer), true
+	case zapcore.Float64Type:
+		return math.Float64frombits(uint64(f.Integer)), true
+	case zapcore.Float32Type:
+		return math.Float32frombits(uint32(f.Integer)), true
+	case zapcore.Int64Type:
+		return f.Integer, true
+	case zapcore.Int32Type:
+		return int32(f.Integer), true
+	case zapcore.Int16Type:
+		return int16(f.Integer), true
+	case zapcore.Int8Type:
+		return int8(f.Integer), true
+	case zapcore.Uint64Type:
+		return uint64(f.Integer), true
+	case zapcore.Uint32Type:
+		return uint32(f.Integer), true
+	case zapcore.Uint16Type:
+		return uint16(f.Integer), true
+	case zapcore.Uint8Type:
+		return uint8(f.Integer), true
+	case zapcore.UintptrType:
+		return uintptr(f.Integer), true
+	case zapcore.StringType:
+		return f.String, true
+	case zapcore.TimeType:
+		t := time.Unix(0, f.Integer)
+		if loc, ok := f.Interface.(*time.Location); ok {
+			t = t.In(loc)
+		}
+		return t, true
+	case zapcore.ArrayMarshalerType, zapcore.ObjectMarshalerType,
+		zapcore.BinaryType, zapcore.ByteStringType,
+		zapcore.Complex128Type, zapcore.Complex64Type,
+		zapcore.TimeFullType, zapcore.ReflectType,
+		zapcore.StringerType, zapcore.ErrorType:
+		return f.Interface, true
+	}
+	return nil, false
+}
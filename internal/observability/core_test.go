@@ -0,0 +1,180 @@
+package observability
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// nopPoster discards everything, so benchmarks measure the logger alone.
+type nopPoster struct{}
+
+func (nopPoster) PostWithTime(string, time.Time, interface{}) error { return nil }
+func (nopPoster) Close() error                                      { return nil }
+
+func newBenchLogger(b *testing.B, cfg *SugaredLoggerConfig) *SugaredLogger {
+	b.Helper()
+	if cfg == nil {
+		cfg = &SugaredLoggerConfig{}
+	}
+	cfg.Tag, cfg.LogLevel = defaultFluentTag, "debug"
+	cfg.FluentConfig.Timeout = time.Second
+	cfg.Trace = cfg.Trace.withDefaults()
+	l := newSugaredLogger(cfg, nopPoster{})
+	b.Cleanup(func() { _ = l.Close() })
+	return l
+}
+
+// coreOf returns the fluentCore behind l.
+func coreOf(l *SugaredLogger) *fluentCore {
+	return l.Desugar().Core().(*fluentCore)
+}
+
+var benchFields = []zapcore.Field{
+	zap.String("user", "ada"),
+	zap.Int("attempt", 3),
+	zap.Duration("elapsed", 42*time.Millisecond),
+	zap.Bool("cached", true),
+	zap.Strings("roles", []string{"admin", "dev"}),
+}
+
+// jsonRoundTrip is the pipeline fluentCore replaced: the entry is encoded
+// to JSON by zap, then parsed back into a map for the transport.
+func jsonRoundTrip(p poster, enc zapcore.Encoder, ent zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		return err
+	}
+	return p.PostWithTime(defaultFluentTag, ent.Time, record)
+}
+
+func BenchmarkWrite(b *testing.B) {
+	ent := zapcore.Entry{Level: zapcore.InfoLevel, Time: time.Now(), Message: "request served"}
+
+	b.Run("core", func(b *testing.B) {
+		core := coreOf(newBenchLogger(b, nil))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := core.Write(ent, benchFields); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("json_round_trip", func(b *testing.B) {
+		enc := zapcore.NewJSONEncoder(*coreOf(newBenchLogger(b, nil)).enc)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := jsonRoundTrip(nopPoster{}, enc, ent, benchFields); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkSugaredInfow(b *testing.B) {
+	l := newBenchLogger(b, nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Infow("request served", "user", "ada", "attempt", 3, "cached", true)
+	}
+}
+
+func BenchmarkWithFields(b *testing.B) {
+	l := newBenchLogger(b, nil).With("service", "billing", "region", "eu-west-1")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Infow("request served", "user", "ada", "attempt", 3)
+	}
+}
+
+// TestWriteAllocatesLessThanJSONRoundTrip guards the point of fluentCore:
+// building the record directly must stay cheaper than encoding it to JSON
+// and parsing it back.
+func TestWriteAllocatesLessThanJSONRoundTrip(t *testing.T) {
+	l, _ := newTestLogger(t, nil)
+	ent := zapcore.Entry{Level: zapcore.InfoLevel, Time: time.Now(), Message: "request served"}
+	c := coreOf(l)
+	enc := zapcore.NewJSONEncoder(*c.enc)
+
+	core := testing.AllocsPerRun(100, func() {
+		_ = c.Write(ent, benchFields)
+	})
+	roundTrip := testing.AllocsPerRun(100, func() {
+		_ = jsonRoundTrip(nopPoster{}, enc, ent, benchFields)
+	})
+	if core >= roundTrip {
+		t.Errorf("Write allocates %.0f times per entry, the JSON round trip %.0f", core, roundTrip)
+	}
+}
+
+func TestWriteEncodesFieldTypes(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+	if err := coreOf(l).Write(zapcore.Entry{Level: zapcore.WarnLevel, Time: time.Now(), Message: "m"}, benchFields); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := p.last(t)
+	want := map[string]interface{}{
+		"user":     "ada",
+		"attempt":  int64(3),
+		"elapsed":  "42ms",
+		"cached":   true,
+		"severity": "warn",
+		"message":  "m",
+	}
+	for k, v := range want {
+		if rec[k] != v {
+			t.Errorf("%s = %#v, want %#v", k, rec[k], v)
+		}
+	}
+	if roles, ok := rec["roles"].([]interface{}); !ok || len(roles) != 2 || roles[0] != "admin" {
+		t.Errorf("roles = %#v", rec["roles"])
+	}
+}
+
+func TestWriteReportsTransportError(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+	p.setErr(errors.New("connection refused"))
+
+	err := coreOf(l).Write(zapcore.Entry{Level: zapcore.InfoLevel, Time: time.Now()}, nil)
+	if err == nil {
+		t.Fatal("Write returned nil for a failed delivery")
+	}
+	if got := l.Stats().Dropped; got != 1 {
+		t.Errorf("Dropped = %d, want 1", got)
+	}
+}
+
+func TestWritePreservesNumericTypes(t *testing.T) {
+	for _, tt := range []struct {
+		field zapcore.Field
+		want  interface{}
+	}{
+		{zap.Int("v", 3), int64(3)},
+		{zap.Int64("v", -1<<40), int64(-1 << 40)},
+		{zap.Int32("v", 7), int32(7)},
+		{zap.Uint("v", 3), uint64(3)},
+		{zap.Uint64("v", 1<<63), uint64(1 << 63)},
+		{zap.Bool("v", false), false},
+		{zap.Float64("v", 3), float64(3)},
+		{zap.Float32("v", 1.5), float32(1.5)},
+		{zap.Any("v", 3), int64(3)},
+	} {
+		l, p := newTestLogger(t, nil)
+		l.Desugar().Info("m", tt.field)
+		if got := p.last(t)["v"]; got != tt.want {
+			t.Errorf("%v: posted %#v (%T), want %#v (%T)", tt.field.Type, got, got, tt.want, tt.want)
+		}
+	}
+}
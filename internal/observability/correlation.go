@@ -0,0 +1,61 @@
+package observability
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// CorrelationIDKey is the field Ctx attaches the correlation ID of its
+// context under.
+const CorrelationIDKey = "correlation_id"
+
+type correlationIDContextKey struct{}
+
+// ContextWithCorrelationID returns ctx carrying a correlation ID, and the
+// ID. If ctx already carries one, e.g. set by a caller further up, ctx is
+// returned unchanged with that ID; otherwise a random UUIDv4 is generated.
+// Call it once at the start of a logical operation, such as in the
+// middleware handling a request:
+//
+//	ctx, id := observability.ContextWithCorrelationID(r.Context())
+//	w.Header().Set("X-Correlation-ID", id)
+//	logger.Ctx(ctx).Infow("request received")
+//
+// Loggers derived with Ctx from the returned context attach the ID as
+// correlation_id, giving request correlation without full tracing. Ctx
+// generates an ID of its own for a context without one.
+func ContextWithCorrelationID(ctx context.Context) (context.Context, string) {
+	if id, ok := CorrelationIDFromContext(ctx); ok {
+		return ctx, id
+	}
+	id := newUUID()
+	return context.WithValue(ctx, correlationIDContextKey{}, id), id
+}
+
+// CorrelationIDFromContext returns the correlation ID carried by ctx.
+func CorrelationIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(correlationIDContextKey{}).(string)
+	return id, ok && id != ""
+}
+
+// newUUID returns a random (version 4) UUID in its canonical form.
+func newUUID() string {
+	var b [16]byte
+	// crypto/rand.Read never fails on supported platforms.
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	var s [36]byte
+	hex.Encode(s[0:8], b[0:4])
+	s[8] = '-'
+	hex.Encode(s[9:13], b[4:6])
+	s[13] = '-'
+	hex.Encode(s[14:18], b[6:8])
+	s[18] = '-'
+	hex.Encode(s[19:23], b[8:10])
+	s[23] = '-'
+	hex.Encode(s[24:], b[10:])
+	return string(s[:])
+}
@@ -0,0 +1,55 @@
+package observability
+
+import (
+	"context"
+	"regexp"
+	"testing"
+)
+
+var uuidV4 = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestContextWithCorrelationID(t *testing.T) {
+	ctx, id := ContextWithCorrelationID(context.Background())
+	if !uuidV4.MatchString(id) {
+		t.Errorf("generated ID %q is not a UUIDv4", id)
+	}
+	if got, ok := CorrelationIDFromContext(ctx); !ok || got != id {
+		t.Errorf("CorrelationIDFromContext = %q, %v, want %q", got, ok, id)
+	}
+	if _, again := ContextWithCorrelationID(ctx); again != id {
+		t.Errorf("ContextWithCorrelationID replaced %q with %q", id, again)
+	}
+}
+
+func TestCtxCorrelationIDFromContext(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+	ctx, id := ContextWithCorrelationID(context.Background())
+	l.Ctx(ctx).Infow("first")
+	l.Ctx(ctx).Infow("second")
+
+	for _, rec := range p.records() {
+		if rec[CorrelationIDKey] != id {
+			t.Errorf("%s = %v, want %q", CorrelationIDKey, rec[CorrelationIDKey], id)
+		}
+	}
+}
+
+func TestCtxGeneratesCorrelationID(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+	op := l.Ctx(context.Background())
+	op.Infow("first")
+	op.Infow("second")
+	l.Ctx(context.Background()).Infow("other operation")
+
+	recs := p.records()
+	id, _ := recs[0][CorrelationIDKey].(string)
+	if !uuidV4.MatchString(id) {
+		t.Fatalf("%s = %v, want a generated UUIDv4", CorrelationIDKey, recs[0][CorrelationIDKey])
+	}
+	if recs[1][CorrelationIDKey] != id {
+		t.Errorf("entries of one derived logger carry %v and %v, want the same ID", id, recs[1][CorrelationIDKey])
+	}
+	if recs[2][CorrelationIDKey] == id {
+		t.Error("a second Ctx logger reused the generated ID")
+	}
+}
@@ -0,0 +1,91 @@
+package observability
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const counterMessage = "counter"
+
+// Count adds delta to the counter name. Counters are not logged one call
+// at a time: every CounterFlushInterval, one info entry per counter that
+// changed is posted with its accumulated value, and the counter restarts
+// at zero. Close posts the counts accumulated since the last interval.
+//
+// Count is safe for concurrent use and does not allocate once a counter
+// exists. Counters are never forgotten, so names should come from a small
+// fixed set. Count does nothing unless CounterFlushInterval is positive.
+func (l *SugaredLogger) Count(name string, delta int64) {
+	if l.cfg.CounterFlushInterval <= 0 {
+		return
+	}
+	f := l.fluent
+	c, ok := f.counters.Load(name)
+	if !ok {
+		c, _ = f.counters.LoadOrStore(name, new(atomic.Int64))
+	}
+	c.(*atomic.Int64).Add(delta)
+}
+
+// flushCountersEvery posts the counters every interval until ctx is done.
+func (l *SugaredLogger) flushCountersEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := l.flushCounters(); err != nil {
+				l.fluent.self.warn("counter flush failed", "error", err)
+			}
+		}
+	}
+}
+
+// flushCounters posts one entry per non-zero counter, in name order, and
+// resets the counters it posted. The entries bypass sampling, which would
+// otherwise drop aggregates, but not the level. A count that fails to post
+// is lost.
+func (l *SugaredLogger) flushCounters() error {
+	f := l.fluent
+	if !l.core.Enabled(zapcore.InfoLevel) {
+		return nil
+	}
+
+	counts := make(map[string]int64)
+	f.counters.Range(func(k, v interface{}) bool {
+		if n := v.(*atomic.Int64).Swap(0); n != 0 {
+			counts[k.(string)] = n
+		}
+		return true
+	})
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var firstErr error
+	for _, name := range names {
+		now := time.Now()
+		record := l.core.encode(zapcore.Entry{
+			Level:   zapcore.InfoLevel,
+			Time:    now,
+			Message: l.cfg.MessagePrefix + counterMessage,
+		}, []zapcore.Field{
+			zap.String("counter", name),
+			zap.Int64("count", counts[name]),
+		})
+		if err := f.post(context.Background(), f.tag, now, record); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,92 @@
+package observability
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// counts returns the counter and count of the counter records posted to p.
+func counts(p *fakePoster) [][2]interface{} {
+	var got [][2]interface{}
+	for _, r := range p.records() {
+		if r["message"] == counterMessage {
+			got = append(got, [2]interface{}{r["counter"], r["count"]})
+		}
+	}
+	return got
+}
+
+func TestCountFlushAndReset(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{CounterFlushInterval: time.Hour})
+	l.Count("b", 1)
+	l.Count("a", 2)
+	l.Count("a", 3)
+	l.Count("c", 0)
+
+	if err := l.flushCounters(); err != nil {
+		t.Fatal(err)
+	}
+	want := [][2]interface{}{{"a", int64(5)}, {"b", int64(1)}}
+	if got := counts(p); !reflect.DeepEqual(got, want) {
+		t.Errorf("posted %v, want %v", got, want)
+	}
+
+	// Counters restart at zero; unchanged ones are not posted
+	l.Count("b", 4)
+	if err := l.flushCounters(); err != nil {
+		t.Fatal(err)
+	}
+	want = append(want, [2]interface{}{"b", int64(4)})
+	if got := counts(p); !reflect.DeepEqual(got, want) {
+		t.Errorf("posted %v, want %v", got, want)
+	}
+
+	// Close posts what accumulated since the last flush
+	l.Count("a", 7)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want = append(want, [2]interface{}{"a", int64(7)})
+	if got := counts(p); !reflect.DeepEqual(got, want) {
+		t.Errorf("posted %v after Close, want %v", got, want)
+	}
+}
+
+func TestCountEveryInterval(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{CounterFlushInterval: 10 * time.Millisecond})
+	l.Count("requests", 3)
+
+	deadline := time.Now().Add(time.Second)
+	for len(counts(p)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("no counter posted within a second")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got := counts(p)[0]; got != [2]interface{}{"requests", int64(3)} {
+		t.Errorf("posted %v, want requests=3", got)
+	}
+}
+
+func TestCountDisabled(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+	l.Count("requests", 3)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := counts(p); len(got) != 0 {
+		t.Errorf("posted %v without CounterFlushInterval", got)
+	}
+}
+
+func TestCountBelowLevel(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{CounterFlushInterval: time.Hour, LogLevel: "warn"})
+	l.Count("requests", 3)
+	if err := l.flushCounters(); err != nil {
+		t.Fatal(err)
+	}
+	if got := counts(p); len(got) != 0 {
+		t.Errorf("posted %v at warn level", got)
+	}
+}
@@ -0,0 +1,134 @@
+package observability
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/fluent/fluent-logger-golang/fluent"
+)
+
+// datagramNetwork is the FluentNetwork of unix datagram sockets, which the
+// fluent client does not support.
+const datagramNetwork = "unixgram"
+
+// isUnixSocket reports whether network is one of the unix socket networks,
+// whose socket can disappear and reappear under the logger.
+func isUnixSocket(network string) bool {
+	return network == "unix" || network == datagramNetwork
+}
+
+// datagramPoster posts to a unix datagram socket (FluentNetwork
+// "unixgram", at FluentSocketPath): each record is sent as one
+// forward-protocol message in its own datagram, in msgpack. A record that
+// does not fit in a datagram fails to post. Sends are synchronous, so the
+// Async setting does not apply. MarshalAsJSON and RequestAck are rejected
+// by NewSugaredLogger: messages are always msgpack, and nothing is read
+// back from the socket.
+//
+// A failed send is retried once on a fresh socket, which recovers from
+// the socket being removed and recreated by a receiver restart.
+type datagramPoster struct {
+	cfg fluent.Config
+
+	mu     sync.Mutex
+	conn   net.Conn
+	buf    []byte
+	closed bool
+}
+
+func newDatagramPoster(cfg fluent.Config) (*datagramPoster, error) {
+	p := &datagramPoster{cfg: cfg}
+	if err := p.connect(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// validateDatagram checks that cfg can be served by a datagramPoster.
+func validateDatagram(cfg fluent.Config) error {
+	switch {
+	case cfg.FluentSocketPath == "":
+		return errors.New("FluentSocketPath required for unixgram network")
+	case cfg.MarshalAsJSON:
+		return errors.New("MarshalAsJSON is not supported on the unixgram network")
+	case cfg.RequestAck:
+		return errors.New("RequestAck is not supported on the unixgram network")
+	}
+	return nil
+}
+
+func (p *datagramPoster) PostWithTime(tag string, tm time.Time, message interface{}) error {
+	if p.cfg.TagPrefix != "" {
+		tag = p.cfg.TagPrefix + "." + tag
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return net.ErrClosed
+	}
+	var err error
+	if p.cfg.SubSecondPrecision {
+		msg := fluent.MessageExt{Tag: tag, Time: fluent.EventTime(tm), Record: message}
+		p.buf, err = msg.MarshalMsg(p.buf[:0])
+	} else {
+		msg := fluent.Message{Tag: tag, Time: tm.Unix(), Record: message}
+		p.buf, err = msg.MarshalMsg(p.buf[:0])
+	}
+	if err != nil {
+		return fmt.Errorf("fluent message encode failed: %w", err)
+	}
+
+	if err = p.send(); err == nil {
+		return nil
+	}
+	// The socket may have been recreated; retry once on a fresh one
+	if connErr := p.connect(); connErr != nil {
+		return errors.Join(err, connErr)
+	}
+	return p.send()
+}
+
+// send writes the encoded message. Callers must hold p.mu.
+func (p *datagramPoster) send() error {
+	if p.conn == nil {
+		return net.ErrClosed
+	}
+	if p.cfg.WriteTimeout > 0 {
+		p.conn.SetWriteDeadline(time.Now().Add(p.cfg.WriteTimeout))
+	}
+	_, err := p.conn.Write(p.buf)
+	return err
+}
+
+// connect replaces the socket with a new one. Callers must hold p.mu,
+// except in newDatagramPoster.
+func (p *datagramPoster) connect() error {
+	if p.conn != nil {
+		p.conn.Close()
+		p.conn = nil
+	}
+	conn, err := net.DialTimeout(datagramNetwork, p.cfg.FluentSocketPath, p.cfg.Timeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", p.cfg.FluentSocketPath, err)
+	}
+	p.conn = conn
+	return nil
+}
+
+func (p *datagramPoster) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	if p.conn == nil {
+		return nil
+	}
+	err := p.conn.Close()
+	p.conn = nil
+	return err
+}
@@ -0,0 +1,134 @@
+package observability
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fluent/fluent-logger-golang/fluent"
+	"github.com/tinylib/msgp/msgp"
+)
+
+// listenDatagram listens on a unix datagram socket at path. The socket is
+// closed and removed when the test ends.
+func listenDatagram(t *testing.T, path string) *net.UnixConn {
+	t.Helper()
+	conn, err := net.ListenUnixgram(datagramNetwork, &net.UnixAddr{Name: path, Net: datagramNetwork})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		os.Remove(path)
+	})
+	return conn
+}
+
+// readMessage reads one datagram from conn and decodes it as a
+// forward-protocol message: [tag, time, record, option].
+func readMessage(t *testing.T, conn *net.UnixConn) (string, map[string]interface{}) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 64<<10)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("read datagram: %v", err)
+	}
+	v, rest, err := msgp.ReadIntfBytes(buf[:n])
+	if err != nil {
+		t.Fatalf("decode datagram: %v", err)
+	}
+	if len(rest) != 0 {
+		t.Fatalf("%d trailing bytes in datagram", len(rest))
+	}
+	msg, ok := v.([]interface{})
+	if !ok || len(msg) != 4 {
+		t.Fatalf("datagram = %#v, want a 4-element message", v)
+	}
+	tag, _ := msg[0].(string)
+	rec, _ := msg[2].(map[string]interface{})
+	return tag, rec
+}
+
+func datagramConfig(path string) fluent.Config {
+	return fluent.Config{FluentNetwork: datagramNetwork, FluentSocketPath: path}
+}
+
+func TestDatagramTransport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fluent.sock")
+	conn := listenDatagram(t, path)
+
+	l, err := NewSugaredLogger(&SugaredLoggerConfig{FluentConfig: datagramConfig(path)})
+	if err != nil {
+		t.Fatalf("NewSugaredLogger: %v", err)
+	}
+	defer l.Close()
+	if err := l.Ping(context.Background()); err != nil {
+		t.Errorf("Ping: %v", err)
+	}
+
+	l.Infow("hello", "n", 1)
+	tag, rec := readMessage(t, conn)
+	if tag != defaultFluentTag {
+		t.Errorf("tag = %q, want %q", tag, defaultFluentTag)
+	}
+	if rec["message"] != "hello" || rec["n"] != int64(1) {
+		t.Errorf("record = %v", rec)
+	}
+}
+
+func TestDatagramTransportRecoversRecreatedSocket(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "fluent.sock")
+	first := listenDatagram(t, path)
+
+	l, err := NewSugaredLogger(&SugaredLoggerConfig{FluentConfig: datagramConfig(path)})
+	if err != nil {
+		t.Fatalf("NewSugaredLogger: %v", err)
+	}
+	defer l.Close()
+
+	// The receiver restarts: its socket is removed and created again
+	first.Close()
+	os.Remove(path)
+	second := listenDatagram(t, path)
+
+	l.Infow("after restart")
+	if _, rec := readMessage(t, second); rec["message"] != "after restart" {
+		t.Errorf("record = %v", rec)
+	}
+}
+
+func TestDatagramTransportRejectsStreamOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fluent.sock")
+	listenDatagram(t, path)
+
+	for _, cfg := range []fluent.Config{
+		{FluentNetwork: datagramNetwork},
+		{FluentNetwork: datagramNetwork, FluentSocketPath: path, MarshalAsJSON: true},
+		{FluentNetwork: datagramNetwork, FluentSocketPath: path, RequestAck: true},
+	} {
+		_, err := NewSugaredLogger(&SugaredLoggerConfig{FluentConfig: cfg})
+		if err == nil || !strings.Contains(err.Error(), "unixgram") {
+			t.Errorf("NewSugaredLogger(%+v) = %v, want a unixgram error", cfg, err)
+		}
+	}
+}
+
+func TestDatagramPosterClosed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fluent.sock")
+	listenDatagram(t, path)
+
+	p, err := newDatagramPoster(datagramConfig(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+	if err := p.PostWithTime("app", time.Now(), map[string]interface{}{}); err == nil {
+		t.Error("PostWithTime succeeded after Close")
+	}
+}
@@ -0,0 +1,76 @@
+package observability
+
+import "context"
+
+// TagFieldKey is the reserved field key that overrides the tag of a single
+// entry, the lightweight counterpart to WithTag:
+//
+//	logger.Infow("user deleted", observability.TagFieldKey, "app.audit")
+//
+// The field itself is not sent. Like WithTag, an invalid tag is reported to
+// the self-logger and the logger's tag is used instead. A tag carried by the
+// context of a Ctx-derived logger takes precedence; see ContextWithTag.
+const TagFieldKey = "_tag"
+
+type tagContextKey struct{}
+
+// ContextWithTag returns ctx carrying tag, so that loggers derived from it
+// with Ctx post under tag. It lets middleware decide the routing of a
+// request once, for handlers further down that just log through
+// logger.Ctx(ctx):
+//
+//	ctx = observability.ContextWithTag(ctx, "app.audit")
+//	...
+//	logger.Ctx(ctx).Infow("user deleted") // posted under app.audit
+//
+// The tag is validated by Ctx; an invalid one is reported to the
+// self-logger and ignored. The tag of an entry is, from highest to lowest
+// precedence: the context tag, a TagFieldKey field of the entry or the tag
+// argument of Event, then the logger's own tag (Tag, WithTag). A WithTag
+// derived from a Ctx logger replaces the context tag again.
+func ContextWithTag(ctx context.Context, tag string) context.Context {
+	return context.WithValue(ctx, tagContextKey{}, tag)
+}
+
+// tagFromContext returns the tag carried by ctx.
+func tagFromContext(ctx context.Context) (string, bool) {
+	tag, ok := ctx.Value(tagContextKey{}).(string)
+	return tag, ok
+}
+
+// Tag returns the Fluent tag entries of this logger are posted under.
+func (l *SugaredLogger) Tag() string {
+	if fc, ok := fluentCoreOf(l.Desugar().Core()); ok {
+		return fc.tag
+	}
+	return l.fluent.tag
+}
+
+// WithTag returns a derived logger that posts under tag. An invalid tag is
+// reported to the self-logger and the parent's tag is kept. Like all derived
+// loggers it shares, but does not own, the parent's transport.
+func (l *SugaredLogger) WithTag(tag string) *SugaredLogger {
+	if err := ValidateTag(tag); err != nil {
+		l.fluent.self.warn("invalid tag ignored", "tag", tag, "error", err)
+		return l.derive(l.SugaredLogger)
+	}
+	return l.derive(l.withCore(func(c *fluentCore) *fluentCore {
+		return c.withTag(tag)
+	}))
+}
+
+// WithComponent returns a derived logger for a component of the
+// application: entries carry a component=name field and are routed under
+// <tag>.<name>, so the field and the routing cannot disagree. If
+// <tag>.<name> is not a valid tag, neither is applied: the name is reported
+// to the self-logger and the derived logger behaves like its parent.
+func (l *SugaredLogger) WithComponent(name string) *SugaredLogger {
+	tag := l.Tag() + "." + name
+	if err := ValidateTag(tag); err != nil {
+		l.fluent.self.warn("invalid component ignored", "component", name, "error", err)
+		return l.derive(l.SugaredLogger)
+	}
+	child := l.WithTag(tag)
+	child.SugaredLogger = child.SugaredLogger.With("component", name)
+	return child
+}
@@ -0,0 +1,107 @@
+package observability
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithComponent(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+	l.WithComponent("billing").Infow("charged")
+
+	rec := p.last(t)
+	if rec["component"] != "billing" {
+		t.Errorf("component = %v, want billing", rec["component"])
+	}
+	tags := p.postedTags()
+	if want := defaultFluentTag + ".billing"; len(tags) != 1 || tags[0] != want {
+		t.Errorf("tags = %v, want [%s]", tags, want)
+	}
+}
+
+func TestWithComponentInvalidName(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+	var self bytes.Buffer
+	l.fluent.self = &selfLogger{w: &self}
+
+	l.WithComponent("bad name").Infow("charged")
+
+	rec := p.last(t)
+	if _, ok := rec["component"]; ok {
+		t.Errorf("record = %v, want no component field", rec)
+	}
+	if tags := p.postedTags(); len(tags) != 1 || tags[0] != defaultFluentTag {
+		t.Errorf("tags = %v, want [%s]", tags, defaultFluentTag)
+	}
+	if !strings.Contains(self.String(), "invalid component ignored") {
+		t.Errorf("self-log = %q, want a warning", self.String())
+	}
+}
+
+func TestWithTagInvalid(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+	l.WithTag("app..audit").Infow("m")
+	if tags := p.postedTags(); len(tags) != 1 || tags[0] != defaultFluentTag {
+		t.Errorf("tags = %v, want [%s]", tags, defaultFluentTag)
+	}
+}
+
+func TestMessagePrefixAppliedOnce(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{MessagePrefix: "[billing] "})
+	l.Infow("root")
+	child := l.WithTag(defaultFluentTag + ".child")
+	child.Named("worker").With("k", "v").Infow("child")
+	child.WithComponent("db").Ctx(context.Background()).Named("worker").Named("pool").Infow("grandchild")
+
+	recs := p.records()
+	if len(recs) != 3 {
+		t.Fatalf("got %d records, want 3", len(recs))
+	}
+	for i, want := range []string{"[billing] root", "[billing] child", "[billing] grandchild"} {
+		if recs[i]["message"] != want {
+			t.Errorf("record %d: message = %q, want %q", i, recs[i]["message"], want)
+		}
+	}
+	if recs[2]["logger"] != "worker.pool" {
+		t.Errorf("logger = %v, want worker.pool", recs[2]["logger"])
+	}
+}
+
+func TestContextTagPrecedence(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{Tag: "app.base"})
+	ctx := ContextWithTag(context.Background(), "app.ctx")
+
+	l.Infow("base")
+	l.Infow("field", TagFieldKey, "app.field")
+	l.Ctx(ctx).Infow("context")
+	l.Ctx(ctx).Infow("context over field", TagFieldKey, "app.field")
+	l.Ctx(ctx).WithTag("app.with").Infow("WithTag after Ctx")
+	l.Ctx(context.Background()).Infow("no context tag")
+
+	want := []string{"app.base", "app.field", "app.ctx", "app.ctx", "app.with", "app.base"}
+	if got := p.postedTags(); !reflect.DeepEqual(got, want) {
+		t.Errorf("tags = %q, want %q", got, want)
+	}
+	for _, rec := range p.records() {
+		if _, ok := rec[TagFieldKey]; ok {
+			t.Errorf("record %v carries the tag field", rec)
+		}
+	}
+}
+
+func TestContextTagInvalid(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{Tag: "app.base"})
+	var self bytes.Buffer
+	l.fluent.self = &selfLogger{w: &self}
+
+	l.Ctx(ContextWithTag(context.Background(), "bad tag")).Infow("m")
+	if got := p.postedTags(); len(got) != 1 || got[0] != "app.base" {
+		t.Errorf("tags = %q, want the logger's tag", got)
+	}
+	if !strings.Contains(self.String(), "invalid context tag ignored") {
+		t.Errorf("self-log = %q, want the invalid tag reported", self.String())
+	}
+}
@@ -0,0 +1,42 @@
+package observability
+
+import "testing"
+
+func TestDPanicDevelopment(t *testing.T) {
+	l, sink := newAsyncTestLogger(t, &SugaredLoggerConfig{Development: true})
+	l.Infow("before")
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("DPanicw did not panic with Development")
+			}
+			// The queue was flushed before the panic
+			recs := sink.records()
+			if len(recs) != 2 || recs[1]["message"] != "broken invariant" {
+				t.Fatalf("records at panic = %v, want both entries", recs)
+			}
+		}()
+		l.DPanicw("broken invariant", "id", 1)
+	}()
+}
+
+func TestDPanicProduction(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Fatalf("DPanicw panicked without Development: %v", r)
+			}
+		}()
+		l.DPanicw("broken invariant")
+	}()
+
+	if rec := p.last(t); rec["message"] != "broken invariant" || rec["severity"] != "dpanic" {
+		t.Errorf("record = %v", rec)
+	}
+	l.Infow("after")
+	if rec := p.last(t); rec["message"] != "after" {
+		t.Errorf("logger stopped after DPanic: last record = %v", rec)
+	}
+}
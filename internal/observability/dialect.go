@@ -0,0 +1,67 @@
+package observability
+
+import (
+	"time"
+
+	"github.com/fluent/fluent-logger-golang/fluent"
+)
+
+// Dialect selects which forward-protocol receiver the logger talks to.
+//
+// Fluent Bit's forward input is stricter than Fluentd's:
+//   - it only decodes msgpack, so MarshalAsJSON is turned off;
+//   - it does not answer ack requests, so RequestAck is turned off rather
+//     than waiting for an ack that never comes;
+//   - it only understands EventTime at the entry's time position, so
+//     time.Time values nested in the record (which msgpack would encode as
+//     an extension type) are sent as RFC3339Nano strings instead.
+//
+// Overridden settings are reported to the self-logger.
+type Dialect int
+
+const (
+	DialectFluentd Dialect = iota
+	DialectFluentBit
+)
+
+// apply adjusts cfg to what the dialect's receiver accepts.
+func (d Dialect) apply(cfg *fluent.Config, self *selfLogger) {
+	if d != DialectFluentBit {
+		return
+	}
+	if cfg.MarshalAsJSON {
+		self.warn("MarshalAsJSON disabled for Fluent Bit dialect")
+		cfg.MarshalAsJSON = false
+	}
+	if cfg.RequestAck {
+		self.warn("RequestAck disabled for Fluent Bit dialect")
+		cfg.RequestAck = false
+	}
+}
+
+// shape rewrites entry in place into the form the dialect's receiver
+// accepts.
+func (d Dialect) shape(entry map[string]interface{}) {
+	if d != DialectFluentBit {
+		return
+	}
+	for k, v := range entry {
+		entry[k] = flattenTimes(v)
+	}
+}
+
+func flattenTimes(v interface{}) interface{} {
+	switch v := v.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = flattenTimes(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = flattenTimes(e)
+		}
+	}
+	return v
+}
@@ -0,0 +1,55 @@
+package observability
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fluent/fluent-logger-golang/fluent"
+)
+
+func TestDialectApply(t *testing.T) {
+	for _, tt := range []struct {
+		dialect Dialect
+		want    bool
+	}{
+		{DialectFluentd, true},
+		{DialectFluentBit, false},
+	} {
+		cfg := fluent.Config{MarshalAsJSON: true, RequestAck: true}
+		tt.dialect.apply(&cfg, nil)
+		if cfg.MarshalAsJSON != tt.want || cfg.RequestAck != tt.want {
+			t.Errorf("dialect %d: MarshalAsJSON=%v RequestAck=%v, want %v", tt.dialect, cfg.MarshalAsJSON, cfg.RequestAck, tt.want)
+		}
+	}
+}
+
+func TestDialectRecordShape(t *testing.T) {
+	at := time.Date(2024, 5, 1, 10, 0, 0, 123, time.UTC)
+	for _, tt := range []struct {
+		dialect Dialect
+		want    interface{}
+	}{
+		{DialectFluentd, at},
+		{DialectFluentBit, "2024-05-01T10:00:00.000000123Z"},
+	} {
+		// Times in fields are already formatted by the core; only an
+		// EntryMarshaler can still hand raw ones to the dialect.
+		addTimes := func(entry map[string]interface{}) (map[string]interface{}, error) {
+			entry["meta"] = map[string]interface{}{
+				"at":    at,
+				"times": []interface{}{at},
+			}
+			return entry, nil
+		}
+		l, p := newTestLogger(t, &SugaredLoggerConfig{Dialect: tt.dialect, EntryMarshaler: addTimes})
+		l.Infow("m")
+
+		meta := p.last(t)["meta"].(map[string]interface{})
+		if got := meta["at"]; got != tt.want {
+			t.Errorf("dialect %d: meta.at = %#v, want %#v", tt.dialect, got, tt.want)
+		}
+		if got := meta["times"].([]interface{})[0]; got != tt.want {
+			t.Errorf("dialect %d: meta.times[0] = %#v, want %#v", tt.dialect, got, tt.want)
+		}
+	}
+}
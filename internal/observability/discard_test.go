@@ -0,0 +1,51 @@
+package observability
+
+import (
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestDiscardLevels(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{
+		LogLevel:      "debug",
+		DiscardLevels: []zapcore.Level{zapcore.InfoLevel},
+	})
+	l.Debugw("debug")
+	l.Infow("info")
+	l.WithComponent("billing").Infow("child info")
+	l.Warnw("warn")
+	l.Errorw("error")
+
+	var got []string
+	for _, rec := range p.records() {
+		got = append(got, rec["message"].(string))
+	}
+	want := []string{"debug", "warn", "error"}
+	if len(got) != len(want) {
+		t.Fatalf("logged %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("logged %q, want %q", got, want)
+			break
+		}
+	}
+}
+
+func TestDiscardLevelsBeforeEncoding(t *testing.T) {
+	l, _ := newTestLogger(t, &SugaredLoggerConfig{DiscardLevels: []zapcore.Level{zapcore.WarnLevel}})
+	if l.Desugar().Core().Enabled(zapcore.WarnLevel) {
+		t.Error("core enabled for a discarded level")
+	}
+	if !l.Desugar().Core().Enabled(zapcore.ErrorLevel) {
+		t.Error("core disabled for a level that is not discarded")
+	}
+}
+
+func TestDiscardLevelsIgnoresUnknownLevels(t *testing.T) {
+	d := newDiscardSet([]zapcore.Level{zapcore.Level(-5), zapcore.Level(42)})
+	if d != (discardSet{}) {
+		t.Errorf("discard set = %v, want empty", d)
+	}
+}
@@ -0,0 +1,50 @@
+package observability
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// TestCloseDeliversAcceptedWrites logs from several goroutines while the
+// logger closes: every write must either be rejected with ErrLoggerClosed
+// or end up at the transport.
+func TestCloseDeliversAcceptedWrites(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		l, p := newTestLogger(t, nil)
+		line := []byte(`{"level":"info","message":"m"}` + "\n")
+
+		var accepted atomic.Int64
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				for {
+					_, err := l.fluent.Write(line)
+					if errors.Is(err, ErrLoggerClosed) {
+						return
+					}
+					if err != nil {
+						t.Errorf("Write: %v", err)
+						return
+					}
+					accepted.Add(1)
+				}
+			}()
+		}
+
+		close(start)
+		if err := l.Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+		wg.Wait()
+
+		if got, want := int64(len(p.records())), accepted.Load(); got != want {
+			t.Fatalf("run %d: transport got %d records, %d writes were accepted", i, got, want)
+		}
+	}
+}
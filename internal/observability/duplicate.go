@@ -0,0 +1,79 @@
+package observability
+
+import (
+	"fmt"
+	"strconv"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// DuplicateKeyPolicy selects what happens when an entry carries several
+// fields of the same key, e.g. logger.Infow("m", "k", 1, "k", 2):
+//
+//	DuplicateKeepLast   {"k": 2}
+//	DuplicateKeepFirst  {"k": 1}
+//	DuplicateRename     {"k": 1, "k_2": 2}
+//	DuplicateError      the entry is dropped and Write returns ErrDuplicateKey
+//
+// The policy covers the fields attached with With and those passed at the
+// call site, in that order. Fields under a zap.Namespace are compared with
+// the other fields of that namespace only. Base fields (SetBaseFields) are
+// not subject to it; they are always overridden.
+type DuplicateKeyPolicy int
+
+const (
+	DuplicateKeepLast DuplicateKeyPolicy = iota
+	DuplicateKeepFirst
+	DuplicateRename
+	DuplicateError
+)
+
+// dedupe applies the policy to the context and call-site fields of c. It
+// returns a core without context fields and the fields to write in their
+// place, or c and an error wrapping ErrDuplicateKey. With DuplicateKeepLast
+// it returns c and fields unchanged, as the record encoder already lets the
+// last field win.
+func (c *fluentCore) dedupe(fields []zapcore.Field) (*fluentCore, []zapcore.Field, error) {
+	policy := c.cfg.DuplicateKeyPolicy
+	if policy == DuplicateKeepLast || len(c.fields)+len(fields) < 2 {
+		return c, fields, nil
+	}
+
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(append(all, c.fields...), fields...)
+	out := all[:0]
+	// seen holds the keys of the current namespace.
+	seen := make(map[string]bool, len(all))
+	for _, f := range all {
+		if f.Type == zapcore.NamespaceType {
+			out = append(out, f)
+			clear(seen)
+			continue
+		}
+		if f.Key == "" {
+			out = append(out, f)
+			continue
+		}
+		switch {
+		case !seen[f.Key]:
+			seen[f.Key] = true
+			out = append(out, f)
+		case policy == DuplicateKeepFirst:
+			// Leave the later field out.
+		case policy == DuplicateRename:
+			key := f.Key
+			for n := 2; seen[key]; n++ {
+				key = f.Key + "_" + strconv.Itoa(n)
+			}
+			f.Key = key
+			seen[key] = true
+			out = append(out, f)
+		case policy == DuplicateError:
+			return c, nil, fmt.Errorf("%w: %q", ErrDuplicateKey, f.Key)
+		}
+	}
+
+	clone := *c
+	clone.fields = nil
+	return &clone, out, nil
+}
@@ -0,0 +1,66 @@
+package observability
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestDuplicateKeyPolicy(t *testing.T) {
+	tests := []struct {
+		policy DuplicateKeyPolicy
+		want   map[string]interface{}
+	}{
+		{DuplicateKeepLast, map[string]interface{}{"k": int64(4), "other": "x"}},
+		{DuplicateKeepFirst, map[string]interface{}{"k": int64(1), "other": "x"}},
+		{DuplicateRename, map[string]interface{}{"k": int64(1), "k_2": int64(2), "k_3": int64(3), "k_4": int64(4), "other": "x"}},
+	}
+	for _, tt := range tests {
+		l, p := newTestLogger(t, &SugaredLoggerConfig{DuplicateKeyPolicy: tt.policy})
+		l.WithComponent("c").With("k", 1, "other", "x").Infow("m", "k", 2, "k", 3, "k", 4)
+
+		rec := p.last(t)
+		got := make(map[string]interface{})
+		for k, v := range rec {
+			if k == "k" || k == "other" || strings.HasPrefix(k, "k_") {
+				got[k] = v
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("policy %d: fields = %v, want %v", tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestDuplicateRenameSkipsTakenKeys(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{DuplicateKeyPolicy: DuplicateRename})
+	l.Infow("m", "k", 1, "k_2", "taken", "k", 2)
+
+	rec := p.last(t)
+	if rec["k"] != int64(1) || rec["k_2"] != "taken" || rec["k_3"] != int64(2) {
+		t.Errorf("record = %v, want k, k_2 kept and the duplicate as k_3", rec)
+	}
+}
+
+func TestDuplicateError(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{DuplicateKeyPolicy: DuplicateError})
+	l.Infow("m", "k", 1, "k", 2)
+	l.Infow("unique", "k", 1, "j", 2)
+
+	recs := p.records()
+	if len(recs) != 1 || recs[0]["message"] != "unique" {
+		t.Errorf("records = %v, want only the entry without duplicates", recs)
+	}
+}
+
+func TestDuplicateNamespaces(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{DuplicateKeyPolicy: DuplicateError})
+	l.Desugar().Info("m", zap.Int("k", 1), zap.Namespace("ns"), zap.Int("k", 2))
+
+	rec := p.last(t)
+	if rec["k"] != int64(1) || !reflect.DeepEqual(rec["ns"], map[string]interface{}{"k": int64(2)}) {
+		t.Errorf("record = %v, want k in both scopes", rec)
+	}
+}
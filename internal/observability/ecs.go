@@ -0,0 +1,137 @@
+package observability
+
+import (
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// ecsVersion is the Elastic Common Schema version ECS records declare.
+const ecsVersion = "8.11.0"
+
+// ecsCallerKey is where ECSEncoderConfig writes the caller, for
+// ECSMarshaler to split into log.origin.file.name and .line.
+const ecsCallerKey = "log.origin"
+
+// ecsFieldSets are the top-level field sets of the Elastic Common Schema.
+// ECSMarshaler leaves fields under them where they are.
+var ecsFieldSets = map[string]bool{
+	"@timestamp": true, "agent": true, "client": true, "cloud": true,
+	"container": true, "data_stream": true, "destination": true, "dns": true,
+	"ecs": true, "error": true, "event": true, "file": true, "host": true,
+	"http": true, "labels": true, "log": true, "message": true,
+	"network": true, "orchestrator": true, "process": true, "server": true,
+	"service": true, "source": true, "span": true, "tags": true,
+	"threat": true, "tls": true, "trace": true, "transaction": true,
+	"url": true, "user": true, "user_agent": true,
+}
+
+// ECSEncoderConfig returns the encoder config of the Elastic Common Schema
+// layout, to be used together with ECSMarshaler:
+//
+//	enc := observability.ECSEncoderConfig()
+//	cfg.EncoderConfig = &enc
+//	cfg.EntryMarshaler = observability.ECSMarshaler("app")
+//
+// It writes @timestamp, log.level, log.logger, message,
+// log.origin.function and error.stack_trace. Its EncodeCaller is left nil,
+// so CallerStyle still selects how the file name is written.
+func ECSEncoderConfig() zapcore.EncoderConfig {
+	return zapcore.EncoderConfig{
+		TimeKey:        "@timestamp",
+		LevelKey:       "log.level",
+		NameKey:        "log.logger",
+		CallerKey:      ecsCallerKey,
+		FunctionKey:    "log.origin.function",
+		MessageKey:     "message",
+		StacktraceKey:  "error.stack_trace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeTime:     zapcore.RFC3339NanoTimeEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
+	}
+}
+
+// ECSMarshaler returns the EntryMarshaler of the Elastic Common Schema
+// layout (see ECSEncoderConfig). It turns dotted keys into nested objects,
+// e.g. log.level into {"log": {"level": ...}}, splits the caller into
+// log.origin.file.name and log.origin.file.line, moves the message of an
+// error field to error.message and adds ecs.version.
+//
+// Fields outside the ECS field sets are moved under custom, as ECS
+// recommends for custom fields, so they cannot collide with fields a later
+// ECS version adds. An empty custom leaves them at the top level.
+func ECSMarshaler(custom string) EntryMarshaler {
+	return func(entry map[string]interface{}) (map[string]interface{}, error) {
+		out := make(map[string]interface{}, len(entry)+1)
+		for k, v := range entry {
+			switch {
+			case k == ecsCallerKey:
+				if file, line, ok := splitCaller(v); ok {
+					setPath(out, "log.origin.file.name", file)
+					setPath(out, "log.origin.file.line", line)
+					continue
+				}
+			case k == "error":
+				if msg, ok := v.(string); ok {
+					k, v = "error.message", msg
+				}
+			case custom != "" && !ecsFieldSets[strings.SplitN(k, ".", 2)[0]]:
+				k = custom + "." + k
+			}
+			setPath(out, k, v)
+		}
+		setPath(out, "ecs.version", ecsVersion)
+		return out, nil
+	}
+}
+
+// splitCaller splits a caller written as file:line.
+func splitCaller(v interface{}) (string, int, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return "", 0, false
+	}
+	i := strings.LastIndexByte(s, ':')
+	if i < 0 {
+		return "", 0, false
+	}
+	line, err := strconv.Atoi(s[i+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	return s[:i], line, true
+}
+
+// setPath stores v in m under the dotted key path, creating nested objects
+// along the way and merging into those already there. A path blocked by a
+// non-object value keeps the rest of the key, dots included, as one key at
+// that level.
+func setPath(m map[string]interface{}, path string, v interface{}) {
+	for {
+		head, rest, nested := strings.Cut(path, ".")
+		if !nested {
+			break
+		}
+		next, ok := m[head].(map[string]interface{})
+		if !ok {
+			if _, taken := m[head]; taken {
+				break
+			}
+			next = make(map[string]interface{})
+			m[head] = next
+		}
+		m, path = next, rest
+	}
+
+	if obj, ok := v.(map[string]interface{}); ok {
+		if existing, ok := m[path].(map[string]interface{}); ok {
+			for k, e := range obj {
+				setPath(existing, k, e)
+			}
+			return
+		}
+	}
+	m[path] = v
+}
@@ -0,0 +1,139 @@
+package observability
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// lookupPath returns the value at the dotted path of a nested record.
+func lookupPath(rec map[string]interface{}, p string) (interface{}, bool) {
+	var v interface{} = rec
+	for _, k := range strings.Split(p, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[k]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+func TestECSLayout(t *testing.T) {
+	enc := ECSEncoderConfig()
+	l, p := newTestLogger(t, &SugaredLoggerConfig{
+		AddCaller:      true,
+		EncoderConfig:  &enc,
+		EntryMarshaler: ECSMarshaler("app"),
+	})
+	l.Desugar().WithOptions(zap.AddStacktrace(zapcore.ErrorLevel)).Named("billing").Error("charge failed",
+		zap.Error(errors.New("card declined")),
+		zap.String("user.name", "ada"),
+		zap.String("order_id", "o-1"),
+		zap.String("http.request.method", "POST"),
+	)
+
+	rec := p.last(t)
+	for p, want := range map[string]interface{}{
+		"message":             "charge failed",
+		"log.level":           "error",
+		"log.logger":          "billing",
+		"error.message":       "card declined",
+		"http.request.method": "POST",
+		"user.name":           "ada",
+		"app.order_id":        "o-1",
+		"ecs.version":         ecsVersion,
+	} {
+		if got, _ := lookupPath(rec, p); got != want {
+			t.Errorf("%s = %#v, want %#v", p, got, want)
+		}
+	}
+	if ts, ok := lookupPath(rec, "@timestamp"); !ok || ts == "" {
+		t.Errorf("@timestamp = %#v, want a time", ts)
+	}
+	file, _ := lookupPath(rec, "log.origin.file.name")
+	if name, _ := file.(string); !regexp.MustCompile(`ecs_test\.go$`).MatchString(name) {
+		t.Errorf("log.origin.file.name = %#v, want ecs_test.go", file)
+	}
+	if line, _ := lookupPath(rec, "log.origin.file.line"); line == nil || line.(int) <= 0 {
+		t.Errorf("log.origin.file.line = %#v, want a line number", line)
+	}
+	if stack, _ := lookupPath(rec, "error.stack_trace"); !strings.Contains(stack.(string), "TestECSLayout") {
+		t.Errorf("error.stack_trace = %q, want the calling test", stack)
+	}
+	for _, k := range []string{"order_id", "severity", "caller", "log.origin"} {
+		if _, ok := rec[k]; ok {
+			t.Errorf("record has top-level key %q", k)
+		}
+	}
+}
+
+func TestECSMarshalerWithoutNamespace(t *testing.T) {
+	got, err := ECSMarshaler("")(map[string]interface{}{"user": "ada", "log.level": "info"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"user": "ada",
+		"log":  map[string]interface{}{"level": "info"},
+		"ecs":  map[string]interface{}{"version": ecsVersion},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("record = %#v, want %#v", got, want)
+	}
+}
+
+func TestSetPath(t *testing.T) {
+	m := map[string]interface{}{"a": "scalar"}
+	setPath(m, "a.b", 1)
+	setPath(m, "x.y", 2)
+	setPath(m, "x", map[string]interface{}{"z": 3})
+	want := map[string]interface{}{
+		"a":   "scalar",
+		"a.b": 1,
+		"x":   map[string]interface{}{"y": 2, "z": 3},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("map = %#v, want %#v", m, want)
+	}
+}
+
+func TestEncoderConfigRenamedKeys(t *testing.T) {
+	enc := newEncoderConfig()
+	enc.MessageKey = "msg"
+	enc.LevelKey = "level"
+	l, p := newTestLogger(t, &SugaredLoggerConfig{
+		EncoderConfig:   &enc,
+		MaxMessageBytes: 8,
+		OrderedFields:   true,
+		EntryMarshaler:  NestUserFieldsFor("data", enc),
+	})
+	l.Infow("a rather long message", "user", "ada")
+
+	p.mu.Lock()
+	rec := p.messages[0].(orderedRecord)
+	p.mu.Unlock()
+	if got := rec.fields["msg"]; got != "a rat…" {
+		t.Errorf("msg = %q, want it truncated to 8 bytes", got)
+	}
+	data, _ := rec.fields["data"].(map[string]interface{})
+	if data["user"] != "ada" || data[messageTruncatedKey] != true {
+		t.Errorf("data = %v, want the user fields nested", data)
+	}
+	for _, key := range []string{"msg", "level"} {
+		if _, nested := data[key]; nested {
+			t.Errorf("standard key %q nested under data", key)
+		}
+	}
+	if keys, want := rec.keys(), []string{"timestamp", "level", "msg", "data"}; !slices.Equal(keys, want) {
+		t.Errorf("keys = %v, want %v", keys, want)
+	}
+}
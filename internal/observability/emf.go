@@ -0,0 +1,104 @@
+package observability
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// emfKey is the record key of the CloudWatch Embedded Metric Format
+// envelope.
+const emfKey = "_aws"
+
+// EMFConfig turns numeric record fields into CloudWatch metrics using the
+// Embedded Metric Format. Records carrying at least one of the metric
+// fields as a number get an _aws envelope describing them:
+//
+//	{
+//	  "_aws": {
+//	    "Timestamp": 1700000000000,
+//	    "CloudWatchMetrics": [{
+//	      "Namespace": "app",
+//	      "Dimensions": [["service", "route"]],
+//	      "Metrics": [{"Name": "latency_ms", "Unit": "Milliseconds"}]
+//	    }]
+//	  },
+//	  "service": "checkout",
+//	  "route": "/pay",
+//	  "latency_ms": 42,
+//	  ...
+//	}
+//
+// Metric values and dimension values stay top-level fields, as EMF
+// requires; every other field passes through unchanged. Only the metrics
+// present in the record are listed, and only the dimension sets whose
+// fields are all present as strings.
+type EMFConfig struct {
+	Namespace string
+	// Dimensions lists the dimension sets, each a list of field names.
+	Dimensions [][]string
+	Metrics    []EMFMetric
+}
+
+// EMFMetric names a record field to extract as a metric. Unit is a
+// CloudWatch unit such as "Milliseconds" or "Count"; empty means "None".
+type EMFMetric struct {
+	Name string
+	Unit string
+}
+
+// addEMF adds the EMF envelope for the metrics present in entry, if any.
+func addEMF(entry map[string]interface{}, cfg *EMFConfig, t time.Time) {
+	var metrics []interface{}
+	for _, m := range cfg.Metrics {
+		if !isNumber(entry[m.Name]) {
+			continue
+		}
+		metric := map[string]interface{}{"Name": m.Name}
+		if m.Unit != "" {
+			metric["Unit"] = m.Unit
+		}
+		metrics = append(metrics, metric)
+	}
+	if len(metrics) == 0 {
+		return
+	}
+
+	dimensions := []interface{}{}
+	for _, set := range cfg.Dimensions {
+		if hasStrings(entry, set) {
+			keys := make([]interface{}, len(set))
+			for i, k := range set {
+				keys[i] = k
+			}
+			dimensions = append(dimensions, keys)
+		}
+	}
+
+	entry[emfKey] = map[string]interface{}{
+		"Timestamp": t.UnixMilli(),
+		"CloudWatchMetrics": []interface{}{map[string]interface{}{
+			"Namespace":  cfg.Namespace,
+			"Dimensions": dimensions,
+			"Metrics":    metrics,
+		}},
+	}
+}
+
+func isNumber(v interface{}) bool {
+	switch v.(type) {
+	case int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64, json.Number:
+		return true
+	}
+	return false
+}
+
+func hasStrings(entry map[string]interface{}, keys []string) bool {
+	for _, k := range keys {
+		if _, ok := entry[k].(string); !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+package observability
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testEMFConfig() *EMFConfig {
+	return &EMFConfig{
+		Namespace:  "app",
+		Dimensions: [][]string{{"service", "route"}, {"service"}, {"region"}},
+		Metrics:    []EMFMetric{{Name: "latency_ms", Unit: "Milliseconds"}, {Name: "retries"}},
+	}
+}
+
+func TestAddEMF(t *testing.T) {
+	at := time.UnixMilli(1700000000123)
+	entry := map[string]interface{}{
+		"service":    "checkout",
+		"route":      "/pay",
+		"latency_ms": 42.5,
+		"retries":    "three",
+		"user":       "ada",
+	}
+	addEMF(entry, testEMFConfig(), at)
+
+	want := map[string]interface{}{
+		"Timestamp": int64(1700000000123),
+		"CloudWatchMetrics": []interface{}{map[string]interface{}{
+			"Namespace":  "app",
+			"Dimensions": []interface{}{[]interface{}{"service", "route"}, []interface{}{"service"}},
+			"Metrics":    []interface{}{map[string]interface{}{"Name": "latency_ms", "Unit": "Milliseconds"}},
+		}},
+	}
+	if got := entry[emfKey]; !reflect.DeepEqual(got, want) {
+		t.Errorf("_aws = %#v\nwant %#v", got, want)
+	}
+	if entry["latency_ms"] != 42.5 || entry["user"] != "ada" || entry["retries"] != "three" {
+		t.Errorf("fields changed: %v", entry)
+	}
+}
+
+func TestAddEMFWithoutMetrics(t *testing.T) {
+	entry := map[string]interface{}{"service": "checkout", "latency_ms": "slow"}
+	addEMF(entry, testEMFConfig(), time.Now())
+	if _, ok := entry[emfKey]; ok {
+		t.Errorf("envelope added to a record without numeric metrics: %v", entry)
+	}
+}
+
+func TestEMFWritePath(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{EMF: testEMFConfig()})
+	l.Infow("paid", "service", "checkout", "retries", 2)
+
+	aws, ok := p.last(t)[emfKey].(map[string]interface{})
+	if !ok {
+		t.Fatalf("record has no _aws envelope: %v", p.last(t))
+	}
+	metrics := aws["CloudWatchMetrics"].([]interface{})[0].(map[string]interface{})
+	if got, want := metrics["Metrics"], []interface{}{map[string]interface{}{"Name": "retries"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Metrics = %#v, want %#v", got, want)
+	}
+	if got, want := metrics["Dimensions"], []interface{}{[]interface{}{"service"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Dimensions = %#v, want %#v", got, want)
+	}
+}
@@ -0,0 +1,305 @@
+package observability
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// maxExactNumberLen bounds the length of the numbers plainNumbers checks
+// for an exact integral value.
+const maxExactNumberLen = 64
+
+// mapEncoder is a zapcore.ObjectEncoder that builds a Fluent record as a
+// plain map. Unlike the JSON encoder it keeps Go types intact, so the record
+// can be handed to PostWithTime without an encode/decode round-trip.
+type mapEncoder struct {
+	cfg    *zapcore.EncoderConfig
+	fields map[string]interface{}
+	// cur is the map fields are added to; it differs from fields once a
+	// namespace has been opened.
+	cur map[string]interface{}
+}
+
+func newMapEncoder(cfg *zapcore.EncoderConfig, size int) *mapEncoder {
+	m := make(map[string]interface{}, size)
+	return &mapEncoder{cfg: cfg, fields: m, cur: m}
+}
+
+func (m *mapEncoder) AddArray(key string, v zapcore.ArrayMarshaler) error {
+	arr := &sliceEncoder{cfg: m.cfg}
+	err := v.MarshalLogArray(arr)
+	m.cur[key] = arr.elems
+	return err
+}
+
+func (m *mapEncoder) AddObject(key string, v zapcore.ObjectMarshaler) error {
+	obj := newMapEncoder(m.cfg, 0)
+	m.cur[key] = obj.fields
+	return v.MarshalLogObject(obj)
+}
+
+func (m *mapEncoder) AddBinary(key string, v []byte) {
+	m.cur[key] = base64.StdEncoding.EncodeToString(v)
+}
+func (m *mapEncoder) AddByteString(key string, v []byte) { m.cur[key] = string(v) }
+func (m *mapEncoder) AddBool(key string, v bool)         { m.cur[key] = v }
+func (m *mapEncoder) AddComplex128(key string, v complex128) {
+	m.cur[key] = formatComplex(v, 64)
+}
+func (m *mapEncoder) AddComplex64(key string, v complex64) {
+	m.cur[key] = formatComplex(complex128(v), 32)
+}
+func (m *mapEncoder) AddDuration(key string, v time.Duration) {
+	m.cur[key] = encodeDuration(m.cfg, v)
+}
+func (m *mapEncoder) AddFloat64(key string, v float64) { m.cur[key] = v }
+func (m *mapEncoder) AddFloat32(key string, v float32) { m.cur[key] = v }
+func (m *mapEncoder) AddInt(key string, v int)         { m.cur[key] = v }
+func (m *mapEncoder) AddInt64(key string, v int64)     { m.cur[key] = v }
+func (m *mapEncoder) AddInt32(key string, v int32)     { m.cur[key] = v }
+func (m *mapEncoder) AddInt16(key string, v int16)     { m.cur[key] = v }
+func (m *mapEncoder) AddInt8(key string, v int8)       { m.cur[key] = v }
+func (m *mapEncoder) AddString(key string, v string)   { m.cur[key] = v }
+func (m *mapEncoder) AddTime(key string, v time.Time)  { m.cur[key] = encodeTime(m.cfg, v) }
+func (m *mapEncoder) AddUint(key string, v uint)       { m.cur[key] = v }
+func (m *mapEncoder) AddUint64(key string, v uint64)   { m.cur[key] = v }
+func (m *mapEncoder) AddUint32(key string, v uint32)   { m.cur[key] = v }
+func (m *mapEncoder) AddUint16(key string, v uint16)   { m.cur[key] = v }
+func (m *mapEncoder) AddUint8(key string, v uint8)     { m.cur[key] = v }
+func (m *mapEncoder) AddUintptr(key string, v uintptr) { m.cur[key] = uint64(v) }
+
+func (m *mapEncoder) AddReflected(key string, v interface{}) error {
+	val, err := reflectedTree(m.cfg, v)
+	if err != nil {
+		return err
+	}
+	m.cur[key] = val
+	return nil
+}
+
+func (m *mapEncoder) OpenNamespace(key string) {
+	ns := make(map[string]interface{})
+	m.cur[key] = ns
+	m.cur = ns
+}
+
+// sliceEncoder is the zapcore.ArrayEncoder counterpart of mapEncoder. It is
+// also used to capture the single value produced by encoder callbacks such
+// as EncodeLevel and EncodeTime.
+type sliceEncoder struct {
+	cfg   *zapcore.EncoderConfig
+	elems []interface{}
+}
+
+func (s *sliceEncoder) AppendArray(v zapcore.ArrayMarshaler) error {
+	arr := &sliceEncoder{cfg: s.cfg}
+	err := v.MarshalLogArray(arr)
+	s.elems = append(s.elems, arr.elems)
+	return err
+}
+
+func (s *sliceEncoder) AppendObject(v zapcore.ObjectMarshaler) error {
+	obj := newMapEncoder(s.cfg, 0)
+	err := v.MarshalLogObject(obj)
+	s.elems = append(s.elems, obj.fields)
+	return err
+}
+
+func (s *sliceEncoder) AppendReflected(v interface{}) error {
+	val, err := reflectedTree(s.cfg, v)
+	if err != nil {
+		return err
+	}
+	s.elems = append(s.elems, val)
+	return nil
+}
+
+func (s *sliceEncoder) AppendBool(v bool)         { s.elems = append(s.elems, v) }
+func (s *sliceEncoder) AppendByteString(v []byte) { s.elems = append(s.elems, string(v)) }
+func (s *sliceEncoder) AppendComplex128(v complex128) {
+	s.elems = append(s.elems, formatComplex(v, 64))
+}
+func (s *sliceEncoder) AppendComplex64(v complex64) {
+	s.elems = append(s.elems, formatComplex(complex128(v), 32))
+}
+func (s *sliceEncoder) AppendDuration(v time.Duration) {
+	s.elems = append(s.elems, encodeDuration(s.cfg, v))
+}
+func (s *sliceEncoder) AppendFloat64(v float64) { s.elems = append(s.elems, v) }
+func (s *sliceEncoder) AppendFloat32(v float32) { s.elems = append(s.elems, v) }
+func (s *sliceEncoder) AppendInt(v int)         { s.elems = append(s.elems, v) }
+func (s *sliceEncoder) AppendInt64(v int64)     { s.elems = append(s.elems, v) }
+func (s *sliceEncoder) AppendInt32(v int32)     { s.elems = append(s.elems, v) }
+func (s *sliceEncoder) AppendInt16(v int16)     { s.elems = append(s.elems, v) }
+func (s *sliceEncoder) AppendInt8(v int8)       { s.elems = append(s.elems, v) }
+func (s *sliceEncoder) AppendString(v string)   { s.elems = append(s.elems, v) }
+func (s *sliceEncoder) AppendTime(v time.Time)  { s.elems = append(s.elems, encodeTime(s.cfg, v)) }
+func (s *sliceEncoder) AppendUint(v uint)       { s.elems = append(s.elems, v) }
+func (s *sliceEncoder) AppendUint64(v uint64)   { s.elems = append(s.elems, v) }
+func (s *sliceEncoder) AppendUint32(v uint32)   { s.elems = append(s.elems, v) }
+func (s *sliceEncoder) AppendUint16(v uint16)   { s.elems = append(s.elems, v) }
+func (s *sliceEncoder) AppendUint8(v uint8)     { s.elems = append(s.elems, v) }
+func (s *sliceEncoder) AppendUintptr(v uintptr) { s.elems = append(s.elems, uint64(v)) }
+
+// value returns the first captured element, or nil if nothing was appended.
+func (s *sliceEncoder) value() interface{} {
+	if len(s.elems) == 0 {
+		return nil
+	}
+	return s.elems[0]
+}
+
+// encodeTime renders t the same way the JSON encoder would, falling back to
+// Unix nanoseconds when no time encoder is configured.
+func encodeTime(cfg *zapcore.EncoderConfig, t time.Time) interface{} {
+	if cfg.EncodeTime != nil {
+		c := &sliceEncoder{cfg: cfg}
+		cfg.EncodeTime(t, c)
+		if v := c.value(); v != nil {
+			return v
+		}
+	}
+	return t.UnixNano()
+}
+
+// timeValue reports whether v is a time.Time, or a non-nil pointer to one,
+// so that reflected times are written like zap.Time fields and the entry
+// timestamp rather than in their JSON form.
+func timeValue(v interface{}) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case *time.Time:
+		if t != nil {
+			return *t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func encodeDuration(cfg *zapcore.EncoderConfig, d time.Duration) interface{} {
+	if cfg.EncodeDuration != nil {
+		c := &sliceEncoder{cfg: cfg}
+		cfg.EncodeDuration(d, c)
+		if v := c.value(); v != nil {
+			return v
+		}
+	}
+	return int64(d)
+}
+
+func formatComplex(c complex128, bits int) string {
+	return strings.Trim(strconv.FormatComplex(c, 'g', -1, bits), "()")
+}
+
+// reflectedTree is reflectedValue for reflected field values, which are
+// often plain maps and slices: times, also those nested in a
+// map[string]interface{} or []interface{}, are written like zap.Time fields
+// and the entry timestamp rather than in their JSON form. Maps and slices
+// are copied, never modified. Times inside structs still go through JSON.
+func reflectedTree(cfg *zapcore.EncoderConfig, v interface{}) (interface{}, error) {
+	if t, ok := timeValue(v); ok {
+		return encodeTime(cfg, t), nil
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			val, err := reflectedTree(cfg, e)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = val
+		}
+		return m, nil
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			val, err := reflectedTree(cfg, e)
+			if err != nil {
+				return nil, err
+			}
+			s[i] = val
+		}
+		return s, nil
+	}
+	return reflectedValue(v)
+}
+
+// reflectedValue passes scalars through and normalizes everything else
+// (structs, maps, slices, pointers) via JSON, which matches what the JSON
+// encoder produced for zap.Any fields. A json.RawMessage is decoded as is.
+// Integers come out as int64 (uint64 beyond its range) rather than being
+// coerced to float64.
+func reflectedValue(v interface{}) (interface{}, error) {
+	switch v.(type) {
+	case nil, bool, string,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64, json.Number:
+		return v, nil
+	}
+
+	b, ok := v.(json.RawMessage)
+	if !ok {
+		var err error
+		if b, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+	var out interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+	return plainNumbers(out), nil
+}
+
+// plainNumbers replaces the json.Number values in v, a decoded JSON value,
+// with int64, uint64 or float64, whichever holds the number exactly (or
+// float64 if none does). Integral numbers written with a fraction or an
+// exponent, such as 9007199254740993.0, still become int64 or uint64, so
+// 64-bit IDs survive even when a producer formats them that way. Maps and
+// slices are updated in place.
+func plainNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if u, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			return u
+		}
+		f, _ := v.Float64()
+		// Only numbers within 64-bit range get the exact check, which
+		// keeps big.Rat away from huge exponents.
+		if f == math.Trunc(f) && math.Abs(f) <= math.MaxUint64 && len(v) <= maxExactNumberLen {
+			if r, ok := new(big.Rat).SetString(string(v)); ok && r.IsInt() {
+				if n := r.Num(); n.IsInt64() {
+					return n.Int64()
+				} else if n.IsUint64() {
+					return n.Uint64()
+				}
+			}
+		}
+		return f
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = plainNumbers(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = plainNumbers(e)
+		}
+	}
+	return v
+}
@@ -0,0 +1,57 @@
+package observability
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPlainNumbers(t *testing.T) {
+	tests := []struct {
+		in   string
+		want interface{}
+	}{
+		{"9007199254740993", int64(9007199254740993)},
+		{"9007199254740993.0", int64(9007199254740993)},
+		{"9.007199254740993e15", int64(9007199254740993)},
+		{"-9223372036854775808", int64(-9223372036854775808)},
+		{"18446744073709551615", uint64(18446744073709551615)},
+		{"18446744073709551615.0", uint64(18446744073709551615)},
+		{"1.5", 1.5},
+		{"1e300", 1e300},
+		{"36893488147419103232", 36893488147419103232.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := plainNumbers(json.Number(tt.in)); got != tt.want {
+				t.Errorf("plainNumbers(%s) = %#v, want %#v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlainNumbersNested(t *testing.T) {
+	dec := json.NewDecoder(strings.NewReader(`{"ids":[9007199254740993,{"id":9007199254740993.0}]}`))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"ids": []interface{}{int64(9007199254740993), map[string]interface{}{"id": int64(9007199254740993)}},
+	}
+	if got := plainNumbers(v); !reflect.DeepEqual(got, want) {
+		t.Errorf("plainNumbers = %#v, want %#v", got, want)
+	}
+}
+
+func TestWriteKeepsFractionalIDExact(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+	if _, err := l.fluent.Write([]byte(`{"level":"info","message":"m","id":9007199254740993.0}` + "\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if got := p.last(t)["id"]; got != int64(9007199254740993) {
+		t.Errorf("id = %#v, want int64(9007199254740993)", got)
+	}
+}
@@ -0,0 +1,93 @@
+package observability
+
+import (
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Event posts fields as a record at lvl, bypassing the sugared API. It is
+// meant for high-volume structured events whose fields are already in a
+// map: the values are added to the record as they are, without going
+// through zap fields and their reflection.
+//
+// The record carries the timestamp, severity and logger name of the
+// layout, the base and context fields of the logger, then fields, which
+// win over fields of the same key. It has no message. It is posted under
+// tag, or the logger's tag if tag is empty; an invalid tag is reported to
+// the self-logger and the logger's tag is used instead. Like a TagFieldKey
+// field, tag does not override a tag carried by the context of a Ctx
+// logger; see ContextWithTag.
+//
+// Events below the logger's level or in DiscardLevels are dropped, as for
+// every entry. Sampling, ValueTransformer, PreferStringer,
+// DuplicateKeyPolicy, SetEncoder and Serializers do not apply; the
+// record-level options (redaction, field limits, EntryMarshaler, ...) do.
+// Values of type time.Time, also those nested in maps and slices, are
+// written in the format of the timestamp.
+// Event does not panic or exit at DPanic level and above. Nested
+// map[string]interface{} and []interface{} values are copied into the
+// record, so the record options never modify the caller's fields, nor the
+// caller an event still being written; other nested types are added as
+// they are.
+func (l *SugaredLogger) Event(lvl zapcore.Level, tag string, fields map[string]interface{}) error {
+	c, ok := fluentCoreOf(l.Desugar().Core())
+	if !ok {
+		c = l.core
+	}
+	if !c.Enabled(lvl) {
+		return nil
+	}
+	if tag == "" || c.ctxTag {
+		tag = c.tag
+	} else if err := ValidateTag(tag); err != nil {
+		c.out.self.warn("invalid tag ignored", "tag", tag, "error", err)
+		tag = c.tag
+	}
+
+	ent := zapcore.Entry{Level: lvl, Time: time.Now(), LoggerName: l.Desugar().Name()}
+	record := c.encode(ent, nil)
+	if key := c.enc.MessageKey; key != "" {
+		delete(record, key)
+	}
+	if c.cfg.DualTimestamp {
+		c.addDualTimestamp(record, ent.Time)
+	}
+	for k, v := range fields {
+		record[k] = eventValue(c.enc, v)
+	}
+
+	urgent := c.out.syncFlushes(lvl)
+	if err := c.out.accept(c.ctx, tag, ent.Time, record, urgent); err != nil {
+		return err
+	}
+	c.out.countWrite()
+	if urgent {
+		return c.out.flush()
+	}
+	return nil
+}
+
+// eventValue returns v as Event adds it to a record: a time in the format
+// of the timestamp, a deep copy of a map[string]interface{} or an
+// []interface{}, and v itself otherwise.
+func eventValue(cfg *zapcore.EncoderConfig, v interface{}) interface{} {
+	if t, ok := timeValue(v); ok {
+		return encodeTime(cfg, t)
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = eventValue(cfg, e)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = eventValue(cfg, e)
+		}
+		return s
+	}
+	return v
+}
@@ -0,0 +1,132 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestEvent(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+	err := l.WithComponent("billing").Event(zapcore.WarnLevel, "app.events", map[string]interface{}{
+		"action":    "login",
+		"component": "auth",
+	})
+	if err != nil {
+		t.Fatalf("Event: %v", err)
+	}
+
+	rec := p.last(t)
+	if rec["action"] != "login" || rec["severity"] != "warn" {
+		t.Errorf("record = %v, want the fields and the severity", rec)
+	}
+	if rec["component"] != "auth" {
+		t.Errorf("component = %v, want the event field over the context field", rec["component"])
+	}
+	if _, ok := rec["message"]; ok {
+		t.Errorf("record has a message: %v", rec)
+	}
+	if tags := p.postedTags(); tags[len(tags)-1] != "app.events" {
+		t.Errorf("tag = %q, want app.events", tags[len(tags)-1])
+	}
+}
+
+func TestEventLevelAndTag(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{LogLevel: "info"})
+	if err := l.Event(zapcore.DebugLevel, "", map[string]interface{}{"k": 1}); err != nil {
+		t.Fatalf("Event below the level: %v", err)
+	}
+	if n := len(p.records()); n != 0 {
+		t.Fatalf("%d records posted below the level", n)
+	}
+
+	if err := l.Event(zapcore.InfoLevel, "bad tag", map[string]interface{}{"k": 1}); err != nil {
+		t.Fatalf("Event: %v", err)
+	}
+	if tags := p.postedTags(); len(tags) != 1 || tags[0] != defaultFluentTag {
+		t.Errorf("tags = %q, want the logger's tag for an invalid tag", tags)
+	}
+}
+
+func TestEventReturnsPostError(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+	postErr := errors.New("fluentd down")
+	p.setErr(postErr)
+	if err := l.Event(zapcore.InfoLevel, "", map[string]interface{}{"k": 1}); !errors.Is(err, postErr) {
+		t.Errorf("Event = %v, want the post error", err)
+	}
+}
+
+func TestEventCopiesNestedValues(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{RedactPatterns: []*regexp.Regexp{RedactEmails}})
+	fields := map[string]interface{}{
+		"user":   map[string]interface{}{"email": "ada@example.com"},
+		"emails": []interface{}{"ada@example.com", map[string]interface{}{"cc": "bob@example.com"}},
+	}
+	want := map[string]interface{}{
+		"user":   map[string]interface{}{"email": "ada@example.com"},
+		"emails": []interface{}{"ada@example.com", map[string]interface{}{"cc": "bob@example.com"}},
+	}
+	if err := l.Event(zapcore.InfoLevel, "", fields); err != nil {
+		t.Fatalf("Event: %v", err)
+	}
+
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("caller's fields modified: %v", fields)
+	}
+	rec := p.last(t)
+	if got := rec["user"].(map[string]interface{})["email"]; got == "ada@example.com" {
+		t.Errorf("nested email not redacted in the record: %v", rec["user"])
+	}
+
+	fields["user"].(map[string]interface{})["email"] = "changed"
+	if got := rec["user"].(map[string]interface{})["email"]; got == "changed" {
+		t.Error("record shares a nested map with the caller")
+	}
+}
+
+func TestEventTagPrecedence(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+	ctx := ContextWithTag(context.Background(), "app.audit")
+	fields := map[string]interface{}{"action": "delete"}
+
+	_ = l.Event(zapcore.InfoLevel, "app.events", fields)
+	_ = l.Ctx(ctx).Event(zapcore.InfoLevel, "app.events", fields)
+	_ = l.Ctx(ctx).Event(zapcore.InfoLevel, "", fields)
+	_ = l.Ctx(ctx).WithTag("app.billing").Event(zapcore.InfoLevel, "app.events", fields)
+
+	want := []string{"app.events", "app.audit", "app.audit", "app.events"}
+	if tags := p.postedTags(); !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags = %v, want %v", tags, want)
+	}
+}
+
+func BenchmarkEvent(b *testing.B) {
+	fields := map[string]interface{}{
+		"user":    "ada",
+		"attempt": 3,
+		"cached":  true,
+		"roles":   []interface{}{"admin", "dev"},
+	}
+
+	b.Run("event", func(b *testing.B) {
+		l := newBenchLogger(b, nil)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := l.Event(zapcore.InfoLevel, "", fields); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("infow", func(b *testing.B) {
+		l := newBenchLogger(b, nil)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			l.Infow("", "user", "ada", "attempt", 3, "cached", true, "roles", []interface{}{"admin", "dev"})
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package observability
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// LastResortPolicy selects what happens to a record the Fallback failed to
+// take, e.g. because the disk is full:
+//
+//	LastResortDrop    the record is counted as dropped
+//	LastResortStderr  the fallback line is written to stderr instead
+//	LastResortPanic   the logger panics with an error wrapping ErrFallbackFailed
+//
+// It only applies when a Fallback is set. LastResortPanic is meant for
+// systems that must not run on without their audit trail; the panic is not
+// recovered by the logger and may leave it unusable.
+type LastResortPolicy int
+
+const (
+	LastResortDrop LastResortPolicy = iota
+	LastResortStderr
+	LastResortPanic
+)
+
+// fallbackRecord is the line format written to the fallback sink. It keeps
+// the tag and event time so the line can be re-posted later.
+type fallbackRecord struct {
+	Tag    string      `json:"tag"`
+	Time   time.Time   `json:"time"`
+	Record interface{} `json:"record"`
+}
+
+// fallbackSink serializes records that could not be delivered to Fluentd
+// as JSON lines on an io.Writer.
+type fallbackSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func newFallbackSink(w io.Writer) *fallbackSink {
+	if w == nil {
+		return nil
+	}
+	return &fallbackSink{w: w}
+}
+
+// write persists one record, usually a map but possibly the message of a
+// Serializer. A nil sink has nowhere to put it and always fails.
+func (s *fallbackSink) write(tag string, t time.Time, entry interface{}) error {
+	if s == nil {
+		return fmt.Errorf("no fallback configured")
+	}
+
+	line, err := fallbackLine(tag, t, entry)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(line); err != nil {
+		return fmt.Errorf("fallback write failed: %w", err)
+	}
+	return nil
+}
+
+// fallbackLine encodes one line of the fallback format.
+func fallbackLine(tag string, t time.Time, entry interface{}) ([]byte, error) {
+	line, err := json.Marshal(fallbackRecord{Tag: tag, Time: t, Record: entry})
+	if err != nil {
+		return nil, fmt.Errorf("fallback encode failed: %w", err)
+	}
+	return append(line, '\n'), nil
+}
+
+// lastResort applies LastResort to a record the fallback failed to take
+// with err, and reports whether the record was persisted after all. It
+// writes to stderr directly, never through a logger, so it cannot recurse.
+func (f *FluentLogger) lastResort(tag string, t time.Time, entry interface{}, err error) bool {
+	switch f.cfg.LastResort {
+	case LastResortStderr:
+		if line, encErr := fallbackLine(tag, t, entry); encErr == nil {
+			if _, wErr := os.Stderr.Write(line); wErr == nil {
+				return true
+			}
+		}
+	case LastResortPanic:
+		f.drop(tag)
+		panic(fmt.Errorf("%w: %w", ErrFallbackFailed, err))
+	}
+	return false
+}
+
+// sync flushes the underlying writer if it supports it, e.g. *os.File or
+// *RotatingFile.
+func (s *fallbackSink) sync() error {
+	if s == nil {
+		return nil
+	}
+	syncer, ok := s.w.(interface{ Sync() error })
+	if !ok {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return syncer.Sync()
+}
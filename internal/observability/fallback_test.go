@@ -0,0 +1,82 @@
+package observability
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// failingWriter fails every write, like a fallback on a full disk.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("no space left on device") }
+
+func newFailingFallbackLogger(t *testing.T, policy LastResortPolicy) *SugaredLogger {
+	t.Helper()
+	l, p := newTestLogger(t, &SugaredLoggerConfig{Fallback: failingWriter{}, LastResort: policy})
+	p.setErr(errors.New("fluentd down"))
+	return l
+}
+
+// captureStderr returns what fn writes to os.Stderr.
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	out := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		out <- string(b)
+	}()
+	fn()
+	w.Close()
+	return <-out
+}
+
+func TestLastResortDrop(t *testing.T) {
+	l := newFailingFallbackLogger(t, LastResortDrop)
+	out := captureStderr(t, func() {
+		l.Infow("lost")
+		l.Infow("lost again")
+	})
+	if out != "" {
+		t.Errorf("stderr = %q, want nothing", out)
+	}
+	if s := l.Stats(); s.Dropped != 2 || s.Fallback != 0 {
+		t.Errorf("Dropped, Fallback = %d, %d, want 2, 0", s.Dropped, s.Fallback)
+	}
+}
+
+func TestLastResortStderr(t *testing.T) {
+	l := newFailingFallbackLogger(t, LastResortStderr)
+	out := captureStderr(t, func() { l.Infow("rescued", "n", 1) })
+	if !strings.Contains(out, `"message":"rescued"`) || !strings.Contains(out, `"tag":"`+defaultFluentTag+`"`) {
+		t.Errorf("stderr = %q, want the fallback line", out)
+	}
+	if s := l.Stats(); s.Dropped != 0 {
+		t.Errorf("Dropped = %d, want 0", s.Dropped)
+	}
+}
+
+func TestLastResortPanic(t *testing.T) {
+	l := newFailingFallbackLogger(t, LastResortPanic)
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.Is(err, ErrFallbackFailed) || !strings.Contains(err.Error(), "no space left") {
+			t.Errorf("recover() = %v, want ErrFallbackFailed with the fallback error", err)
+		}
+		if s := l.Stats(); s.Dropped != 1 {
+			t.Errorf("Dropped = %d, want 1", s.Dropped)
+		}
+	}()
+	l.Infow("fatal")
+	t.Error("write did not panic")
+}
@@ -0,0 +1,84 @@
+package observability
+
+import (
+	"encoding/json"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// rawJSONErrorSuffix is appended to the key of an invalid RawJSON field to
+// name the field marking it.
+const rawJSONErrorSuffix = "_raw_json_error"
+
+// debugValue marks the value of a DebugField. It marshals as the wrapped
+// value, so cores other than ours encode it transparently.
+type debugValue struct {
+	v interface{}
+}
+
+func (d debugValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.v)
+}
+
+// DebugField returns a field that is only included in entries logged at
+// DEBUG level or below; at higher levels the core strips it before the
+// record is sent. Use it for verbose context such as full request bodies:
+//
+//	logger.Infow("request handled", "status", 200, DebugField("body", body))
+func DebugField(key string, val interface{}) zap.Field {
+	return zap.Reflect(key, debugValue{v: val})
+}
+
+// lazyValue marks the value of a LazyField. It marshals as the result of
+// fn, so cores other than ours also defer the call to encoding time.
+type lazyValue struct {
+	fn func() interface{}
+}
+
+func (l lazyValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.fn())
+}
+
+// LazyField returns a field whose value is computed by fn only when an
+// entry carrying it is written, i.e. after it passed the level filter and
+// sampling. Use it for values that are expensive to compute:
+//
+//	logger.Debugw("state", LazyField("dump", func() interface{} { return s.Dump() }))
+//
+// fn is called once per entry written. A LazyField attached with With is
+// evaluated for every entry of that logger, possibly concurrently, so fn
+// must then be safe for concurrent use.
+func LazyField(key string, fn func() interface{}) zap.Field {
+	return zap.Reflect(key, lazyValue{fn: fn})
+}
+
+// RawJSON returns a field holding an already serialized JSON document,
+// e.g. one received from an upstream system. The document is nested into
+// the record as structure rather than escaped into a string:
+//
+//	logger.Infow("webhook received", RawJSON("payload", body))
+//
+// data is validated and copied when the field is created. If it is not
+// valid JSON, the field falls back to data as a string, and a
+// <key>_raw_json_error field carries the reason.
+func RawJSON(key string, data []byte) zap.Field {
+	var raw json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return zap.Inline(invalidRawJSON{key: key, data: string(data), err: err.Error()})
+	}
+	return zap.Reflect(key, raw)
+}
+
+// invalidRawJSON is the fallback of a RawJSON field given invalid input.
+type invalidRawJSON struct {
+	key  string
+	data string
+	err  string
+}
+
+func (r invalidRawJSON) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString(r.key, r.data)
+	enc.AddString(r.key+rawJSONErrorSuffix, r.err)
+	return nil
+}
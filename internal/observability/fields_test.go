@@ -0,0 +1,82 @@
+package observability
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestDebugField(t *testing.T) {
+	for _, tt := range []struct {
+		level zapcore.Level
+		want  bool
+	}{
+		{zapcore.DebugLevel, true},
+		{zapcore.InfoLevel, false},
+		{zapcore.WarnLevel, false},
+		{zapcore.ErrorLevel, false},
+	} {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			l, p := newTestLogger(t, &SugaredLoggerConfig{LogLevel: "debug"})
+			l.Logw(tt.level, "request handled", "status", 200, DebugField("body", "payload"))
+			l.With(DebugField("session", "s1")).Logw(tt.level, "bound")
+
+			recs := p.records()
+			if len(recs) != 2 {
+				t.Fatalf("got %d records, want 2", len(recs))
+			}
+			if recs[0]["status"] != int64(200) {
+				t.Errorf("status = %v, want 200", recs[0]["status"])
+			}
+			if body, ok := recs[0]["body"]; ok != tt.want || ok && body != "payload" {
+				t.Errorf("body = %v (present %v), want present %v", body, ok, tt.want)
+			}
+			if session, ok := recs[1]["session"]; ok != tt.want || ok && session != "s1" {
+				t.Errorf("session = %v (present %v), want present %v", session, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestLazyFieldSkippedForFilteredEntries(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{
+		LogLevel: "info",
+		Sampling: &SamplingConfig{Tick: time.Minute, Initial: 1, Thereafter: 0},
+	})
+	calls := 0
+	lazy := LazyField("dump", func() interface{} {
+		calls++
+		return "expensive"
+	})
+
+	l.Debugw("below level", lazy)
+	if calls != 0 {
+		t.Fatalf("fn called %d times for an entry below the level", calls)
+	}
+	l.Infow("sampled", lazy)
+	l.Infow("sampled", lazy)
+	if calls != 1 {
+		t.Fatalf("fn called %d times, want once for the sampled-in entry only", calls)
+	}
+	if rec := p.last(t); rec["dump"] != "expensive" {
+		t.Errorf("dump = %v, want expensive", rec["dump"])
+	}
+}
+
+func TestLazyFieldWith(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+	calls := 0
+	child := l.With(LazyField("n", func() interface{} {
+		calls++
+		return calls
+	}))
+	if calls != 0 {
+		t.Fatalf("fn called by With")
+	}
+	child.Infow("one")
+	child.Infow("two")
+	if calls != 2 || p.last(t)["n"] != int64(2) {
+		t.Errorf("calls = %d, last n = %#v; want fn evaluated per entry", calls, p.last(t)["n"])
+	}
+}
@@ -0,0 +1,319 @@
+package observability
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRotateMaxBytes = 100 << 20
+	rotatedTimeFormat     = "20060102T150405.000000000"
+	compressedSuffix      = ".gz"
+)
+
+// renameFile renames rotated segments; tests replace it to make rotation
+// fail.
+var renameFile = os.Rename
+
+// RotatingFileConfig configures a RotatingFile.
+type RotatingFileConfig struct {
+	// Path of the active segment, e.g. /var/log/app/fallback.jsonl.
+	Path string
+	// MaxBytes is the size at which the active segment is rotated.
+	// Defaults to 100MiB.
+	MaxBytes int64
+	// MaxBackups is the number of compressed segments to keep; zero keeps
+	// all of them.
+	MaxBackups int
+	// MaxAge removes compressed segments older than this; zero keeps them
+	// regardless of age.
+	MaxAge time.Duration
+}
+
+// RotatingFile is an io.WriteCloser meant to be used as the Fallback sink
+// during long outages. Rotated segments are gzip-compressed next to the
+// active file as <name>-<timestamp><ext>.gz and pruned by the retention
+// policy, so an outage cannot fill the disk. The file is fsynced on every
+// rotation and on Close.
+//
+// If a rotation fails, e.g. because the directory became read-only, the
+// active segment is reopened and keeps growing, and the next rotation is
+// attempted once another MaxBytes have been written. The failure is
+// returned by the next Sync.
+type RotatingFile struct {
+	cfg RotatingFileConfig
+
+	mu     sync.Mutex
+	file   *os.File
+	closed bool
+	size   int64
+	// rotateAt is the size at which the next rotation is attempted.
+	rotateAt int64
+	// rotateErr is the last rotation failure, not yet returned by Sync.
+	rotateErr error
+}
+
+// NewRotatingFile opens (or appends to) the active segment at cfg.Path.
+func NewRotatingFile(cfg *RotatingFileConfig) (*RotatingFile, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("rotating file path required")
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = defaultRotateMaxBytes
+	}
+
+	r := &RotatingFile{cfg: *cfg}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Write implements io.Writer. A write never straddles two segments.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return 0, os.ErrClosed
+	}
+	if r.size > 0 && r.size+int64(len(p)) > r.rotateAt {
+		if err := r.rotate(); err != nil {
+			r.rotateErr = err
+			r.rotateAt = r.size + r.cfg.MaxBytes
+		}
+	}
+	if r.file == nil {
+		// A failed rotation could not reopen the segment; retry
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Sync flushes the active segment to stable storage. It also returns the
+// error of a rotation that failed since the last Sync.
+func (r *RotatingFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rotateErr := r.rotateErr
+	r.rotateErr = nil
+	if r.file == nil {
+		return rotateErr
+	}
+	return errors.Join(r.file.Sync(), rotateErr)
+}
+
+// Close fsyncs and closes the active segment.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Sync()
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+	r.file = nil
+	return err
+}
+
+func (r *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.cfg.Path), 0o755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	f, err := os.OpenFile(r.cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	r.file = f
+	r.size = info.Size()
+	r.rotateAt = max(r.size, r.cfg.MaxBytes)
+	return nil
+}
+
+// rotate closes the active segment, compresses it and starts a new one. If
+// the segment cannot be moved aside, it is reopened, so writes keep going
+// to it. Callers must hold r.mu.
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync log file: %w", err)
+	}
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	r.file = nil
+
+	rotated := r.backupName(time.Now())
+	if err := renameFile(r.cfg.Path, rotated); err != nil {
+		err = fmt.Errorf("failed to rotate log file: %w", err)
+		if openErr := r.open(); openErr != nil {
+			return errors.Join(err, openErr)
+		}
+		return err
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+
+	if err := compressFile(rotated); err != nil {
+		return err
+	}
+	return r.prune()
+}
+
+func (r *RotatingFile) backupName(t time.Time) string {
+	ext := filepath.Ext(r.cfg.Path)
+	base := strings.TrimSuffix(r.cfg.Path, ext)
+	return base + "-" + t.UTC().Format(rotatedTimeFormat) + ext
+}
+
+// prune applies MaxBackups and MaxAge to the compressed segments.
+func (r *RotatingFile) prune() error {
+	if r.cfg.MaxBackups <= 0 && r.cfg.MaxAge <= 0 {
+		return nil
+	}
+
+	ext := filepath.Ext(r.cfg.Path)
+	pattern := strings.TrimSuffix(r.cfg.Path, ext) + "-*" + ext + compressedSuffix
+	backups, err := filepath.Glob(pattern)
+	if err != nil {
+		return err
+	}
+	// The timestamp in the name sorts chronologically; newest first.
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+
+	var errs []error
+	for i, name := range backups {
+		expired := false
+		if r.cfg.MaxAge > 0 {
+			if info, err := os.Stat(name); err == nil && time.Since(info.ModTime()) > r.cfg.MaxAge {
+				expired = true
+			}
+		}
+		if expired || r.cfg.MaxBackups > 0 && i >= r.cfg.MaxBackups {
+			if err := os.Remove(name); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// compressFile replaces name with a gzip-compressed name.gz. The compressed
+// file is fsynced before the original is removed.
+func compressFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return fmt.Errorf("failed to open rotated file: %w", err)
+	}
+	defer src.Close()
+
+	tmp := name + compressedSuffix + ".tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create compressed file: %w", err)
+	}
+
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if syncErr := dst.Sync(); err == nil {
+		err = syncErr
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to compress rotated file: %w", err)
+	}
+
+	if err := os.Rename(tmp, name+compressedSuffix); err != nil {
+		return fmt.Errorf("failed to rename compressed file: %w", err)
+	}
+	return os.Remove(name)
+}
+
+// ReplayFile re-posts the records of a fallback file (plain or .gz) to
+// Fluentd, in file order, with their original tag and time. It bypasses the
+// fallback sink so replaying the active fallback file cannot feed itself, and
+// stops at the first failure. The file is left in place; remove it once the
+// replay succeeded.
+func (l *SugaredLogger) ReplayFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open replay file: %w", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, compressedSuffix) {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("failed to open compressed replay file: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		b, err := br.ReadBytes('\n')
+		if len(bytes.TrimSpace(b)) > 0 {
+			if postErr := l.replayLine(b); postErr != nil {
+				return fmt.Errorf("replay of %s failed at line %d: %w", path, line, postErr)
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read replay file: %w", err)
+		}
+	}
+}
+
+func (l *SugaredLogger) replayLine(b []byte) error {
+	var rec fallbackRecord
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&rec); err != nil {
+		return fmt.Errorf("log decode failed: %w", err)
+	}
+
+	entry, ok := plainNumbers(rec.Record).(map[string]interface{})
+	if !ok {
+		return errors.New("log decode failed: record is not an object")
+	}
+	return l.fluent.postDirect(rec.Tag, rec.Time, entry)
+}
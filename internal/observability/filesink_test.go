@@ -0,0 +1,169 @@
+package observability
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestRotatingFile(t *testing.T, cfg RotatingFileConfig) *RotatingFile {
+	t.Helper()
+	r, err := NewRotatingFile(&cfg)
+	if err != nil {
+		t.Fatalf("NewRotatingFile: %v", err)
+	}
+	t.Cleanup(func() { _ = r.Close() })
+	return r
+}
+
+func backups(t *testing.T, path string) []string {
+	t.Helper()
+	ext := filepath.Ext(path)
+	names, err := filepath.Glob(strings.TrimSuffix(path, ext) + "-*" + ext + compressedSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return names
+}
+
+func readGzip(t *testing.T, name string) string {
+	t.Helper()
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestRotatingFileRotatesAndCompresses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fallback.jsonl")
+	r := newTestRotatingFile(t, RotatingFileConfig{Path: path, MaxBytes: 10})
+
+	for _, line := range []string{"aaaaaaaa\n", "bbbbbbbb\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	names := backups(t, path)
+	if len(names) != 1 {
+		t.Fatalf("backups = %v, want one", names)
+	}
+	if got := readGzip(t, names[0]); got != "aaaaaaaa\n" {
+		t.Errorf("rotated segment = %q", got)
+	}
+	if got, _ := os.ReadFile(path); string(got) != "bbbbbbbb\n" {
+		t.Errorf("active segment = %q", got)
+	}
+}
+
+func TestRotatingFilePrunesBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fallback.jsonl")
+	r := newTestRotatingFile(t, RotatingFileConfig{Path: path, MaxBytes: 1, MaxBackups: 2})
+
+	for i := 0; i < 5; i++ {
+		if _, err := r.Write([]byte("x\n")); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if names := backups(t, path); len(names) != 2 {
+		t.Errorf("backups = %v, want 2", names)
+	}
+}
+
+func TestRotatingFileKeepsWritingWhenRotationFails(t *testing.T) {
+	renameErr := errors.New("read-only file system")
+	defer func(rename func(string, string) error) { renameFile = rename }(renameFile)
+	renameFile = func(string, string) error { return renameErr }
+
+	path := filepath.Join(t.TempDir(), "fallback.jsonl")
+	r := newTestRotatingFile(t, RotatingFileConfig{Path: path, MaxBytes: 4})
+
+	for _, line := range []string{"one\n", "two\n", "three\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatalf("Write(%q): %v", line, err)
+		}
+	}
+	if got, _ := os.ReadFile(path); string(got) != "one\ntwo\nthree\n" {
+		t.Errorf("active segment = %q", got)
+	}
+	if err := r.Sync(); !errors.Is(err, renameErr) {
+		t.Errorf("Sync() = %v, want the rotation error", err)
+	}
+	if err := r.Sync(); err != nil {
+		t.Errorf("second Sync() = %v, want nil", err)
+	}
+}
+
+func TestRotatingFileWriteAfterClose(t *testing.T) {
+	r := newTestRotatingFile(t, RotatingFileConfig{Path: filepath.Join(t.TempDir(), "fallback.jsonl")})
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Write([]byte("x\n")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Write after Close = %v, want os.ErrClosed", err)
+	}
+}
+
+func TestReplayFile(t *testing.T) {
+	var buf bytes.Buffer
+	l, p := newTestLogger(t, &SugaredLoggerConfig{Fallback: &buf})
+	p.setErr(errors.New("fluentd down"))
+	l.Infow("first", "n", 1)
+	l.Infow("second", "n", 2)
+	if buf.Len() == 0 {
+		t.Fatal("nothing was written to the fallback")
+	}
+
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "fallback.jsonl")
+	if err := os.WriteFile(plain, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := compressFile(plain); err != nil {
+		t.Fatal(err)
+	}
+
+	p.setErr(nil)
+	if err := l.ReplayFile(plain + compressedSuffix); err != nil {
+		t.Fatalf("ReplayFile: %v", err)
+	}
+	recs := p.records()
+	if len(recs) != 2 || recs[0]["message"] != "first" || recs[1]["message"] != "second" {
+		t.Fatalf("replayed records = %v", recs)
+	}
+	if recs[1]["n"] != int64(2) {
+		t.Errorf("n = %#v, want int64(2)", recs[1]["n"])
+	}
+	for _, tag := range p.postedTags() {
+		if tag != defaultFluentTag {
+			t.Errorf("replayed tag = %q, want %q", tag, defaultFluentTag)
+		}
+	}
+}
+
+func TestReplayFileStopsAtBadLine(t *testing.T) {
+	l, _ := newTestLogger(t, nil)
+	path := filepath.Join(t.TempDir(), "fallback.jsonl")
+	if err := os.WriteFile(path, []byte("not json\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err := l.ReplayFile(path)
+	if err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("ReplayFile = %v, want a line 1 error", err)
+	}
+}
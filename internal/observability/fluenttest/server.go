@@ -0,0 +1,354 @@
+// Package fluenttest provides an in-process Fluentd forward server for
+// end-to-end tests of the logging pipeline.
+package fluenttest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// eventTimeType is the msgpack extension type of the forward protocol's
+// EventTime.
+const eventTimeType = 0
+
+// Event is an entry received by the test server.
+type Event struct {
+	Tag    string
+	Time   time.Time
+	Record map[string]interface{}
+}
+
+// StartTestServer starts a forward protocol server on a random local TCP
+// port and returns its address and a function returning the events
+// received so far, in arrival order. It implements enough of the protocol
+// for the fluent client: Message, Forward and PackedForward modes, msgpack
+// or JSON (MarshalAsJSON), and acks for RequestAck. The server is shut
+// down when the test ends; protocol errors fail the test.
+//
+// Point a logger at it with FluentHost and FluentPort taken from addr.
+func StartTestServer(t testing.TB) (addr string, received func() []Event) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("fluenttest: failed to listen: %v", err)
+	}
+
+	s := &server{t: t, ln: ln}
+	s.wg.Add(1)
+	go s.serve()
+	t.Cleanup(s.close)
+
+	return ln.Addr().String(), s.events
+}
+
+type server struct {
+	t  testing.TB
+	ln net.Listener
+	wg sync.WaitGroup
+
+	mu       sync.Mutex
+	received []Event
+	conns    map[net.Conn]struct{}
+	closed   bool
+}
+
+func (s *server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		if !s.track(conn) {
+			conn.Close()
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer s.untrack(conn)
+			if err := s.handle(conn); err != nil && !s.isClosed() {
+				s.t.Errorf("fluenttest: %v", err)
+			}
+		}()
+	}
+}
+
+func (s *server) track(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	if s.conns == nil {
+		s.conns = make(map[net.Conn]struct{})
+	}
+	s.conns[conn] = struct{}{}
+	return true
+}
+
+func (s *server) untrack(conn net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.conns, conn)
+	conn.Close()
+}
+
+func (s *server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+func (s *server) close() {
+	s.mu.Lock()
+	s.closed = true
+	s.ln.Close()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *server) events() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Event(nil), s.received...)
+}
+
+func (s *server) add(events ...Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.received = append(s.received, events...)
+}
+
+// handle reads messages from conn until it is closed. The first byte tells
+// JSON (an array) from msgpack.
+func (s *server) handle(conn net.Conn) error {
+	br := bufio.NewReader(conn)
+	first, err := br.Peek(1)
+	if err != nil {
+		return nil
+	}
+	if first[0] == '[' {
+		return s.handleJSON(br, conn)
+	}
+	return s.handleMsgpack(br, conn)
+}
+
+func (s *server) handleMsgpack(r io.Reader, w io.Writer) error {
+	mr := msgp.NewReader(r)
+	for {
+		v, err := mr.ReadIntf()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to decode message: %w", err)
+		}
+		msg, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("message is %T, not an array", v)
+		}
+		chunk, err := s.decode(msg)
+		if err != nil {
+			return err
+		}
+		if chunk != "" {
+			ack := msgp.AppendMapHeader(nil, 1)
+			ack = msgp.AppendString(ack, "ack")
+			ack = msgp.AppendString(ack, chunk)
+			if _, err := w.Write(ack); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *server) handleJSON(r io.Reader, w io.Writer) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	for {
+		var msg []interface{}
+		if err := dec.Decode(&msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to decode message: %w", err)
+		}
+		chunk, err := s.decode(jsonNumbers(msg).([]interface{}))
+		if err != nil {
+			return err
+		}
+		if chunk != "" {
+			if err := json.NewEncoder(w).Encode(map[string]string{"ack": chunk}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// decode records the events of one message and returns the chunk ID to
+// acknowledge, if one was requested.
+func (s *server) decode(msg []interface{}) (string, error) {
+	if len(msg) < 2 {
+		return "", fmt.Errorf("message has %d elements", len(msg))
+	}
+	tag, ok := msg[0].(string)
+	if !ok {
+		return "", fmt.Errorf("tag is %T, not a string", msg[0])
+	}
+
+	var (
+		events []Event
+		option interface{}
+		err    error
+	)
+	switch entries := msg[1].(type) {
+	case []interface{}:
+		// Forward mode: [tag, [[time, record], ...], option]
+		events, err = forwardEvents(tag, entries)
+		if len(msg) > 2 {
+			option = msg[2]
+		}
+	case []byte, string:
+		// PackedForward mode: [tag, msgpack stream of [time, record], option]
+		events, err = packedEvents(tag, entries)
+		if len(msg) > 2 {
+			option = msg[2]
+		}
+	default:
+		// Message mode: [tag, time, record, option]
+		if len(msg) < 3 {
+			return "", fmt.Errorf("message mode entry has %d elements", len(msg))
+		}
+		var ev Event
+		ev, err = newEvent(tag, msg[1], msg[2])
+		events = []Event{ev}
+		if len(msg) > 3 {
+			option = msg[3]
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	s.add(events...)
+
+	if opts, ok := option.(map[string]interface{}); ok {
+		if chunk, ok := opts["chunk"].(string); ok {
+			return chunk, nil
+		}
+	}
+	return "", nil
+}
+
+func forwardEvents(tag string, entries []interface{}) ([]Event, error) {
+	events := make([]Event, 0, len(entries))
+	for _, e := range entries {
+		pair, ok := e.([]interface{})
+		if !ok || len(pair) != 2 {
+			return nil, fmt.Errorf("forward entry is %T, not a [time, record] pair", e)
+		}
+		ev, err := newEvent(tag, pair[0], pair[1])
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+	return events, nil
+}
+
+func packedEvents(tag string, packed interface{}) ([]Event, error) {
+	var b []byte
+	switch p := packed.(type) {
+	case []byte:
+		b = p
+	case string:
+		b = []byte(p)
+	}
+	mr := msgp.NewReader(bytes.NewReader(b))
+	var entries []interface{}
+	for {
+		v, err := mr.ReadIntf()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode packed entry: %w", err)
+		}
+		entries = append(entries, v)
+	}
+	return forwardEvents(tag, entries)
+}
+
+func newEvent(tag string, tm, record interface{}) (Event, error) {
+	t, err := eventTime(tm)
+	if err != nil {
+		return Event{}, err
+	}
+	rec, ok := record.(map[string]interface{})
+	if !ok {
+		return Event{}, fmt.Errorf("record is %T, not a map", record)
+	}
+	return Event{Tag: tag, Time: t, Record: rec}, nil
+}
+
+// eventTime decodes the time of an entry: Unix seconds or an EventTime.
+func eventTime(v interface{}) (time.Time, error) {
+	switch v := v.(type) {
+	case int64:
+		return time.Unix(v, 0), nil
+	case uint64:
+		return time.Unix(int64(v), 0), nil
+	case time.Time:
+		return v, nil
+	case msgp.Extension:
+		// A *msgp.RawExtension, or the client's own EventTime type if it
+		// registered one.
+		data := make([]byte, v.Len())
+		if v.ExtensionType() != eventTimeType || len(data) != 8 || v.MarshalBinaryTo(data) != nil {
+			return time.Time{}, fmt.Errorf("invalid EventTime extension %d", v.ExtensionType())
+		}
+		sec := binary.BigEndian.Uint32(data[:4])
+		nsec := binary.BigEndian.Uint32(data[4:])
+		return time.Unix(int64(sec), int64(nsec)), nil
+	default:
+		return time.Time{}, fmt.Errorf("time is %T", v)
+	}
+}
+
+// jsonNumbers replaces the json.Number values in v with int64, or float64
+// if they are not integers, so that records decoded from JSON carry the
+// same types as those decoded from msgpack.
+func jsonNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = jsonNumbers(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = jsonNumbers(e)
+		}
+	}
+	return v
+}
@@ -0,0 +1,109 @@
+package fluenttest_test
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fluent/fluent-logger-golang/fluent"
+	"github.com/niquet/go-fluentd-logger-poc/internal/observability"
+	"github.com/niquet/go-fluentd-logger-poc/internal/observability/fluenttest"
+)
+
+// waitFor polls received until it returns n events.
+func waitFor(t *testing.T, received func() []fluenttest.Event, n int) []fluenttest.Event {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		events := received()
+		if len(events) >= n || time.Now().After(deadline) {
+			if len(events) != n {
+				t.Fatalf("received %d events, want %d: %v", len(events), n, events)
+			}
+			return events
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestStartTestServer(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		cfg  fluent.Config
+	}{
+		{"msgpack", fluent.Config{}},
+		{"json", fluent.Config{MarshalAsJSON: true}},
+		{"ack", fluent.Config{RequestAck: true}},
+		{"subsecond", fluent.Config{SubSecondPrecision: true}},
+		{"async", fluent.Config{Async: true}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, received := fluenttest.StartTestServer(t)
+			host, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.cfg.FluentHost = host
+			tt.cfg.FluentPort, _ = strconv.Atoi(port)
+
+			l, err := observability.NewSugaredLogger(&observability.SugaredLoggerConfig{
+				Tag:          "app.test",
+				FluentConfig: tt.cfg,
+			})
+			if err != nil {
+				t.Fatalf("NewSugaredLogger: %v", err)
+			}
+			before := time.Now()
+			l.Infow("hello", "user", "ada", "attempt", 3, "tags", []string{"a", "b"})
+			if err := l.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			ev := waitFor(t, received, 1)[0]
+			if ev.Tag != "app.test" {
+				t.Errorf("tag = %q, want app.test", ev.Tag)
+			}
+			if ev.Time.Before(before.Truncate(time.Second)) || ev.Time.After(time.Now()) {
+				t.Errorf("time = %v, want around %v", ev.Time, before)
+			}
+			if tt.cfg.SubSecondPrecision && ev.Time.Nanosecond() == 0 && before.Nanosecond() != 0 {
+				t.Errorf("time = %v, want sub-second precision", ev.Time)
+			}
+			rec := ev.Record
+			if rec["message"] != "hello" || rec["user"] != "ada" {
+				t.Errorf("record = %v", rec)
+			}
+			if n, ok := rec["attempt"].(int64); !ok || n != 3 {
+				t.Errorf("attempt = %#v, want int64(3)", rec["attempt"])
+			}
+			if tags, ok := rec["tags"].([]interface{}); !ok || len(tags) != 2 || tags[1] != "b" {
+				t.Errorf("tags = %#v", rec["tags"])
+			}
+		})
+	}
+}
+
+func TestStartTestServerKeepsOrder(t *testing.T) {
+	addr, received := fluenttest.StartTestServer(t)
+	host, port, _ := net.SplitHostPort(addr)
+	portNum, _ := strconv.Atoi(port)
+	l, err := observability.NewSugaredLogger(&observability.SugaredLoggerConfig{
+		FluentConfig: fluent.Config{FluentHost: host, FluentPort: portNum},
+	})
+	if err != nil {
+		t.Fatalf("NewSugaredLogger: %v", err)
+	}
+	for i := 0; i < 50; i++ {
+		l.Infow("entry", "i", i)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	for i, ev := range waitFor(t, received, 50) {
+		if ev.Record["i"] != int64(i) {
+			t.Fatalf("event %d has i = %v", i, ev.Record["i"])
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Flush pushes records the logger is holding toward their destination
+// without closing it: records in the offline buffer are resent, the
+// fallback sink is synced to stable storage and, with an async
+// FluentConfig, the records queued in the client are written. The fluent
+// client only drains its queue on Close, so for the latter Flush
+// reconnects like Reconnect: a fresh client is swapped in and the old one
+// closed. Loggers that cannot reconnect leave the async queue alone.
+func (l *SugaredLogger) Flush() error {
+	return l.fluent.flush()
+}
+
+func (f *FluentLogger) flush() error {
+	f.mu.RLock()
+	if f.closed.Load() {
+		f.mu.RUnlock()
+		return ErrLoggerClosed
+	}
+	f.resend()
+	err := f.fallback.sync()
+	f.mu.RUnlock()
+
+	return errors.Join(err, f.drain())
+}
+
+// drain waits until an async client has written the records queued in it,
+// by recycling it. It does nothing for sync clients, which hold nothing.
+func (f *FluentLogger) drain() error {
+	if !f.async {
+		return nil
+	}
+	if err := f.recycle(); err != nil && !errors.Is(err, errNoDialer) {
+		return err
+	}
+	return nil
+}
+
+// autoFlush calls Flush every interval until ctx is done.
+func (l *SugaredLogger) autoFlush(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := l.Flush(); err != nil {
+				l.fluent.self.warn("auto flush failed", "error", err)
+			}
+		}
+	}
+}
+
+// countWrite counts a successful write and, every SyncEveryN writes, wakes
+// the flush worker. It never blocks: if a flush is already pending, the
+// writes are covered by it.
+func (f *FluentLogger) countWrite() {
+	n := uint64(f.cfg.SyncEveryN)
+	if n == 0 || f.writes.Add(1)%n != 0 {
+		return
+	}
+	select {
+	case f.flushes <- struct{}{}:
+	default:
+	}
+}
+
+// flushOnSignal calls flush each time countWrite asks for it, until ctx is
+// done.
+func (f *FluentLogger) flushOnSignal(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-f.flushes:
+			if err := f.flush(); err != nil {
+				f.self.warn("flush failed", "error", err)
+			}
+		}
+	}
+}
+
+// goWorker runs fn in a background goroutine bound to the logger's
+// lifetime. Close cancels the context passed to fn and waits for it to
+// return.
+func (f *FluentLogger) goWorker(fn func(ctx context.Context)) {
+	f.workers.Add(1)
+	go func() {
+		defer f.workers.Done()
+		fn(f.lifetime)
+	}()
+}
+
+func (f *FluentLogger) stopWorkers() {
+	f.stop()
+	f.workers.Wait()
+}
@@ -0,0 +1,267 @@
+package observability
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fluent/fluent-logger-golang/fluent"
+	"go.uber.org/zap/zapcore"
+)
+
+// queuePoster stands in for an async fluent client: it queues what is
+// posted and only hands it to sink on Close.
+type queuePoster struct {
+	sink *fakePoster
+
+	mu     sync.Mutex
+	tags   []string
+	times  []time.Time
+	queued []interface{}
+}
+
+func (p *queuePoster) PostWithTime(tag string, tm time.Time, message interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tags = append(p.tags, tag)
+	p.times = append(p.times, tm)
+	p.queued = append(p.queued, message)
+	return nil
+}
+
+func (p *queuePoster) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, m := range p.queued {
+		if err := p.sink.PostWithTime(p.tags[i], p.times[i], m); err != nil {
+			return err
+		}
+	}
+	p.tags, p.times, p.queued = nil, nil, nil
+	return nil
+}
+
+// newAsyncTestLogger is newTestLogger with an async transport whose
+// clients queue records until they are closed. The records end up in the
+// returned sink.
+func newAsyncTestLogger(t *testing.T, cfg *SugaredLoggerConfig) (*SugaredLogger, *fakePoster) {
+	t.Helper()
+	if cfg == nil {
+		cfg = &SugaredLoggerConfig{}
+	}
+	cfg.FluentConfig.Async = true
+	l, _ := newTestLogger(t, cfg)
+
+	sink := &fakePoster{}
+	f := l.fluent
+	f.mu.Lock()
+	f.logger = &queuePoster{sink: sink}
+	f.dial = func() (poster, error) { return &queuePoster{sink: sink}, nil }
+	f.mu.Unlock()
+	return l, sink
+}
+
+// waitForRecords polls p until it holds n records.
+func waitForRecords(t *testing.T, p *fakePoster, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(p.records()) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d records, want %d", len(p.records()), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// syncCounter is a Fallback writer counting the Sync calls of flushes.
+type syncCounter struct {
+	bytes.Buffer
+	syncs atomic.Int32
+}
+
+func (w *syncCounter) Sync() error {
+	w.syncs.Add(1)
+	return nil
+}
+
+func TestFlushDrainsAsyncClient(t *testing.T) {
+	l, sink := newAsyncTestLogger(t, nil)
+	l.Infow("one")
+	l.Infow("two")
+	if n := len(sink.records()); n != 0 {
+		t.Fatalf("%d records written before Flush", n)
+	}
+
+	if err := l.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if n := len(sink.records()); n != 2 {
+		t.Fatalf("%d records written by Flush, want 2", n)
+	}
+
+	l.Infow("three")
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if rec := sink.last(t); rec["message"] != "three" {
+		t.Errorf("last record = %v, want three", rec)
+	}
+}
+
+func TestFlushSyncClient(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+	l.Infow("one")
+	if err := l.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if !l.LastRecycledAt().IsZero() {
+		t.Error("Flush recycled a sync client")
+	}
+	if n := len(p.records()); n != 1 {
+		t.Errorf("%d records, want 1", n)
+	}
+}
+
+func TestFlushSyncsFallback(t *testing.T) {
+	fallback := &syncCounter{}
+	l, p := newTestLogger(t, &SugaredLoggerConfig{Fallback: fallback})
+	l.Infow("one")
+	if err := l.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if n := fallback.syncs.Load(); n != 1 {
+		t.Errorf("Flush synced the fallback %d times, want 1", n)
+	}
+	if n := len(p.records()); n != 1 {
+		t.Errorf("%d records, want 1", n)
+	}
+}
+
+func TestFlushAfterClose(t *testing.T) {
+	l, _ := newTestLogger(t, nil)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Flush(); !errors.Is(err, ErrLoggerClosed) {
+		t.Errorf("Flush after Close = %v, want ErrLoggerClosed", err)
+	}
+}
+
+func TestAutoFlushInterval(t *testing.T) {
+	fallback := &syncCounter{}
+	_, _ = newTestLogger(t, &SugaredLoggerConfig{Fallback: fallback, AutoFlushInterval: 5 * time.Millisecond})
+
+	deadline := time.Now().Add(time.Second)
+	for fallback.syncs.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d flushes within a second", fallback.syncs.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAutoFlushIntervalDrainsAsyncClient(t *testing.T) {
+	l, sink := newAsyncTestLogger(t, &SugaredLoggerConfig{AutoFlushInterval: 5 * time.Millisecond})
+	l.Infow("one")
+	waitForRecords(t, sink, 1)
+}
+
+func TestAutoFlushStopsOnClose(t *testing.T) {
+	fallback := &syncCounter{}
+	l, _ := newTestLogger(t, &SugaredLoggerConfig{Fallback: fallback, AutoFlushInterval: time.Millisecond})
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	n := fallback.syncs.Load()
+	time.Sleep(20 * time.Millisecond)
+	if got := fallback.syncs.Load(); got != n {
+		t.Errorf("%d flushes after Close", got-n)
+	}
+}
+
+func TestSyncEveryN(t *testing.T) {
+	fallback := &syncCounter{}
+	l, _ := newTestLogger(t, &SugaredLoggerConfig{SyncEveryN: 3, Fallback: fallback})
+	l.Infow("one")
+	l.Infow("two")
+	time.Sleep(20 * time.Millisecond)
+	if n := fallback.syncs.Load(); n != 0 {
+		t.Fatalf("%d flushes before the 3rd write", n)
+	}
+
+	l.Infow("three")
+	deadline := time.Now().Add(time.Second)
+	for fallback.syncs.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("no flush after the 3rd write")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSyncEveryNRejectsAsync(t *testing.T) {
+	_, err := NewSugaredLogger(&SugaredLoggerConfig{SyncEveryN: 3, FluentConfig: fluent.Config{Async: true}})
+	if err == nil || !strings.Contains(err.Error(), "SyncEveryN") {
+		t.Fatalf("NewSugaredLogger = %v, want a SyncEveryN error", err)
+	}
+}
+
+func TestSyncEveryNSkipsFailedWrites(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{SyncEveryN: 2})
+	p.setErr(errors.New("fluentd down"))
+	l.Infow("lost")
+	p.setErr(nil)
+	l.Infow("one")
+	if got := l.fluent.writes.Load(); got != 1 {
+		t.Errorf("counted writes = %d, want 1", got)
+	}
+}
+
+func TestSyncFlushLevels(t *testing.T) {
+	l, sink := newAsyncTestLogger(t, &SugaredLoggerConfig{SyncFlushLevels: []zapcore.Level{zapcore.ErrorLevel}})
+	urgent := &fakePoster{}
+	l.fluent.urgent = urgent
+
+	l.Errorw("crash ahead")
+	if recs := urgent.records(); len(recs) != 1 || recs[0]["message"] != "crash ahead" {
+		t.Fatalf("sync client records = %v, want the error", recs)
+	}
+
+	if err := l.Event(zapcore.ErrorLevel, "", map[string]interface{}{"k": 1}); err != nil {
+		t.Fatalf("Event: %v", err)
+	}
+	l.Infow("routine")
+	if n := len(urgent.records()); n != 2 {
+		t.Errorf("sync client got %d records, want the error entry and event", n)
+	}
+	if n := len(sink.records()); n != 0 {
+		t.Errorf("%d records left the async buffer, want the info entry still buffered", n)
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if recs := sink.records(); len(recs) != 1 || recs[0]["message"] != "routine" {
+		t.Errorf("async records = %v, want the info entry", recs)
+	}
+	urgent.mu.Lock()
+	defer urgent.mu.Unlock()
+	if !urgent.closed {
+		t.Error("Close left the sync client open")
+	}
+}
+
+func TestSyncFlushLevelsFlushSyncClient(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{SyncFlushLevels: []zapcore.Level{zapcore.WarnLevel}})
+	l.Warnw("m")
+	if n := len(p.records()); n != 1 {
+		t.Errorf("%d records posted, want 1", n)
+	}
+	if l.fluent.urgent != nil {
+		t.Error("sync logger has a separate sync client")
+	}
+}
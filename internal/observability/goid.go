@@ -0,0 +1,28 @@
+package observability
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+)
+
+const goroutineIDKey = "goroutine_id"
+
+// goroutineID returns the ID of the calling goroutine by parsing the
+// header of its stack trace ("goroutine 42 [running]:"), or 0 if that
+// fails. It relies on an unspecified format and costs a stack capture; it
+// backs IncludeGoroutineID and, in race-enabled builds, the SingleProducer
+// ownership check.
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, err := strconv.ParseUint(string(b), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
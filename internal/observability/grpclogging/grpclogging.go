@@ -0,0 +1,135 @@
+// Package grpclogging provides gRPC server interceptors that log calls
+// through an observability.SugaredLogger. It is kept apart so that only
+// programs using it depend on gRPC, which is why the interceptors are
+// functions taking the logger rather than methods of SugaredLogger:
+//
+//	srv := grpc.NewServer(
+//		grpc.ChainUnaryInterceptor(grpclogging.UnaryServerInterceptor(logger)),
+//		grpc.ChainStreamInterceptor(grpclogging.StreamServerInterceptor(logger)),
+//	)
+package grpclogging
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/niquet/go-fluentd-logger-poc/internal/observability"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor returns a gRPC interceptor that logs every unary
+// call to l when it finishes, with the field names used by the
+// grpc-ecosystem logging middleware: grpc.service, grpc.method,
+// grpc.method_type, grpc.code, grpc.time_ms and peer.address. Entries go
+// through Ctx, so they carry the trace of the call, but not its deadline:
+// they are written even if the call timed out or was canceled. A panicking
+// handler is logged at ERROR with its stack and the call fails with
+// codes.Internal.
+func UnaryServerInterceptor(l *observability.SugaredLogger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		start := time.Now()
+		defer func() {
+			if r := recover(); r != nil {
+				err = recoverCall(ctx, l, info.FullMethod, r)
+			}
+			logCall(ctx, l, info.FullMethod, "unary", start, err)
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of
+// UnaryServerInterceptor; the entry is written when the stream ends.
+func StreamServerInterceptor(l *observability.SugaredLogger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		ctx := ss.Context()
+		start := time.Now()
+		defer func() {
+			if r := recover(); r != nil {
+				err = recoverCall(ctx, l, info.FullMethod, r)
+			}
+			logCall(ctx, l, info.FullMethod, streamType(info), start, err)
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// logCall writes the entry for a finished call at the level its status
+// code maps to.
+func logCall(ctx context.Context, l *observability.SugaredLogger, fullMethod, methodType string, start time.Time, err error) {
+	code := status.Code(err)
+	service, method := splitMethod(fullMethod)
+	kv := []interface{}{
+		"protocol", "grpc",
+		"grpc.component", "server",
+		"grpc.service", service,
+		"grpc.method", method,
+		"grpc.method_type", methodType,
+		"grpc.code", code.String(),
+		"grpc.time_ms", float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		kv = append(kv, "peer.address", p.Addr.String())
+	}
+	if err != nil {
+		kv = append(kv, "grpc.error", err.Error())
+	}
+	l.Ctx(context.WithoutCancel(ctx)).Logw(codeLevel(code), "finished call", kv...)
+}
+
+// recoverCall logs a handler panic and converts it into the error returned
+// to the client.
+func recoverCall(ctx context.Context, l *observability.SugaredLogger, fullMethod string, r interface{}) error {
+	service, method := splitMethod(fullMethod)
+	l.Ctx(context.WithoutCancel(ctx)).Errorw("grpc handler panicked",
+		"grpc.service", service,
+		"grpc.method", method,
+		"panic", fmt.Sprint(r),
+		"stacktrace", string(debug.Stack()),
+	)
+	return status.Errorf(codes.Internal, "panic: %v", r)
+}
+
+// codeLevel maps a status code to a level the way grpc-ecosystem does:
+// client errors at INFO, conditions worth watching at WARN, server errors
+// at ERROR.
+func codeLevel(code codes.Code) zapcore.Level {
+	switch code {
+	case codes.OK, codes.Canceled, codes.InvalidArgument, codes.NotFound,
+		codes.AlreadyExists, codes.Unauthenticated:
+		return zapcore.InfoLevel
+	case codes.DeadlineExceeded, codes.PermissionDenied, codes.ResourceExhausted,
+		codes.FailedPrecondition, codes.Aborted, codes.OutOfRange:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+// splitMethod splits "/pkg.Service/Method" into service and method.
+func splitMethod(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.Index(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], path.Base(fullMethod[i:])
+	}
+	return "unknown", fullMethod
+}
+
+func streamType(info *grpc.StreamServerInfo) string {
+	switch {
+	case info.IsClientStream && info.IsServerStream:
+		return "bidi_stream"
+	case info.IsClientStream:
+		return "client_stream"
+	default:
+		return "server_stream"
+	}
+}
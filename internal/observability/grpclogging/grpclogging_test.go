@@ -0,0 +1,141 @@
+package grpclogging
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fluent/fluent-logger-golang/fluent"
+	"github.com/niquet/go-fluentd-logger-poc/internal/observability"
+	"github.com/niquet/go-fluentd-logger-poc/internal/observability/fluenttest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// newLogger returns a logger posting to a fluenttest server, and a
+// function that closes it and returns the n records the server received.
+func newLogger(t *testing.T) (*observability.SugaredLogger, func(n int) []map[string]interface{}) {
+	t.Helper()
+	addr, received := fluenttest.StartTestServer(t)
+	host, portStr, _ := net.SplitHostPort(addr)
+	port, _ := strconv.Atoi(portStr)
+
+	l, err := observability.NewSugaredLogger(&observability.SugaredLoggerConfig{
+		FluentConfig: fluent.Config{FluentHost: host, FluentPort: port},
+	})
+	if err != nil {
+		t.Fatalf("NewSugaredLogger: %v", err)
+	}
+	return l, func(n int) []map[string]interface{} {
+		if err := l.Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+		deadline := time.Now().Add(2 * time.Second)
+		for len(received()) < n && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		var recs []map[string]interface{}
+		for _, e := range received() {
+			recs = append(recs, e.Record)
+		}
+		if len(recs) != n {
+			t.Fatalf("got %d records, want %d: %v", len(recs), n, recs)
+		}
+		return recs
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	l, records := newLogger(t)
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 4000}})
+	info := &grpc.UnaryServerInfo{FullMethod: "/pkg.Users/Get"}
+
+	_, err := UnaryServerInterceptor(l)(ctx, nil, info, func(context.Context, interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "no such user")
+	})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("err = %v, want NotFound", err)
+	}
+
+	recs := records(1)
+	rec := recs[0]
+	for k, want := range map[string]interface{}{
+		"grpc.service":     "pkg.Users",
+		"grpc.method":      "Get",
+		"grpc.method_type": "unary",
+		"grpc.code":        "NotFound",
+		"peer.address":     "10.0.0.1:4000",
+		"severity":         "info",
+	} {
+		if rec[k] != want {
+			t.Errorf("%s = %v, want %v", k, rec[k], want)
+		}
+	}
+}
+
+func TestUnaryServerInterceptorExpiredDeadline(t *testing.T) {
+	l, records := newLogger(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	info := &grpc.UnaryServerInfo{FullMethod: "/pkg.Users/Get"}
+
+	_, err := UnaryServerInterceptor(l)(ctx, nil, info, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		<-ctx.Done()
+		return nil, status.FromContextError(ctx.Err()).Err()
+	})
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("err = %v, want DeadlineExceeded", err)
+	}
+	if rec := records(1)[0]; rec["grpc.code"] != "DeadlineExceeded" {
+		t.Errorf("record = %v, want a DeadlineExceeded call", rec)
+	}
+}
+
+func TestUnaryServerInterceptorRecoversPanic(t *testing.T) {
+	l, records := newLogger(t)
+	info := &grpc.UnaryServerInfo{FullMethod: "/pkg.Users/Get"}
+
+	_, err := UnaryServerInterceptor(l)(context.Background(), nil, info, func(context.Context, interface{}) (interface{}, error) {
+		panic("boom")
+	})
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("err = %v, want Internal", err)
+	}
+
+	recs := records(2)
+	if recs[0]["panic"] != "boom" || recs[0]["severity"] != "error" {
+		t.Errorf("panic record = %v", recs[0])
+	}
+	if recs[1]["grpc.code"] != "Internal" {
+		t.Errorf("call record = %v", recs[1])
+	}
+}
+
+type fakeStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s fakeStream) Context() context.Context { return s.ctx }
+
+func TestStreamServerInterceptor(t *testing.T) {
+	l, records := newLogger(t)
+	info := &grpc.StreamServerInfo{FullMethod: "/pkg.Users/Watch", IsServerStream: true}
+
+	err := StreamServerInterceptor(l)(nil, fakeStream{ctx: context.Background()}, info, func(interface{}, grpc.ServerStream) error {
+		return errors.New("backend down")
+	})
+	if status.Code(err) != codes.Unknown {
+		t.Fatalf("err = %v, want Unknown", err)
+	}
+
+	recs := records(1)
+	if recs[0]["grpc.method_type"] != "server_stream" || recs[0]["severity"] != "error" || recs[0]["grpc.error"] != "backend down" {
+		t.Errorf("record = %v", recs[0])
+	}
+}
@@ -0,0 +1,85 @@
+package observability
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// writeDurationMetric is the name under which WriteMetrics exposes the
+// write duration histogram.
+const writeDurationMetric = "fluentlogger_write_duration_seconds"
+
+// writeDurationBuckets are the upper bounds, in seconds, of the write
+// duration histogram buckets.
+var writeDurationBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// durationHistogram counts durations into writeDurationBuckets. The zero
+// value is ready to use.
+type durationHistogram struct {
+	// counts[i] counts durations in bucket i; the last slot counts those
+	// above the largest bound.
+	counts [13]atomic.Uint64
+	count  atomic.Uint64
+	sumNs  atomic.Int64
+}
+
+func (h *durationHistogram) observe(d time.Duration) {
+	i := 0
+	for i < len(writeDurationBuckets) && d.Seconds() > writeDurationBuckets[i] {
+		i++
+	}
+	h.counts[i].Add(1)
+	h.count.Add(1)
+	h.sumNs.Add(int64(d))
+}
+
+// HistogramSnapshot is a point-in-time copy of a duration histogram.
+// Buckets are cumulative, as in Prometheus: Buckets[i] counts the
+// observations of at most Bounds[i] seconds.
+type HistogramSnapshot struct {
+	Bounds  []float64 `json:"bounds"`
+	Buckets []uint64  `json:"buckets"`
+	Count   uint64    `json:"count"`
+	Sum     float64   `json:"sum"`
+}
+
+func (h *durationHistogram) snapshot() HistogramSnapshot {
+	s := HistogramSnapshot{
+		Bounds:  writeDurationBuckets,
+		Buckets: make([]uint64, len(writeDurationBuckets)),
+		Count:   h.count.Load(),
+		Sum:     time.Duration(h.sumNs.Load()).Seconds(),
+	}
+	var cum uint64
+	for i := range writeDurationBuckets {
+		cum += h.counts[i].Load()
+		s.Buckets[i] = cum
+	}
+	return s
+}
+
+// WriteMetrics writes the logger's transport metrics to w in the Prometheus
+// text exposition format, for serving from a /metrics handler. Currently
+// that is the fluentlogger_write_duration_seconds histogram of transport
+// write durations.
+func (l *SugaredLogger) WriteMetrics(w io.Writer) error {
+	s := l.fluent.writeDurations.snapshot()
+
+	_, err := fmt.Fprintf(w, "# HELP %s Duration of writes to the Fluent transport.\n# TYPE %s histogram\n",
+		writeDurationMetric, writeDurationMetric)
+	if err != nil {
+		return err
+	}
+	for i, bound := range s.Bounds {
+		le := strconv.FormatFloat(bound, 'g', -1, 64)
+		if _, err := fmt.Fprintf(w, "%s_bucket{le=%q} %d\n", writeDurationMetric, le, s.Buckets[i]); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n%s_sum %g\n%s_count %d\n",
+		writeDurationMetric, s.Count, writeDurationMetric, s.Sum, writeDurationMetric, s.Count)
+	return err
+}
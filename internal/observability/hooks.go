@@ -0,0 +1,47 @@
+package observability
+
+import "runtime/debug"
+
+// OnClose registers fn to run when the logger is closed, after its records
+// have been flushed, e.g. to emit a final metric. Hooks run once, in the
+// reverse order of registration, also when the flush timed out or was
+// aborted. A panicking hook is reported to the self-logger and does not
+// keep the other hooks from running. Hooks registered through a derived
+// logger run when the root is closed; one registered after Close runs
+// right away.
+//
+// The transport is closed by the time hooks run, so entries they log are
+// rejected with ErrLoggerClosed.
+func (l *SugaredLogger) OnClose(fn func()) {
+	f := l.fluent
+	f.hooksMu.Lock()
+	if !f.hooksRan {
+		f.hooks = append(f.hooks, fn)
+		f.hooksMu.Unlock()
+		return
+	}
+	f.hooksMu.Unlock()
+	f.runHook(fn)
+}
+
+// runHooks runs the hooks registered with OnClose, last first. Only the
+// first call runs them.
+func (f *FluentLogger) runHooks() {
+	f.hooksMu.Lock()
+	hooks := f.hooks
+	f.hooks, f.hooksRan = nil, true
+	f.hooksMu.Unlock()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		f.runHook(hooks[i])
+	}
+}
+
+func (f *FluentLogger) runHook(fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			f.self.warn("close hook panicked", "panic", r, "stack", string(debug.Stack()))
+		}
+	}()
+	fn()
+}
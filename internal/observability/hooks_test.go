@@ -0,0 +1,61 @@
+package observability
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOnCloseOrder(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+	var order []string
+	l.OnClose(func() { order = append(order, "first") })
+	l.WithComponent("billing").OnClose(func() { order = append(order, "derived") })
+	l.OnClose(func() {
+		p.mu.Lock()
+		closed := p.closed
+		p.mu.Unlock()
+		if !closed {
+			t.Error("hook ran before the transport was closed")
+		}
+		order = append(order, "last")
+	})
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+	if want := []string{"last", "derived", "first"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("hooks ran as %q, want %q", order, want)
+	}
+
+	var late bool
+	l.OnClose(func() { late = true })
+	if !late {
+		t.Error("hook registered after Close did not run")
+	}
+}
+
+func TestOnClosePanicIsolated(t *testing.T) {
+	l, _ := newTestLogger(t, nil)
+	var self bytes.Buffer
+	l.fluent.self = &selfLogger{w: &self}
+
+	var ran []int
+	l.OnClose(func() { ran = append(ran, 1) })
+	l.OnClose(func() { panic("hook failed") })
+	l.OnClose(func() { ran = append(ran, 3) })
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if want := []int{3, 1}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("hooks ran = %v, want %v", ran, want)
+	}
+	if !strings.Contains(self.String(), "close hook panicked") || !strings.Contains(self.String(), "hook failed") {
+		t.Errorf("self-log = %q, want the hook panic", self.String())
+	}
+}
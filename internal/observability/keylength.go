@@ -0,0 +1,111 @@
+package observability
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// keyHashLen is the length of the hash KeyHash ends keys in.
+const keyHashLen = 8
+
+// minKeyLength is the shortest MaxKeyLength: room for at least one byte of
+// the key, an underscore and the KeyHash hash, and a collision suffix up
+// to _9.
+const minKeyLength = keyHashLen + 4
+
+// KeyOverflowPolicy selects what happens to field keys longer than
+// MaxKeyLength bytes:
+//
+//	KeyTruncate  the key is cut to MaxKeyLength
+//	KeyHash      the key is cut short and ends in a hash of the full key,
+//	             keeping keys that share a long prefix apart
+//	KeyDrop      the field is left out
+//
+// A shortened key that collides with another key of the same object gets
+// a _2, _3, ... suffix, still within MaxKeyLength, so no field overwrites
+// another.
+type KeyOverflowPolicy int
+
+const (
+	KeyTruncate KeyOverflowPolicy = iota
+	KeyHash
+	KeyDrop
+)
+
+// limitKeys applies KeyOverflowPolicy to the keys of entry and of the
+// objects nested in it, also inside arrays. The standard keys are left
+// alone.
+func (f *FluentLogger) limitKeys(entry map[string]interface{}) {
+	limitKeys(entry, f.cfg.MaxKeyLength, f.cfg.KeyOverflowPolicy, f.standard)
+}
+
+func limitKeys(m map[string]interface{}, max int, policy KeyOverflowPolicy, keep map[string]bool) {
+	var long []string
+	for k, v := range m {
+		limitNestedKeys(v, max, policy)
+		if len(k) > max && !keep[k] {
+			long = append(long, k)
+		}
+	}
+	// Sorted, so that collisions resolve the same way every time.
+	sort.Strings(long)
+	for _, k := range long {
+		v := m[k]
+		delete(m, k)
+		if policy == KeyDrop {
+			continue
+		}
+		short := shortenKey(k, max, policy, "")
+		for n := 2; ; n++ {
+			if _, taken := m[short]; !taken {
+				break
+			}
+			short = shortenKey(k, max, policy, "_"+strconv.Itoa(n))
+		}
+		m[short] = v
+	}
+}
+
+// limitNestedKeys is limitKeys for the objects in v, a field value.
+func limitNestedKeys(v interface{}, max int, policy KeyOverflowPolicy) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		limitKeys(v, max, policy, nil)
+	case []interface{}:
+		for _, e := range v {
+			limitNestedKeys(e, max, policy)
+		}
+	}
+}
+
+// shortenKey returns key cut to max bytes including suffix, a collision
+// suffix or "". Under KeyHash, the hash of key goes between the cut key
+// and suffix; only the readable part of the key is cut for it, unless
+// suffix leaves no room.
+func shortenKey(key string, max int, policy KeyOverflowPolicy, suffix string) string {
+	if policy != KeyHash {
+		return cutKey(key, max-len(suffix)) + suffix
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	hash := fmt.Sprintf("_%0*x", keyHashLen, h.Sum32())
+	return cutKey(cutKey(key, max-len(hash)-len(suffix))+hash, max-len(suffix)) + suffix
+}
+
+// cutKey returns the longest prefix of key of at most max bytes that does
+// not split a UTF-8 sequence.
+func cutKey(key string, max int) string {
+	if max <= 0 {
+		return ""
+	}
+	if len(key) <= max {
+		return key
+	}
+	for max > 0 && !utf8.RuneStart(key[max]) {
+		max--
+	}
+	return key[:max]
+}
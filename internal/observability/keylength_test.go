@@ -0,0 +1,124 @@
+package observability
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fluent/fluent-logger-golang/fluent"
+)
+
+func TestMaxKeyLengthCollisions(t *testing.T) {
+	const max = 12
+	long1 := "request_header_user_agent"
+	long2 := "request_header_user_id"
+	tests := []struct {
+		policy KeyOverflowPolicy
+		want   []string
+	}{
+		{KeyTruncate, []string{"request_head", "request_he_2", "short"}},
+		{KeyDrop, []string{"short"}},
+	}
+	for _, tt := range tests {
+		l, p := newTestLogger(t, &SugaredLoggerConfig{MaxKeyLength: max, KeyOverflowPolicy: tt.policy})
+		l.Infow("m", long1, 1, long2, 2, "short", 3)
+
+		rec := p.last(t)
+		for _, k := range tt.want {
+			if _, ok := rec[k]; !ok {
+				t.Errorf("policy %d: key %q missing from %v", tt.policy, k, rec)
+			}
+		}
+		for k := range rec {
+			if len(k) > max && !(k == "timestamp" || k == "severity") {
+				t.Errorf("policy %d: key %q longer than %d", tt.policy, k, max)
+			}
+		}
+		if tt.policy == KeyTruncate && (rec["request_head"] != int64(1) || rec["request_he_2"] != int64(2)) {
+			t.Errorf("values = %v, %v, want 1, 2", rec["request_head"], rec["request_he_2"])
+		}
+	}
+}
+
+func TestMaxKeyLengthHash(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{MaxKeyLength: 16, KeyOverflowPolicy: KeyHash})
+	l.Infow("m", "request_header_user_agent", 1, "request_header_user_id", 2)
+
+	var hashed []string
+	for k := range p.last(t) {
+		if strings.HasPrefix(k, "request") {
+			hashed = append(hashed, k)
+			if len(k) != 16 || !strings.HasPrefix(k, "request_") {
+				t.Errorf("key %q, want 7 bytes of the key and a hash", k)
+			}
+		}
+	}
+	if len(hashed) != 2 || hashed[0] == hashed[1] {
+		t.Errorf("hashed keys = %q, want two distinct keys", hashed)
+	}
+}
+
+func TestMaxKeyLengthHashCollision(t *testing.T) {
+	const long = "request_header_user_agent"
+	hashed := shortenKey(long, 16, KeyHash, "")
+	l, p := newTestLogger(t, &SugaredLoggerConfig{MaxKeyLength: 16, KeyOverflowPolicy: KeyHash})
+	l.Infow("m", long, 1, hashed, 2)
+
+	rec := p.last(t)
+	if rec[hashed] != int64(2) {
+		t.Fatalf("%s = %v, want the field that already had the key", hashed, rec[hashed])
+	}
+	hash := hashed[len(hashed)-keyHashLen-1:]
+	want := "reque" + hash + "_2"
+	if rec[want] != int64(1) {
+		t.Errorf("record = %v, want the colliding key as %s, keeping the hash", rec, want)
+	}
+}
+
+func TestMaxKeyLengthNested(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{MaxKeyLength: 12})
+	l.Infow("m",
+		"obj", map[string]interface{}{"a_very_long_key": 1},
+		"list", []interface{}{map[string]interface{}{"another_long_key": 2}, []interface{}{map[string]interface{}{"deeply_nested_key": 3}}},
+	)
+
+	rec := p.last(t)
+	if obj := rec["obj"].(map[string]interface{}); !hasKey(obj, "a_very_long_") {
+		t.Errorf("obj = %v, want the key cut", obj)
+	}
+	list := rec["list"].([]interface{})
+	if m := list[0].(map[string]interface{}); !hasKey(m, "another_long") {
+		t.Errorf("list[0] = %v, want the key cut", m)
+	}
+	if m := list[1].([]interface{})[0].(map[string]interface{}); !hasKey(m, "deeply_neste") {
+		t.Errorf("list[1][0] = %v, want the key cut", m)
+	}
+}
+
+func TestMaxKeyLengthStandardKeysExempt(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{MaxKeyLength: 12, KeyOverflowPolicy: KeyDrop})
+	l.Infow("m")
+	if _, ok := p.last(t)["timestamp"]; !ok {
+		t.Error("standard key dropped")
+	}
+}
+
+func TestNewSugaredLoggerRejectsShortMaxKeyLength(t *testing.T) {
+	for _, n := range []int{1, minKeyLength - 1} {
+		cfg := &SugaredLoggerConfig{MaxKeyLength: n, KeyOverflowPolicy: KeyHash, FluentConfig: fluent.Config{Async: true}}
+		if l, err := NewSugaredLogger(cfg); err == nil {
+			l.Close()
+			t.Errorf("MaxKeyLength %d accepted", n)
+		}
+	}
+	cfg := &SugaredLoggerConfig{MaxKeyLength: minKeyLength, KeyOverflowPolicy: KeyHash, FluentConfig: fluent.Config{Async: true}}
+	l, err := NewSugaredLogger(cfg)
+	if err != nil {
+		t.Fatalf("MaxKeyLength %d rejected: %v", minKeyLength, err)
+	}
+	l.Close()
+}
+
+func hasKey(m map[string]interface{}, k string) bool {
+	_, ok := m[k]
+	return ok
+}
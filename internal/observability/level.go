@@ -0,0 +1,107 @@
+package observability
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// SetLevel changes the minimum level of this logger and of every logger
+// derived from the same root. During a BoostVerbosity window it also
+// replaces the level restored when the window ends. On a logger from a
+// LoggerRegistry it changes only the level of that named logger.
+func (l *SugaredLogger) SetLevel(lvl zapcore.Level) {
+	if l.level != nil {
+		l.level.SetLevel(lvl)
+		return
+	}
+	f := l.fluent
+	f.boost.mu.Lock()
+	defer f.boost.mu.Unlock()
+
+	if f.boost.active {
+		f.boost.restore = lvl
+	}
+	f.level.SetLevel(lvl)
+}
+
+// verbosityBoost tracks the window opened by BoostVerbosity.
+type verbosityBoost struct {
+	mu      sync.Mutex
+	active  bool
+	restore zapcore.Level
+	// gen identifies the current window, so the timer of a window that
+	// was since extended does not end it.
+	gen   uint64
+	timer *time.Timer
+}
+
+// BoostVerbosity sets the level to lvl and suspends sampling for d, e.g.
+// to get complete logs during an incident, then restores the previous
+// level and resumes sampling. Calling it again while a window is open
+// replaces lvl and restarts the window with the new d; the level restored
+// at the end is still the one from before the first call.
+func (l *SugaredLogger) BoostVerbosity(lvl zapcore.Level, d time.Duration) {
+	f := l.fluent
+	b := &f.boost
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.active {
+		b.active = true
+		b.restore = f.level.Level()
+	} else {
+		b.timer.Stop()
+	}
+	b.gen++
+	gen := b.gen
+
+	f.level.SetLevel(lvl)
+	f.sampler.suspend(true)
+	f.self.info("verbosity boosted", "level", lvl, "duration", d)
+
+	b.timer = time.AfterFunc(d, func() {
+		f.endBoost(gen)
+	})
+}
+
+// endBoost closes the boost window gen, unless it was since extended.
+func (f *FluentLogger) endBoost(gen uint64) {
+	b := &f.boost
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.active || b.gen != gen {
+		return
+	}
+	b.active = false
+	f.level.SetLevel(b.restore)
+	f.sampler.suspend(false)
+	f.self.info("verbosity restored", "level", b.restore)
+}
+
+// suspend turns sampling off or back on.
+func (s *sampler) suspend(off bool) {
+	if s != nil {
+		s.suspended.Store(off)
+	}
+}
+
+// discardSet is the set of levels listed in DiscardLevels.
+type discardSet [samplerLevels]bool
+
+func newDiscardSet(levels []zapcore.Level) discardSet {
+	var d discardSet
+	for _, lvl := range levels {
+		if lvl >= zapcore.DebugLevel && lvl <= zapcore.FatalLevel {
+			d[lvl-zapcore.DebugLevel] = true
+		}
+	}
+	return d
+}
+
+// discards reports whether entries at lvl are discarded by DiscardLevels.
+func (f *FluentLogger) discards(lvl zapcore.Level) bool {
+	return lvl >= zapcore.DebugLevel && lvl <= zapcore.FatalLevel && f.discard[lvl-zapcore.DebugLevel]
+}
@@ -0,0 +1,68 @@
+package observability
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// waitForLevel polls until l is at lvl, failing t after a second.
+func waitForLevel(t *testing.T, l *SugaredLogger, lvl zapcore.Level) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for l.Level() != lvl {
+		if time.Now().After(deadline) {
+			t.Fatalf("level %v, want %v", l.Level(), lvl)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBoostVerbosity(t *testing.T) {
+	sampling := &SamplingConfig{Tick: time.Hour, Initial: 1}
+	l, p := newTestLogger(t, &SugaredLoggerConfig{LogLevel: "info", Sampling: sampling})
+
+	l.BoostVerbosity(zapcore.DebugLevel, 30*time.Millisecond)
+	for i := 0; i < 3; i++ {
+		l.Debugw("repeated")
+	}
+	if n := len(p.records()); n != 3 {
+		t.Errorf("%d of 3 debug entries logged during the boost, want all: sampling is suspended", n)
+	}
+
+	waitForLevel(t, l, zapcore.InfoLevel)
+	l.Debugw("after")
+	if n := len(p.records()); n != 3 {
+		t.Errorf("debug entry logged after the boost expired")
+	}
+}
+
+func TestBoostVerbosityExtended(t *testing.T) {
+	l, _ := newTestLogger(t, &SugaredLoggerConfig{LogLevel: "warn"})
+
+	l.BoostVerbosity(zapcore.InfoLevel, 20*time.Millisecond)
+	l.BoostVerbosity(zapcore.DebugLevel, 300*time.Millisecond)
+	time.Sleep(60 * time.Millisecond)
+	if lvl := l.Level(); lvl != zapcore.DebugLevel {
+		t.Fatalf("level %v after the first window would have ended, want the extended debug", lvl)
+	}
+
+	// The level from before the first call is restored
+	waitForLevel(t, l, zapcore.WarnLevel)
+}
+
+func TestSetLevelDuringBoost(t *testing.T) {
+	l, _ := newTestLogger(t, &SugaredLoggerConfig{LogLevel: "info"})
+
+	l.BoostVerbosity(zapcore.DebugLevel, 20*time.Millisecond)
+	l.SetLevel(zapcore.ErrorLevel)
+	if lvl := l.Level(); lvl != zapcore.ErrorLevel {
+		t.Errorf("level %v, want SetLevel to apply at once", lvl)
+	}
+	waitForLevel(t, l, zapcore.ErrorLevel)
+	time.Sleep(40 * time.Millisecond)
+	if lvl := l.Level(); lvl != zapcore.ErrorLevel {
+		t.Errorf("level %v after the boost ended, want the level set during it", lvl)
+	}
+}
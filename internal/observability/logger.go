@@ -1,10 +1,15 @@
 package observability
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"runtime/debug"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -20,31 +25,621 @@ const (
 	defaultLogLevel                 = zapcore.DebugLevel
 	defaultShutdownTimeout          = 5 * time.Second
 	compatibleLevelWarningUpperCase = "WARNING"
+
+	defaultTimestampKey = "timestamp"
+	defaultSequenceKey  = "seq"
+
+	defaultDualTimestampKey = "ts"
+
+	schemaVersionKey   = "schema_version"
+	defaultFuncNameKey = "func"
+
+	rawLogKey      = "raw_log"
+	decodeErrorKey = "decode_error"
 )
 
 var (
 	ErrLoggerClosed = errors.New("logger is closed")
+	// ErrWriteAbandoned is returned when a sync write could not complete
+	// within the deadline of the context it was logged with.
+	ErrWriteAbandoned = errors.New("log write abandoned: context deadline exceeded")
+	// ErrCircuitOpen is returned when delivery is skipped because the
+	// circuit breaker is open and no fallback took the record.
+	ErrCircuitOpen = errors.New("log delivery skipped: circuit open")
+	// ErrPostPanicked is returned when the transport panicked on a record,
+	// e.g. on a value its encoder cannot handle.
+	ErrPostPanicked = errors.New("log delivery panicked")
+	// ErrReplayInProgress is returned by ReplayBuffered when buffered
+	// records are already being resent.
+	ErrReplayInProgress = errors.New("buffered records are already being replayed")
+	// ErrMissingTimestamp is returned by Write when RequireTimestamp is set
+	// and the entry carries no readable timestamp.
+	ErrMissingTimestamp = errors.New("log entry has no timestamp")
+	// ErrDuplicateKey is returned for entries carrying a field key twice
+	// when DuplicateKeyPolicy is DuplicateError.
+	ErrDuplicateKey = errors.New("log entry has duplicate field key")
+	// ErrSchemaViolation is returned for records the SchemaValidator
+	// rejects.
+	ErrSchemaViolation = errors.New("log record violates its schema")
+	// ErrFallbackFailed is what the logger panics with when the Fallback
+	// failed and LastResort is LastResortPanic.
+	ErrFallbackFailed = errors.New("log fallback failed")
 )
 
+// poster is the subset of *fluent.Fluent the write path depends on. It is
+// the seam for substituting the transport, e.g. with an in-memory fake.
+type poster interface {
+	PostWithTime(tag string, tm time.Time, message interface{}) error
+	Close() error
+}
+
 // FluentLogger implements zapcore.WriteSyncer with thread safety.
 type FluentLogger struct {
-	logger  *fluent.Fluent
+	logger  poster
+	cfg     *SugaredLoggerConfig
 	tag     string
-	closed  atomic.Bool
 	timeout time.Duration
+	// dial builds a replacement for logger; nil if it cannot be rebuilt.
+	// endpoint is the FluentConfig logger was built from. Reconfigure
+	// replaces both, under mu.
+	dial     func() (poster, error)
+	endpoint fluent.Config
+	// redial wakes the worker that recovers unix socket transports; nil
+	// if there is none.
+	redial chan struct{}
+	// mu is held for reading by every write and for writing when the
+	// flush begins. Between Sync being called and the flush beginning
+	// (closed), writes are still accepted and end up in the flush; only
+	// writes after that are rejected.
+	mu     sync.RWMutex
+	closed atomic.Bool
+	// flushOnce starts the flush that closes the transport; flushed is
+	// closed once it is done, and flushErr then holds its result.
+	flushOnce sync.Once
+	flushed   chan struct{}
+	flushErr  error
+	// urgent is the sync client entries at SyncFlushLevels go through
+	// when logger is async; nil otherwise. Like logger, it is replaced
+	// under mu.
+	urgent poster
+	// writeTimeout and async mirror the fluent.Config the poster was built
+	// from; they bound deadline-aware writes.
+	writeTimeout time.Duration
+	async        bool
+
+	breaker  *circuitBreaker
+	fallback *fallbackSink
+	// buffer holds undeliverable records until delivery succeeds again;
+	// resending guards against concurrent resends.
+	buffer    *offlineBuffer
+	resending atomic.Bool
+
+	// startedAt carries a monotonic reading used for uptime; emitted counts
+	// records accepted by the transport.
+	startedAt time.Time
+	emitted   atomic.Uint64
+	// dropped counts records that were neither delivered nor persisted to
+	// the fallback; fallbacks counts those that went to the fallback;
+	// failures counts failed delivery attempts.
+	dropped   atomic.Uint64
+	fallbacks atomic.Uint64
+	failures  atomic.Uint64
+	// droppedByTag breaks dropped down by tag.
+	droppedByTag tagCounter
+	// writeDurations times every transport write.
+	writeDurations durationHistogram
+	// writes counts successful writes for SyncEveryN; flushes wakes the
+	// worker that flushes every SyncEveryN of them.
+	writes  atomic.Uint64
+	flushes chan struct{}
+	// seq is the last sequence number handed out for IncludeSequence.
+	seq atomic.Uint64
+	// counters maps the names passed to Count to their *atomic.Int64.
+	counters sync.Map
+	// paused is set between Pause and Resume; pauseBuffer holds the records
+	// posted meanwhile under PauseBuffer, and is nil under PauseDrop.
+	paused      atomic.Bool
+	pauseBuffer *offlineBuffer
+	// base holds the fields set by SetBaseFields.
+	base atomic.Pointer[[]zapcore.Field]
+	// queue holds records accepted by post until the queue worker delivers
+	// them, with QueueSize; queueDone is closed once the worker is done.
+	queue     chan queuedRecord
+	queueDone chan struct{}
+	// outage tracks the current delivery outage; outages queues the
+	// reports of ended ones for OutageReport.
+	outage  outage
+	outages chan outageReport
+	// down is set by a failed delivery and cleared by the next successful
+	// one, i.e. once the transport has reconnected.
+	down atomic.Bool
+	self *selfLogger
+
+	// standard is the set of keys written by the encoder config rather
+	// than by callers; they are exempt from MaxFields and OmitEmpty.
+	// standardOrder lists them in the order OrderedFields writes them.
+	standard      map[string]bool
+	standardOrder []string
+	// messageKey is the key MaxMessageBytes applies to.
+	messageKey string
+	// stacktraceKey is the key MaxStacktraceLines applies to.
+	stacktraceKey string
+	// fieldLimitHits counts records trimmed by MaxFields.
+	fieldLimitHits atomic.Uint64
+
+	// recycledAt is when logger was last replaced, in Unix nanoseconds.
+	recycledAt atomic.Int64
+
+	// level is the minimum level of every logger sharing this transport;
+	// discard, sampler and boost implement DiscardLevels, Sampling and
+	// BoostVerbosity.
+	level   zap.AtomicLevel
+	discard discardSet
+	sampler *sampler
+	boost   verbosityBoost
+
+	// encoder is the encoder set by SetEncoder, nil for the built-in
+	// record layout.
+	encoder atomic.Pointer[encoderHolder]
+
+	// producer is the goroutine ID of the first SingleProducer write, in
+	// race-enabled builds.
+	producer atomic.Uint64
+
+	// hooks are the functions registered with OnClose; hooksRan is set
+	// once they ran.
+	hooksMu  sync.Mutex
+	hooks    []func()
+	hooksRan bool
+
+	// lifetime bounds background workers such as the auto-flusher. It is
+	// derived from SugaredLoggerConfig.Context and canceled by Close.
+	lifetime context.Context
+	stop     context.CancelFunc
+	workers  sync.WaitGroup
 }
 
 // SugaredLoggerConfig wraps fluent.Config with additional fields.
 type SugaredLoggerConfig struct {
+	// FluentConfig configures the Fluent client. Besides the networks of
+	// the fluent client (tcp, tls, unix), FluentNetwork may be "unixgram"
+	// for a unix datagram socket at FluentSocketPath: each record is then
+	// sent synchronously as one msgpack message per datagram, whatever
+	// Async says, and MarshalAsJSON and RequestAck are rejected.
 	FluentConfig fluent.Config
 	Tag          string
 	LogLevel     string
+	// Trace names the correlation fields attached by Ctx.
+	Trace TraceFields
+	// ValueTransformer, if set, is called with every field before it is
+	// encoded and may return a replacement value, e.g. to stringify UUIDs
+	// or normalize enums. It sees the value as passed by the caller.
+	ValueTransformer func(key string, val interface{}) interface{}
+	// PreferStringer encodes field values implementing fmt.Stringer,
+	// including those nested in slices and maps, as their String() output
+	// rather than their JSON form, e.g. for enum-like types. Types with
+	// their own JSON or text encoding, such as time.Time, keep it.
+	PreferStringer bool
+	// Fallback receives records that could not be delivered, as JSON
+	// lines carrying tag, time and record. Nil drops them.
+	Fallback io.Writer
+	// LastResort selects what happens to records the Fallback fails to
+	// write. Defaults to LastResortDrop.
+	LastResort LastResortPolicy
+	// BreakerThreshold is the number of consecutive delivery failures
+	// after which writes fast-fail to the fallback without touching the
+	// network. Zero disables the circuit breaker.
+	BreakerThreshold int
+	// BreakerCooldown is how long the circuit stays open before a single
+	// probe write is attempted. Defaults to 30s.
+	BreakerCooldown time.Duration
+	// CloseMarker makes Close emit a final entry with the logger's uptime
+	// (uptime_seconds) and the number of entries delivered (emitted_total).
+	CloseMarker bool
+	// CloseMarkerTag and CloseMarkerLevel override the tag (default: Tag)
+	// and level (default: info) of the close marker.
+	CloseMarkerTag   string
+	CloseMarkerLevel zapcore.Level
+	// EmitStartupBanner makes NewSugaredLogger post an info entry, once
+	// the transport is up, with the process id, hostname, build info and
+	// the effective logger settings, so that it and the CloseMarker
+	// bracket the lifetime of the process. A banner that cannot be
+	// delivered is handled like any other entry and does not fail
+	// NewSugaredLogger.
+	EmitStartupBanner bool
+	// OutageReport makes the logger post two entries once delivery
+	// recovers from an outage: a warn entry, timestamped when the outage
+	// began, with the error that started it, and an info entry with its
+	// duration (outage_seconds) and the number of records buffered,
+	// dropped and diverted to the Fallback meanwhile. The same is always
+	// reported to the self-logger as it happens.
+	OutageReport bool
+	// OutageReportTag overrides the tag of the outage entries (default:
+	// Tag).
+	OutageReportTag string
+	// PreserveUndecodable makes Write post input it cannot decode as
+	// {"raw_log": "<input>", "decode_error": "..."} under the logger's tag
+	// instead of failing, so a malformed line is not lost.
+	PreserveUndecodable bool
+	// UseNumber makes Write keep decoded JSON numbers as json.Number, with
+	// their original text. Otherwise integers decode as int64 (uint64 beyond
+	// its range) and other numbers as float64.
+	UseNumber bool
+	// EntryMarshaler, if set, reshapes every record right before it is
+	// posted. See NestUserFields for a built-in.
+	EntryMarshaler EntryMarshaler
+	// EncoderConfig, if set, replaces the built-in key layout of records,
+	// e.g. with ECSEncoderConfig. If its EncodeCaller is nil, CallerStyle
+	// applies.
+	EncoderConfig *zapcore.EncoderConfig
+	// DuplicateKeyPolicy selects which of several fields of the same key
+	// an entry keeps. Defaults to DuplicateKeepLast.
+	DuplicateKeyPolicy DuplicateKeyPolicy
+	// UppercaseLevel writes the severity as INFO, ERROR, ... instead of
+	// info, error, .... It also applies to an EncoderConfig, but not to an
+	// encoder set with SetEncoder.
+	UppercaseLevel bool
+	// SeparateBody, if set, e.g. to "body", keeps the standard keys
+	// (timestamp, severity, message, ...) at the top level of records and
+	// nests all user fields in an object under this key. It runs after
+	// redaction and field limits and before EntryMarshaler. The fields an
+	// EMF envelope describes move into the body too, where CloudWatch does
+	// not look for them, so the two do not combine.
+	SeparateBody string
+	// SanitizeFields replaces field values the transport cannot encode,
+	// such as channels and funcs, with their string form instead of
+	// failing the whole record, and lists the affected fields under
+	// _unencodable_fields. It matters most for values passed to Event,
+	// which are not converted the way zap fields are.
+	SanitizeFields bool
+	// SingleProducer skips the lock that lets writes run concurrently with
+	// each other and with Close, for services that log from a single
+	// goroutine. The caller guarantees that only one goroutine writes at a
+	// time, and that Close, Sync, Flush, Reconnect, Reconfigure and
+	// ReplayBuffered are called from that goroutine or once it no longer
+	// writes; breaking the contract is a data race. In builds with -race,
+	// a write from a second goroutine panics. It cannot be combined with
+	// options that replace the client or post records from a goroutine of
+	// their own: MaxConnLifetime, a unix socket transport,
+	// CounterFlushInterval, MemStatsInterval, OutageReport,
+	// EmitStartupBanner, CloseMarker and, with an async FluentConfig,
+	// AutoFlushInterval.
+	SingleProducer bool
+	// SyncFlushLevels lists levels whose entries are delivered
+	// synchronously, and the logger flushed (see Flush), before the
+	// logging call returns, e.g. so an error logged right before a crash
+	// is not lost in a buffer. With an async FluentConfig, such entries go
+	// through a separate sync connection, so they may arrive ahead of
+	// async entries logged before them; they also bypass QueueSize.
+	// Reconnect and MaxConnLifetime do not replace that connection;
+	// Reconfigure does.
+	SyncFlushLevels []zapcore.Level
+	// MaxStacktraceLines, if positive, cuts the stacktrace field, whether
+	// written by zap or passed by the caller, to its first lines and marks
+	// the cut with a "...(truncated)" line. Each frame of a zap stacktrace
+	// takes two lines.
+	MaxStacktraceLines int
+	// WriteRetries is how many times a sync write that failed with a
+	// transient network error (reset or refused connection, timeout) is
+	// retried, with a backoff starting at 50ms, before the record is held
+	// or diverted like any failed one. Writes bounded by a context
+	// deadline are not retried.
+	WriteRetries int
+	// InternalDebug reports the logger's own lifecycle events (connect,
+	// reconnect, delivery failures, flush, close) on stderr.
+	InternalDebug bool
+	// RedactPatterns masks every match in every string value of a record,
+	// including the message and strings nested in objects and arrays, as
+	// [REDACTED]. See RedactEmails and RedactCreditCards. The trace and
+	// correlation ID fields attached by Ctx are never redacted. Each
+	// pattern is run over every string of every entry, so the cost grows
+	// with both; leave it empty unless needed.
+	RedactPatterns []*regexp.Regexp
+	// MaxMessageBytes, if positive, truncates longer messages to that many
+	// bytes, ending in "…", and marks the entry message_truncated: true.
+	// Other fields are left intact.
+	MaxMessageBytes int
+	// OmitEmpty drops fields whose value is nil or an empty string, slice
+	// or map before the record is posted. Zero values such as 0 and false
+	// are kept, as are the standard keys.
+	OmitEmpty bool
+	// MaxFields caps the number of non-standard fields per record. Extra
+	// fields (in key order) are replaced by a single __dropped_fields
+	// count. Zero means unlimited.
+	MaxFields int
+	// Context bounds the lifetime of the logger's background goroutines;
+	// they stop when it is canceled or when the logger is closed.
+	// Defaults to context.Background().
+	Context context.Context
+	// AutoFlushInterval, if positive, calls Flush periodically so that a
+	// crash loses at most one interval of buffered records.
+	AutoFlushInterval time.Duration
+	// TimestampKey is the field Write reads an entry's time from, for
+	// encoders that rename it. Defaults to "timestamp".
+	TimestampKey string
+	// RequireTimestamp makes Write reject entries without a readable
+	// TimestampKey field with ErrMissingTimestamp instead of posting them
+	// at the current time, which can hide a misconfigured encoder.
+	RequireTimestamp bool
+	// DualTimestamp adds a second timestamp in the other format next to
+	// the primary one, so records are readable in raw files and need no
+	// parse filter downstream: Unix seconds (e.g. 1700000000.123456) when
+	// the primary is a string, as it is by default, an RFC 3339 string
+	// otherwise. DualTimestampKey names it and defaults to "ts"; the
+	// primary is named by the TimeKey of EncoderConfig, "timestamp" by
+	// default.
+	DualTimestamp    bool
+	DualTimestampKey string
+	// SyncEveryN, if positive, calls Flush after every N successful writes,
+	// bounding by count rather than time what a crash can lose from the
+	// offline buffer and the fallback sink. Smaller values lose less but
+	// flush (fsync a file Fallback) more often. The flush runs on a
+	// background goroutine, so the Nth write does not wait for it; writes
+	// arriving while a flush is pending are covered by it. It cannot be
+	// combined with an async FluentConfig: Flush can only drain the
+	// client's queue by reconnecting, which every N writes would amount
+	// to a new connection.
+	SyncEveryN int
+	// Dialect adapts the connection settings and record shape to the
+	// receiver. Defaults to DialectFluentd.
+	Dialect Dialect
+	// OfflineBuffer, if positive, is the number of undeliverable records
+	// held in memory and resent, in order, after the next successful
+	// delivery or Flush. Records evicted from a full buffer, and those
+	// still buffered on Close, go to the Fallback.
+	OfflineBuffer int
+	// MaxConnLifetime, if positive, replaces the Fluent client (see
+	// Reconnect) once per lifetime, so connections to a load-balanced
+	// endpoint are redistributed across backends.
+	//
+	// There is no KeepAlive option: the Fluent client dials with its own
+	// net.Dialer, which it does not expose, so connections always use Go's
+	// default TCP keepalive (probes after 15s idle). Behind a firewall that
+	// drops idle connections sooner, set MaxConnLifetime below its idle
+	// timeout so the connection is replaced before it can be dropped.
+	MaxConnLifetime time.Duration
+	// OrderedFields makes records go out with their fields in a stable
+	// order: the standard keys first, in a fixed sequence, then the other
+	// fields sorted by key. Without it the order follows Go map iteration
+	// and varies between entries.
+	OrderedFields bool
+	// TagBufferLimits caps, per tag, the number of records the offline
+	// buffer holds, so a burst on one tag evicts that tag's oldest records
+	// instead of starving the others. Tags not listed are only bounded by
+	// OfflineBuffer. It has no effect unless OfflineBuffer is set.
+	TagBufferLimits map[string]int
+	// MaxQueueBytes, if positive, caps the approximate encoded size of the
+	// records held by the offline buffer, independently of the count
+	// limits, so a few huge records cannot exhaust memory. The oldest
+	// records are evicted to the Fallback until the rest fit. See
+	// QueueBytes. It has no effect unless OfflineBuffer is set.
+	MaxQueueBytes int64
+	// ConnectOnStart makes NewSugaredLogger check that Fluentd is
+	// reachable (see Ping) and fail if it is not. By default the
+	// connection is only made when needed.
+	ConnectOnStart bool
+	// ConnectRetries is how many times ConnectOnStart retries a failed
+	// check before NewSugaredLogger gives up, for Fluentd starting
+	// alongside the application. The first retry waits
+	// ConnectRetryInterval (default 1s); each further one waits twice as
+	// long, up to a minute. Retries stop early when Context is canceled.
+	ConnectRetries       int
+	ConnectRetryInterval time.Duration
+	// DiscardLevels lists levels whose entries are dropped before they are
+	// encoded, whatever the logger's level. Unlike LogLevel it can punch a
+	// hole, e.g. discard info while keeping debug and warn.
+	DiscardLevels []zapcore.Level
+	// Sampling, if set, drops part of the entries that repeat the same
+	// level and message. BoostVerbosity suspends it temporarily.
+	Sampling *SamplingConfig
+	// SampleKeyFields makes Sampling count entries per combination of the
+	// values of these fields, e.g. []string{"endpoint"}, instead of per
+	// message, so one hot endpoint cannot use up the allowance of quieter
+	// ones. Entries carrying none of the fields are counted per message.
+	// Since the fields are only known once an entry is written, entries
+	// are then sampled after zap has built them rather than before.
+	SampleKeyFields []string
+	// EMF, if set, adds a CloudWatch Embedded Metric Format envelope to
+	// records carrying any of its metric fields; see EMFConfig.
+	EMF *EMFConfig
+	// AddCaller records the file and line of the logging call in the
+	// caller field, formatted according to CallerStyle. Records from the
+	// slog handler carry their caller regardless.
+	AddCaller bool
+	// CallerStyle selects the format of the caller field. Defaults to
+	// CallerShort.
+	CallerStyle CallerStyle
+	// IncludeFuncName adds the package-qualified name of the function that
+	// made the logging call, e.g. main.(*Server).handle, under "func", or
+	// under the FunctionKey of EncoderConfig if it sets one. It implies
+	// AddCaller, and costs a stack lookup per entry.
+	IncludeFuncName bool
+	// Development makes DPanic entries panic after they are written, as
+	// zap.Development does. The transport is flushed and closed first, so
+	// the entry ships before the crash.
+	Development bool
+	// SeverityNumberKey, if set, adds a numeric severity under this key
+	// next to the severity string, numbered by SeverityScheme (default
+	// SeverityOTel).
+	SeverityNumberKey string
+	SeverityScheme    SeverityScheme
+	// LevelSchedule sets the level by wall-clock time in
+	// LevelScheduleLocation (default: local time), e.g. to log less
+	// overnight. Outside every window the level is LogLevel. Windows are
+	// validated by NewSugaredLogger and may not overlap.
+	LevelSchedule         []LevelWindow
+	LevelScheduleLocation *time.Location
+	// IncludeGoroutineID adds the ID of the logging goroutine to every
+	// entry as goroutine_id, for debugging concurrency issues.
+	//
+	// WARNING: Go deliberately does not expose goroutine IDs. The ID is
+	// parsed from a runtime.Stack capture on every entry, which is slow,
+	// and relies on an unspecified format that may change between Go
+	// releases. Do not enable it in production.
+	IncludeGoroutineID bool
+	// ShutdownContext, if set, aborts a Close in progress when it is
+	// canceled, e.g. by a second SIGTERM while the flush is stuck. See
+	// CloseContext.
+	ShutdownContext context.Context
+	// EventTypeKey and Serializers register fast paths for hot event
+	// types; see Serializer.
+	EventTypeKey string
+	Serializers  map[string]Serializer
+	// ConnectionPoolSize, if greater than one, opens that many connections
+	// and spreads writes over them round-robin, skipping connections whose
+	// last write failed. See ConnHealth.
+	ConnectionPoolSize int
+	// Shards, if set, replaces the single Fluentd endpoint of FluentConfig
+	// with several clusters: each record goes to the shard picked by a
+	// consistent hash of its ShardKeyField value, so a tenant's records
+	// always land on the same cluster. Records without the field go to
+	// the first shard. The key is looked up among the top-level fields of
+	// the record as posted, i.e. after EntryMarshaler. FluentConfig still
+	// provides the logger's own Timeout, WriteTimeout and Async settings,
+	// and the Timeout of shards that set none. With ConnectionPoolSize,
+	// each shard gets a pool of its own. See ShardHealth.
+	Shards        []fluent.Config
+	ShardKeyField string
+	// MessagePrefix is prepended to the message of every entry, e.g.
+	// "[billing] ", for deployments that cannot separate services by tag.
+	// Derived and named loggers share it; it is applied exactly once.
+	MessagePrefix string
+	// IncludeTagField, if set, adds a field with this key to every record
+	// holding the tag the record is posted under, after WithTag and
+	// WithComponent have applied. It does not count towards MaxFields.
+	IncludeTagField string
+	// IncludeSequence adds a sequence number, under SequenceKey, to every
+	// record, counting 1, 2, 3... as records are posted, so
+	// consumers can spot a gap where records were dropped. Numbering is
+	// per process and per root logger (derived loggers share it), and
+	// restarts at 1 when the process does. Entries dropped by sampling or
+	// the level are never numbered; records built by a Serializer are not
+	// numbered either.
+	IncludeSequence bool
+	// SequenceKey is the key of the IncludeSequence field. Defaults to
+	// "seq".
+	SequenceKey string
+	// QueueSize, if positive, puts a bounded queue of this many records in
+	// front of the transport: logging calls only enqueue, and a background
+	// goroutine delivers the records in order. When the queue is full, the
+	// record is dropped and passed to OnOverflow. Close and Sync deliver
+	// what is queued before closing the transport. Messages built by a
+	// Serializer bypass the queue.
+	QueueSize int
+	// OnOverflow, if set, is called with every record dropped because the
+	// queue is full, e.g. to write it elsewhere or count it. It runs on the
+	// logging goroutine, so it must be fast, must not block, and must not
+	// log through this logger.
+	OnOverflow func(entry map[string]interface{})
+	// AutoConsole additionally prints info and above as colored console
+	// output on stdout when stdout is a terminal, so the same binary is
+	// readable when run locally. When stdout is not a terminal (piped, or
+	// under a process manager) it has no effect.
+	AutoConsole bool
+	// SlowWriteThreshold, if positive, reports transport writes taking
+	// longer than this to the self-logger (see InternalDebug). Write
+	// durations are also recorded in a histogram; see WriteMetrics.
+	SlowWriteThreshold time.Duration
+	// IncludeBuildInfo adds go_version, vcs.revision and vcs.time from the
+	// binary's build info to every entry. The vcs fields are only present
+	// when the binary was built with VCS stamping (not under go run).
+	IncludeBuildInfo bool
+	// CounterFlushInterval, if positive, enables Count: every interval, the
+	// counters accumulated by Count are posted as one info entry each,
+	// with the counter and count fields, and reset.
+	CounterFlushInterval time.Duration
+	// SchemaVersion, if set, is added to every entry as schema_version, so
+	// consumers can tell the versions of the record layout apart while it
+	// evolves. It is also on the close marker and on the lines of the
+	// self-logger.
+	SchemaVersion string
+	// SchemaValidator, if set, checks every record as posted against the
+	// schema it implements; records it rejects are dropped with an error
+	// wrapping ErrSchemaViolation. Its Version must equal SchemaVersion,
+	// or NewSugaredLogger fails, so records never claim a version they
+	// were not checked against.
+	SchemaValidator SchemaValidator
+	// MemStatsInterval, if positive, posts a debug entry with heap, GC and
+	// goroutine figures from runtime.MemStats every interval, under the
+	// logger's tag with ".runtime" appended.
+	MemStatsInterval time.Duration
+	// ChecksumField, if set, adds the SHA-256 of every record under this
+	// key, so that tampering with archived records can be detected with
+	// VerifyChecksum. The checksum covers the record as posted, minus
+	// the checksum itself, in a canonical form with sorted keys and every
+	// number as a float64, so that it does not depend on how a later
+	// stage renders numbers. It proves the integrity of each record at
+	// rest only: it is not a signature, anyone able to modify a record can
+	// recompute it, and it neither secures the transport nor detects
+	// removed records (see IncludeSequence). A record JSON cannot encode,
+	// e.g. one holding a NaN, is posted without a checksum and reported to
+	// the self-logger.
+	ChecksumField string
+	// PausePolicy selects what happens to entries logged while the logger
+	// is paused; see Pause. Defaults to PauseDrop. PauseBufferSize caps the
+	// records held under PauseBuffer and defaults to 10000.
+	PausePolicy     PausePolicy
+	PauseBufferSize int
+	// MaxKeyLength, if positive, limits field keys, including those of
+	// nested objects and of objects in arrays, to this many bytes for
+	// backends that reject or mangle long keys; KeyOverflowPolicy selects
+	// how longer keys are handled. The standard keys are exempt. Zero
+	// means unlimited. A limit too short to hold a hash or collision
+	// suffix and part of the key, below 12, is rejected.
+	MaxKeyLength      int
+	KeyOverflowPolicy KeyOverflowPolicy
+}
+
+// Logger is the subset of SugaredLogger that application code typically
+// uses, so consumers can depend on it and substitute a fake in tests. Its
+// derivation methods return Logger too, so that a fake never has to build a
+// real logger and derived loggers stay behind the interface. Get one from a
+// *SugaredLogger with AsLogger; use the concrete type for everything else.
+type Logger interface {
+	Debugw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+	With(args ...interface{}) Logger
+	WithTag(tag string) Logger
+	Ctx(ctx context.Context) Logger
+	Close() error
+}
+
+// AsLogger returns l as a Logger. Closing it closes l; closing a Logger
+// derived from it is a no-op, as for any derived logger.
+func AsLogger(l *SugaredLogger) Logger {
+	return loggerAdapter{l}
+}
+
+// loggerAdapter adapts the derivation methods of SugaredLogger, which keep
+// their concrete return types, to Logger.
+type loggerAdapter struct {
+	*SugaredLogger
+}
+
+func (a loggerAdapter) With(args ...interface{}) Logger {
+	return loggerAdapter{a.derive(a.SugaredLogger.With(args...))}
+}
+
+func (a loggerAdapter) WithTag(tag string) Logger {
+	return loggerAdapter{a.SugaredLogger.WithTag(tag)}
+}
+
+func (a loggerAdapter) Ctx(ctx context.Context) Logger {
+	return loggerAdapter{a.SugaredLogger.Ctx(ctx)}
 }
 
 // SugaredLogger wraps zap.SugaredLogger with ownership of resources.
 type SugaredLogger struct {
 	*zap.SugaredLogger
-	fluent    *FluentLogger
+	fluent *FluentLogger
+	core   *fluentCore
+	cfg    *SugaredLoggerConfig
+	// level is the logger's own level, for loggers handed out by a
+	// LoggerRegistry; nil if it uses the level of its root.
+	level     *zap.AtomicLevel
+	derived   bool
 	closeOnce sync.Once
 }
 
@@ -56,47 +651,300 @@ func NewSugaredLogger(cfg *SugaredLoggerConfig) (*SugaredLogger, error) {
 	if cfg.Tag == "" {
 		cfg.Tag = defaultFluentTag
 	}
+	if err := ValidateTag(cfg.Tag); err != nil {
+		return nil, fmt.Errorf("invalid tag: %w", err)
+	}
+	if cfg.CloseMarkerTag != "" {
+		if err := ValidateTag(cfg.CloseMarkerTag); err != nil {
+			return nil, fmt.Errorf("invalid close marker tag: %w", err)
+		}
+	}
+	if cfg.OutageReportTag != "" {
+		if err := ValidateTag(cfg.OutageReportTag); err != nil {
+			return nil, fmt.Errorf("invalid outage report tag: %w", err)
+		}
+	}
+	if cfg.SingleProducer && (cfg.MaxConnLifetime > 0 || isUnixSocket(cfg.FluentConfig.FluentNetwork)) {
+		return nil, errors.New("SingleProducer cannot be combined with MaxConnLifetime or a unix socket transport")
+	}
+	if cfg.SyncEveryN > 0 && cfg.FluentConfig.Async {
+		return nil, errors.New("SyncEveryN cannot be combined with an async transport")
+	}
+	if cfg.SingleProducer && cfg.FluentConfig.Async && cfg.AutoFlushInterval > 0 {
+		return nil, errors.New("SingleProducer cannot be combined with AutoFlushInterval on an async transport")
+	}
+	if cfg.SingleProducer && (cfg.CounterFlushInterval > 0 || cfg.MemStatsInterval > 0 || cfg.OutageReport) {
+		return nil, errors.New("SingleProducer cannot be combined with CounterFlushInterval, MemStatsInterval or OutageReport")
+	}
+	if cfg.SingleProducer && (cfg.EmitStartupBanner || cfg.CloseMarker) {
+		return nil, errors.New("SingleProducer cannot be combined with EmitStartupBanner or CloseMarker")
+	}
+	if cfg.FluentConfig.FluentNetwork == datagramNetwork {
+		if err := validateDatagram(cfg.FluentConfig); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.MaxKeyLength > 0 && cfg.MaxKeyLength < minKeyLength {
+		return nil, fmt.Errorf("MaxKeyLength %d is below the minimum of %d", cfg.MaxKeyLength, minKeyLength)
+	}
+	if v := cfg.SchemaValidator; v != nil && v.Version() != cfg.SchemaVersion {
+		return nil, fmt.Errorf("SchemaValidator checks schema version %q, but SchemaVersion is %q", v.Version(), cfg.SchemaVersion)
+	}
 	if cfg.FluentConfig.Timeout == 0 {
 		cfg.FluentConfig.Timeout = defaultShutdownTimeout
 	}
+	cfg.Trace = cfg.Trace.withDefaults()
+	cfg.Dialect.apply(&cfg.FluentConfig, newSelfLogger(cfg))
+	shards := shardConfigs(cfg)
+
+	var schedule *levelSchedule
+	if len(cfg.LevelSchedule) > 0 {
+		var err error
+		schedule, err = newLevelSchedule(cfg.LevelSchedule, parseLogLevel(cfg.LogLevel), cfg.LevelScheduleLocation)
+		if err != nil {
+			return nil, fmt.Errorf("invalid level schedule: %w", err)
+		}
+	}
+
+	if cfg.ConnectOnStart {
+		targets := shards
+		if len(targets) == 0 {
+			targets = []fluent.Config{cfg.FluentConfig}
+		}
+		ctx := cfg.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		self := newSelfLogger(cfg)
+		for _, target := range targets {
+			if err := connectOnStart(ctx, cfg, target, self); err != nil {
+				return nil, fmt.Errorf("connect on start failed: %w", err)
+			}
+		}
+	}
 
-	fl, err := fluent.New(cfg.FluentConfig)
+	dial := newDialer(cfg, shards, cfg.FluentConfig.Async)
+	fl, err := dial()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create fluent logger: %w", err)
 	}
 
+	var urgent poster
+	if len(cfg.SyncFlushLevels) > 0 && cfg.FluentConfig.Async {
+		if urgent, err = newDialer(cfg, shards, false)(); err != nil {
+			fl.Close()
+			return nil, fmt.Errorf("failed to create sync fluent logger: %w", err)
+		}
+	}
+
+	l := newSugaredLogger(cfg, fl)
+	l.fluent.dial = dial
+	l.fluent.urgent = urgent
+	if cfg.MaxConnLifetime > 0 {
+		l.fluent.goWorker(func(ctx context.Context) {
+			l.fluent.recycleEvery(ctx, cfg.MaxConnLifetime)
+		})
+	}
+	if schedule != nil {
+		l.fluent.goWorker(func(ctx context.Context) {
+			l.followSchedule(ctx, schedule)
+		})
+	}
+	if isUnixSocket(cfg.FluentConfig.FluentNetwork) {
+		l.fluent.redial = make(chan struct{}, 1)
+		l.fluent.goWorker(l.fluent.redialUnix)
+	}
+	if cfg.EmitStartupBanner {
+		if err := l.emitStartupBanner(); err != nil {
+			l.fluent.self.warn("startup banner failed", "error", err)
+		}
+	}
+	return l, nil
+}
+
+// newFluentClient builds a fluent client; tests replace it.
+var newFluentClient = func(c fluent.Config) (poster, error) {
+	return fluent.New(c)
+}
+
+// newDialer returns the function building the transport for cfg: a fluent
+// client for FluentConfig, or one per shard, pooled with
+// ConnectionPoolSize. async overrides the Async setting of the clients.
+func newDialer(cfg *SugaredLoggerConfig, shards []fluent.Config, async bool) func() (poster, error) {
+	size := cfg.ConnectionPoolSize
+	dialConfig := func(c fluent.Config) (poster, error) {
+		if c.FluentNetwork == datagramNetwork {
+			return newDatagramPoster(c)
+		}
+		c.Async = async
+		if size > 1 {
+			return newPosterPool(size, func() (poster, error) {
+				return newFluentClient(c)
+			})
+		}
+		return newFluentClient(c)
+	}
+	if len(shards) > 0 {
+		key := cfg.ShardKeyField
+		return func() (poster, error) {
+			return newShardedPoster(key, shards, dialConfig)
+		}
+	}
+	fluentConfig := cfg.FluentConfig
+	return func() (poster, error) {
+		return dialConfig(fluentConfig)
+	}
+}
+
+// newSugaredLogger assembles a logger around an already constructed poster.
+// Records are built by fluentCore, so entries reach the transport without
+// being serialized to JSON first.
+func newSugaredLogger(cfg *SugaredLoggerConfig, p poster) *SugaredLogger {
+	conf := *cfg
 	fluentLogger := &FluentLogger{
-		logger:  fl,
-		tag:     cfg.Tag,
-		timeout: cfg.FluentConfig.Timeout,
+		logger:   p,
+		cfg:      &conf,
+		tag:      cfg.Tag,
+		timeout:  cfg.FluentConfig.Timeout,
+		endpoint: cfg.FluentConfig,
+
+		writeTimeout: cfg.FluentConfig.WriteTimeout,
+		async:        cfg.FluentConfig.Async,
+
+		breaker:  newCircuitBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+		fallback: newFallbackSink(cfg.Fallback),
+		buffer:   newOfflineBuffer(cfg.OfflineBuffer, cfg.TagBufferLimits, cfg.MaxQueueBytes),
+
+		pauseBuffer: newPauseBuffer(cfg),
+
+		startedAt: time.Now(),
+		self:      newSelfLogger(cfg),
+	}
+	parent := cfg.Context
+	if parent == nil {
+		parent = context.Background()
 	}
+	fluentLogger.lifetime, fluentLogger.stop = context.WithCancel(parent)
+	fluentLogger.self.info("transport created",
+		"network", cfg.FluentConfig.FluentNetwork,
+		"address", fluentAddress(cfg.FluentConfig),
+		"async", cfg.FluentConfig.Async,
+		"tag", cfg.Tag,
+	)
 
 	// Configure structured logging pipeline
-	encoder := zapcore.NewJSONEncoder(zapcore.EncoderConfig{
-		TimeKey:        "timestamp",
-		LevelKey:       "severity",
-		NameKey:        "logger",
-		CallerKey:      "caller",
-		MessageKey:     "message",
-		StacktraceKey:  "stacktrace",
-		LineEnding:     zapcore.DefaultLineEnding,
-		EncodeLevel:    zapcore.LowercaseLevelEncoder,
-		EncodeTime:     zapcore.RFC3339NanoTimeEncoder,
-		EncodeDuration: zapcore.StringDurationEncoder,
-		EncodeCaller:   zapcore.ShortCallerEncoder,
-	})
+	encoderConfig := newEncoderConfig()
+	if cfg.EncoderConfig != nil {
+		encoderConfig = *cfg.EncoderConfig
+	}
+	if cfg.EncoderConfig == nil || encoderConfig.EncodeCaller == nil {
+		encoderConfig.EncodeCaller = cfg.CallerStyle.encoder()
+	}
+	if cfg.UppercaseLevel {
+		encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+	}
+	if cfg.IncludeFuncName && encoderConfig.FunctionKey == "" {
+		encoderConfig.FunctionKey = defaultFuncNameKey
+	}
+	fluentLogger.standardOrder = encoderKeys(encoderConfig)
+	if cfg.DualTimestamp {
+		key := cfg.DualTimestampKey
+		if key == "" {
+			key = defaultDualTimestampKey
+		}
+		fluentLogger.standardOrder = append(fluentLogger.standardOrder, key)
+	}
+	fluentLogger.standard = keySet(fluentLogger.standardOrder)
+	fluentLogger.messageKey = encoderConfig.MessageKey
+	fluentLogger.stacktraceKey = encoderConfig.StacktraceKey
+	if fluentLogger.stacktraceKey == "" {
+		fluentLogger.stacktraceKey = newEncoderConfig().StacktraceKey
+	}
 
-	lvl := parseLogLevel(cfg.LogLevel)
-	core := zapcore.NewCore(
-		encoder,
-		zapcore.AddSync(fluentLogger),
-		lvl,
-	)
+	fluentLogger.level = zap.NewAtomicLevelAt(parseLogLevel(cfg.LogLevel))
+	fluentLogger.sampler = newSampler(cfg.Sampling, cfg.SampleKeyFields)
+	fluentLogger.discard = newDiscardSet(cfg.DiscardLevels)
+	core := newFluentCore(fluentLogger, &conf, &encoderConfig, fluentLogger.level)
+	if cfg.IncludeBuildInfo {
+		core.fields = buildInfoFields()
+	}
+	if cfg.SchemaVersion != "" {
+		core.fields = append(core.fields, zap.String(schemaVersionKey, cfg.SchemaVersion))
+	}
 
-	return &SugaredLogger{
-		SugaredLogger: zap.New(core).Sugar(),
+	var zcore zapcore.Core = core
+	if cfg.AutoConsole && isTerminal(os.Stdout) {
+		zcore = &teeCore{fluent: core, console: newConsoleCore(fluentLogger.level)}
+	}
+
+	var opts []zap.Option
+	if cfg.AddCaller || cfg.IncludeFuncName {
+		opts = append(opts, zap.AddCaller())
+	}
+	if cfg.Development {
+		opts = append(opts, zap.Development())
+	}
+
+	l := &SugaredLogger{
+		SugaredLogger: zap.New(zcore, opts...).Sugar(),
 		fluent:        fluentLogger,
-	}, nil
+		core:          core,
+		cfg:           &conf,
+	}
+	if cfg.QueueSize > 0 {
+		fluentLogger.startQueue(cfg.QueueSize)
+	}
+	if cfg.OutageReport {
+		fluentLogger.outages = make(chan outageReport, maxPendingOutageReports)
+		fluentLogger.goWorker(l.reportOutages)
+	}
+	if cfg.SyncEveryN > 0 {
+		fluentLogger.flushes = make(chan struct{}, 1)
+		fluentLogger.goWorker(fluentLogger.flushOnSignal)
+	}
+	if cfg.AutoFlushInterval > 0 {
+		fluentLogger.goWorker(func(ctx context.Context) {
+			l.autoFlush(ctx, cfg.AutoFlushInterval)
+		})
+	}
+	if cfg.CounterFlushInterval > 0 {
+		fluentLogger.goWorker(func(ctx context.Context) {
+			l.flushCountersEvery(ctx, cfg.CounterFlushInterval)
+		})
+	}
+	if cfg.MemStatsInterval > 0 {
+		fluentLogger.goWorker(func(ctx context.Context) {
+			l.reportMemStats(ctx, cfg.MemStatsInterval)
+		})
+	}
+	return l
+}
+
+// derive wraps s as a child of l that shares, but does not own, the
+// underlying Fluent transport.
+func (l *SugaredLogger) derive(s *zap.SugaredLogger) *SugaredLogger {
+	return &SugaredLogger{
+		SugaredLogger: s,
+		fluent:        l.fluent,
+		core:          l.core,
+		cfg:           l.cfg,
+		level:         l.level,
+		derived:       true,
+	}
+}
+
+// withCore returns the underlying zap logger with its fluentCore replaced
+// by fn(core).
+func (l *SugaredLogger) withCore(fn func(*fluentCore) *fluentCore) *zap.SugaredLogger {
+	return l.SugaredLogger.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		switch c := c.(type) {
+		case *fluentCore:
+			return fn(c)
+		case *teeCore:
+			return &teeCore{fluent: fn(c.fluent), console: c.console}
+		}
+		return c
+	}))
 }
 
 // Write implements zapcore.WriteSyncer with proper error handling and JSON parsing
@@ -106,69 +954,465 @@ func (f *FluentLogger) Write(p []byte) (int, error) {
 	}
 
 	// Decode Zap's formatted JSON
-	var entry map[string]interface{}
-	if err := json.Unmarshal(p, &entry); err != nil {
-		return 0, fmt.Errorf("log decode failed: %w", err)
+	entry, err := f.decode(p)
+	if err != nil {
+		return 0, err
+	}
+	t, err := f.entryTime(entry)
+	if err != nil {
+		f.drop(f.tag)
+		return 0, err
 	}
 
-	// Async PostWithTime handles its own synchronization
-	if err := f.logger.PostWithTime(f.tag, time.Now(), entry); err != nil {
-		return 0, fmt.Errorf("log delivery failed: %w", err)
+	if err := f.post(context.Background(), f.tag, t, entry); err != nil {
+		return 0, err
 	}
 
 	return len(p), nil
 }
 
-// Sync implements proper resource cleanup with timeout
-func (f *FluentLogger) Sync() error {
-	if f.closed.Swap(true) {
+// decode parses one JSON-encoded entry into a record. With
+// PreserveUndecodable, input that fails to decode becomes a record
+// carrying the raw input and the decode error instead of failing.
+func (f *FluentLogger) decode(p []byte) (map[string]interface{}, error) {
+	var entry map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	if err := dec.Decode(&entry); err != nil {
+		if f.cfg.PreserveUndecodable {
+			return map[string]interface{}{
+				rawLogKey:      string(bytes.TrimRight(p, "\n")),
+				decodeErrorKey: err.Error(),
+			}, nil
+		}
+		return nil, fmt.Errorf("log decode failed: %w", err)
+	}
+	if !f.cfg.UseNumber {
+		plainNumbers(entry)
+	}
+	return entry, nil
+}
+
+// entryTime returns the time of a decoded entry, read from its
+// TimestampKey field as an RFC 3339 string or integer Unix nanoseconds. If
+// the field is missing or unreadable it returns the current time, or
+// ErrMissingTimestamp with RequireTimestamp.
+func (f *FluentLogger) entryTime(entry map[string]interface{}) (time.Time, error) {
+	key := f.cfg.TimestampKey
+	if key == "" {
+		key = defaultTimestampKey
+	}
+	switch v := entry[key].(type) {
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t, nil
+		}
+	case int64:
+		return time.Unix(0, v), nil
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return time.Unix(0, n), nil
+		}
+	}
+	if f.cfg.RequireTimestamp {
+		return time.Time{}, fmt.Errorf("%w: no readable %q field", ErrMissingTimestamp, key)
+	}
+	return time.Now(), nil
+}
+
+// post delivers a decoded record. It is shared by Write and fluentCore.
+// Records that cannot be delivered, or are skipped by an open circuit, are
+// held by the offline buffer or go to the fallback sink; the write only
+// fails if neither takes them.
+func (f *FluentLogger) post(ctx context.Context, tag string, t time.Time, entry map[string]interface{}) error {
+	return f.accept(ctx, tag, t, entry, false)
+}
+
+// postSync is post for entries at SyncFlushLevels: the record bypasses the
+// queue and goes out through a sync client, even if the logger is async.
+func (f *FluentLogger) postSync(ctx context.Context, tag string, t time.Time, entry map[string]interface{}) error {
+	return f.accept(ctx, tag, t, entry, true)
+}
+
+// accept is post and postSync; urgent selects the latter.
+func (f *FluentLogger) accept(ctx context.Context, tag string, t time.Time, entry map[string]interface{}, urgent bool) error {
+	f.lockWrite()
+	defer f.unlockWrite()
+
+	if f.closed.Load() {
+		f.drop(tag)
+		return ErrLoggerClosed
+	}
+
+	if key := f.cfg.IncludeTagField; key != "" {
+		entry[key] = tag
+	}
+	if f.cfg.IncludeSequence {
+		entry[f.sequenceKey()] = f.seq.Add(1)
+	}
+	if f.paused.Load() {
+		f.holdPaused(tag, t, entry)
+		return nil
+	}
+	if f.queue != nil && !urgent {
+		f.enqueue(queuedRecord{tag: tag, time: t, record: entry})
 		return nil
 	}
+	return f.deliver(ctx, tag, t, entry, urgent)
+}
+
+// syncFlushes reports whether entries at lvl are in SyncFlushLevels.
+func (f *FluentLogger) syncFlushes(lvl zapcore.Level) bool {
+	for _, l := range f.cfg.SyncFlushLevels {
+		if l == lvl {
+			return true
+		}
+	}
+	return false
+}
+
+// transport returns the client a delivery goes through and whether it is
+// async: the sync client for urgent deliveries, if there is one.
+func (f *FluentLogger) transport(urgent bool) (poster, bool) {
+	if urgent && f.urgent != nil {
+		return f.urgent, false
+	}
+	return f.logger, f.async
+}
 
-	// Flush remaining logs with timeout
-	done := make(chan struct{})
+// sequenceKey returns the key IncludeSequence numbers records under.
+func (f *FluentLogger) sequenceKey() string {
+	if key := f.cfg.SequenceKey; key != "" {
+		return key
+	}
+	return defaultSequenceKey
+}
+
+// deliver is the part of post after the record is accepted: it prepares
+// the record and sends it, through the sync client if urgent, or holds it
+// if it cannot be sent. Callers must hold f.mu for reading.
+func (f *FluentLogger) deliver(ctx context.Context, tag string, t time.Time, entry map[string]interface{}, urgent bool) error {
+	entry, err := f.prepare(entry, t)
+	if err != nil {
+		f.drop(tag)
+		return err
+	}
+
+	if !f.breaker.allow() {
+		if !f.hold(tag, t, entry) {
+			return ErrCircuitOpen
+		}
+		return nil
+	}
+
+	p, async := f.transport(urgent)
+	if deadline, ok := ctx.Deadline(); ok && !async {
+		err = f.postBefore(p, deadline, tag, t, f.message(entry))
+	} else {
+		// Async PostWithTime handles its own synchronization
+		err = f.sendRetrying(p, async, tag, t, entry)
+	}
+	f.breaker.record(err == nil)
+	if errors.Is(err, ErrWriteAbandoned) {
+		// The post is still in flight, so it must not be duplicated into
+		// the fallback.
+		f.drop(tag)
+		return err
+	}
+	f.observe(tag, err)
+	if err != nil {
+		if f.hold(tag, t, entry) {
+			return nil
+		}
+		return fmt.Errorf("log delivery failed: %w", err)
+	}
+	f.resend()
+	return nil
+}
+
+// send hands entry to p. A panic in p, such as one raised by its encoder on
+// a malformed value, is returned as ErrPostPanicked so that one bad record
+// cannot take the process down; the record then takes the failure path.
+func (f *FluentLogger) send(p poster, tag string, t time.Time, entry map[string]interface{}) error {
+	return f.sendMessage(p, tag, t, f.message(entry))
+}
+
+func (f *FluentLogger) sendMessage(p poster, tag string, t time.Time, msg interface{}) (err error) {
+	start := time.Now()
+	defer func() {
+		d := time.Since(start)
+		f.writeDurations.observe(d)
+		if threshold := f.cfg.SlowWriteThreshold; threshold > 0 && d > threshold {
+			f.self.warn("slow write", "tag", tag, "duration", d, "threshold", threshold)
+		}
+		if r := recover(); r != nil {
+			f.self.warn("delivery panicked", "tag", tag, "panic", r, "stack", string(debug.Stack()))
+			err = fmt.Errorf("%w: %v", ErrPostPanicked, r)
+		}
+	}()
+	return p.PostWithTime(tag, t, msg)
+}
+
+// divert hands an undeliverable record to the fallback sink and reports
+// whether it was persisted there.
+func (f *FluentLogger) divert(tag string, t time.Time, entry interface{}) bool {
+	if err := f.fallback.write(tag, t, entry); err != nil {
+		if f.fallback == nil || !f.lastResort(tag, t, entry, err) {
+			f.drop(tag)
+			return false
+		}
+	}
+	f.fallbacks.Add(1)
+	return true
+}
+
+// drop accounts for a record under tag that was lost.
+func (f *FluentLogger) drop(tag string) {
+	f.dropped.Add(1)
+	f.droppedByTag.add(tag)
+}
+
+// observe accounts for the outcome of a delivery attempt. Every failure is
+// reported to the self-logger, as is the first success after a failure,
+// which is when the transport has reconnected.
+func (f *FluentLogger) observe(tag string, err error) {
+	if err != nil {
+		f.failures.Add(1)
+		if !f.down.Swap(true) {
+			f.connectionLost(tag, err)
+			f.signalRedial()
+		}
+		f.self.warn("delivery failed", "tag", tag, "error", err)
+		return
+	}
+	f.emitted.Add(1)
+	if f.down.Swap(false) {
+		f.connectionRecovered(tag)
+	}
+}
+
+// postBefore caps a sync post at the smaller of the configured write timeout
+// and the time left until deadline. A post that would exceed the budget is
+// abandoned: the caller gets ErrWriteAbandoned immediately while the
+// transport finishes (or fails) in the background, so the entry may or may
+// not arrive. This trades log completeness for the caller's latency.
+func (f *FluentLogger) postBefore(p poster, deadline time.Time, tag string, t time.Time, msg interface{}) error {
+	budget := time.Until(deadline)
+	if f.writeTimeout > 0 && f.writeTimeout < budget {
+		budget = f.writeTimeout
+	}
+	if budget <= 0 {
+		return ErrWriteAbandoned
+	}
+
+	// The post may outlive the read lock, so it is handed p rather than
+	// reading f.logger.
+	done := make(chan error, 1)
 	go func() {
-		f.logger.Close()
-		close(done)
+		done <- f.sendMessage(p, tag, t, msg)
 	}()
 
+	timer := time.NewTimer(budget)
+	defer timer.Stop()
+
 	select {
-	case <-done:
-		return nil
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return ErrWriteAbandoned
+	}
+}
+
+// postDirect hands a record straight to the transport, skipping the
+// breaker and the fallback sink.
+func (f *FluentLogger) postDirect(tag string, t time.Time, entry map[string]interface{}) error {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.closed.Load() {
+		return ErrLoggerClosed
+	}
+	err := f.send(f.logger, tag, t, entry)
+	f.observe(tag, err)
+	if err != nil {
+		return fmt.Errorf("log delivery failed: %w", err)
+	}
+	return nil
+}
+
+// Sync implements proper resource cleanup with timeout
+func (f *FluentLogger) Sync() error {
+	return f.syncContext(context.Background())
+}
+
+// syncContext is Sync, additionally abandoned when ctx is done. An
+// abandoned flush keeps running in the background. Only the first call
+// starts the flush; concurrent and later calls, e.g. a Sync racing Close,
+// wait for that same flush and return its result.
+func (f *FluentLogger) syncContext(ctx context.Context) error {
+	f.flushOnce.Do(f.startFlush)
+
+	select {
+	case <-f.flushed:
+		return f.flushErr
 	case <-time.After(f.timeout):
+		f.self.warn("flush timed out", "timeout", f.timeout)
 		return errors.New("fluent log flush timed out")
+	case <-ctx.Done():
+		f.self.warn("flush aborted", "error", ctx.Err())
+		return fmt.Errorf("fluent log flush aborted: %w", ctx.Err())
 	}
 }
 
-// Close implements graceful shutdown of an instance of WrappedLogger with context.
+// startFlush closes the transport in the background, flushing what it
+// holds, and closes f.flushed once it is done.
+func (f *FluentLogger) startFlush() {
+	// Acquiring mu waits for in-flight writes, so they land in the flush
+	// rather than being dropped.
+	f.self.info("flush started")
+	f.flushed = make(chan struct{})
+	go func() {
+		f.mu.Lock()
+		f.closed.Store(true)
+		f.closeQueue()
+		f.mu.Unlock()
+
+		f.waitQueue()
+		err := f.logger.Close()
+		if f.urgent != nil {
+			err = errors.Join(err, f.urgent.Close())
+		}
+		if err != nil {
+			f.flushErr = err
+			f.self.warn("flush completed", "emitted_total", f.emitted.Load(), "error", err)
+		} else {
+			f.self.info("flush completed", "emitted_total", f.emitted.Load())
+		}
+		close(f.flushed)
+	}()
+}
+
+// CircuitState reports the state of the delivery circuit breaker: "closed",
+// "open" or "half-open". It is always "closed" when the breaker is disabled.
+func (l *SugaredLogger) CircuitState() string {
+	return l.fluent.breaker.State()
+}
+
+// Close implements graceful shutdown of an instance of WrappedLogger. It is
+// bounded by the fluent Timeout and, if set, by ShutdownContext.
+// Derived loggers do not own the transport and return nil.
 func (l *SugaredLogger) Close() error {
-	if l == nil {
+	return l.CloseContext(context.Background())
+}
+
+// CloseContext is Close, additionally bounded by ctx: once ctx (or
+// ShutdownContext) is done, a flush still in progress is abandoned and
+// CloseContext returns immediately with an error wrapping ctx.Err(). Records
+// the transport had not written by then may be lost. Only the first call
+// closes the logger; later calls wait for it and return nil.
+func (l *SugaredLogger) CloseContext(ctx context.Context) error {
+	if l == nil || l.derived {
 		return nil
 	}
 
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if sc := l.cfg.ShutdownContext; sc != nil {
+		stop := context.AfterFunc(sc, cancel)
+		defer stop()
+	}
+
 	var err error
 	l.closeOnce.Do(func() {
-		_, cancel := context.WithTimeout(context.Background(), l.fluent.timeout)
-		defer cancel()
+		// Hooks run last, also when the flush is abandoned
+		defer l.fluent.runHooks()
+
+		// Records held by Pause are delivered like any other
+		l.fluent.resume()
+
+		if l.cfg.CounterFlushInterval > 0 {
+			if countErr := l.flushCounters(); countErr != nil {
+				err = fmt.Errorf("counter flush failed: %w", countErr)
+			}
+		}
+
+		if l.cfg.CloseMarker {
+			if markerErr := l.emitCloseMarker(); markerErr != nil {
+				err = fmt.Errorf("close marker failed: %w", markerErr)
+			}
+		}
+
+		// Stop background workers before the transport goes away
+		l.fluent.stopWorkers()
+
+		// Give buffered records a last chance before the connection goes
+		l.fluent.releaseBuffered()
+
+		// Close the Fluent connection, flushing what it holds
+		if fluentErr := l.fluent.syncContext(ctx); fluentErr != nil {
+			err = fmt.Errorf("fluent close failed: %w", fluentErr)
+			if ctx.Err() != nil {
+				l.fluent.self.warn("logger close aborted", "error", err)
+				return
+			}
+		}
 
-		// Flush Zap first to ensure all logs are sent to Fluent
+		// Then sync any other cores Zap writes to
 		if syncErr := l.SugaredLogger.Sync(); syncErr != nil {
 			err = fmt.Errorf("zap sync failed: %w", syncErr)
 		}
 
-		// Then close Fluent connection
-		if fluentErr := l.fluent.Sync(); fluentErr != nil {
-			err = fmt.Errorf("fluent close failed: %w", fluentErr)
+		if fallbackErr := l.fluent.fallback.sync(); fallbackErr != nil {
+			err = fmt.Errorf("fallback sync failed: %w", fallbackErr)
+		}
+
+		if err != nil {
+			l.fluent.self.warn("logger closed", "error", err)
+		} else {
+			l.fluent.self.info("logger closed")
 		}
 	})
 	return err
 }
 
+// fluentAddress describes where cfg points the transport, for diagnostics.
+func fluentAddress(cfg fluent.Config) string {
+	_, address := dialTarget(cfg)
+	return address
+}
+
+func newEncoderConfig() zapcore.EncoderConfig {
+	return zapcore.EncoderConfig{
+		TimeKey:        defaultTimestampKey,
+		LevelKey:       "severity",
+		NameKey:        "logger",
+		CallerKey:      "caller",
+		MessageKey:     "message",
+		StacktraceKey:  "stacktrace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeTime:     zapcore.RFC3339NanoTimeEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	}
+}
+
 func parseLogLevel(lvl string) zapcore.Level {
+	level, err := ParseLevel(lvl)
+	if err != nil {
+		// if something unknown was provided, just stay with debug
+		return defaultLogLevel
+	}
+	return level
+}
+
+// ParseLevel parses a level name as accepted by LogLevel, including the
+// WARNING spelling used by some log shippers.
+func ParseLevel(lvl string) (zapcore.Level, error) {
 	level := defaultLogLevel
-	err := level.UnmarshalText([]byte(lvl)) // if something unknown was provided, just stay with debug
+	err := level.UnmarshalText([]byte(lvl))
 	if err != nil && strings.ToUpper(lvl) == compatibleLevelWarningUpperCase {
-		level = zapcore.WarnLevel
+		return zapcore.WarnLevel, nil
 	}
-	return level
+	return level, err
 }
@@ -0,0 +1,219 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fluent/fluent-logger-golang/fluent"
+)
+
+// fakePoster is an in-memory poster recording what is posted to it. While
+// err is set, posts fail with it and record nothing.
+type fakePoster struct {
+	mu       sync.Mutex
+	tags     []string
+	times    []time.Time
+	messages []interface{}
+	err      error
+	closed   bool
+}
+
+func (p *fakePoster) PostWithTime(tag string, tm time.Time, message interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	p.tags = append(p.tags, tag)
+	p.times = append(p.times, tm)
+	p.messages = append(p.messages, message)
+	return nil
+}
+
+func (p *fakePoster) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
+
+func (p *fakePoster) setErr(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+// records returns the posted messages that are records.
+func (p *fakePoster) records() []map[string]interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var recs []map[string]interface{}
+	for _, m := range p.messages {
+		switch m := m.(type) {
+		case map[string]interface{}:
+			recs = append(recs, m)
+		case orderedRecord:
+			recs = append(recs, m.fields)
+		}
+	}
+	return recs
+}
+
+// last returns the last posted record, failing t if there is none.
+func (p *fakePoster) last(t testing.TB) map[string]interface{} {
+	t.Helper()
+	recs := p.records()
+	if len(recs) == 0 {
+		t.Fatal("no record posted")
+	}
+	return recs[len(recs)-1]
+}
+
+func (p *fakePoster) postedTags() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.tags...)
+}
+
+// flakyPoster fails its first fails posts with err, then delivers.
+type flakyPoster struct {
+	fakePoster
+	fails    int32
+	err      error
+	attempts atomic.Int32
+}
+
+func (p *flakyPoster) PostWithTime(tag string, tm time.Time, message interface{}) error {
+	if p.attempts.Add(1) <= p.fails {
+		return p.err
+	}
+	return p.fakePoster.PostWithTime(tag, tm, message)
+}
+
+// newTestLogger builds a logger for cfg around a fakePoster, applying the
+// defaults NewSugaredLogger would. The logger is closed when the test ends.
+func newTestLogger(t testing.TB, cfg *SugaredLoggerConfig) (*SugaredLogger, *fakePoster) {
+	t.Helper()
+	if cfg == nil {
+		cfg = &SugaredLoggerConfig{}
+	}
+	if cfg.Tag == "" {
+		cfg.Tag = defaultFluentTag
+	}
+	if cfg.FluentConfig.Timeout == 0 {
+		cfg.FluentConfig.Timeout = time.Second
+	}
+	cfg.Trace = cfg.Trace.withDefaults()
+
+	p := &fakePoster{}
+	l := newSugaredLogger(cfg, p)
+	t.Cleanup(func() { _ = l.Close() })
+	return l, p
+}
+
+func TestNewSugaredLoggerRejectsInvalidTag(t *testing.T) {
+	_, err := NewSugaredLogger(&SugaredLoggerConfig{Tag: "bad tag", FluentConfig: fluent.Config{Async: true}})
+	if err == nil {
+		t.Fatal("NewSugaredLogger accepted an invalid tag")
+	}
+}
+
+func TestLoggerPostsRecord(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+	l.Infow("hello", "user", "ada", "n", 3)
+
+	rec := p.last(t)
+	if rec["message"] != "hello" || rec["user"] != "ada" || rec["n"] != int64(3) {
+		t.Errorf("record = %v", rec)
+	}
+	if tags := p.postedTags(); len(tags) != 1 || tags[0] != defaultFluentTag {
+		t.Errorf("tags = %v, want [%s]", tags, defaultFluentTag)
+	}
+}
+
+func TestCloseRejectsLaterWrites(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	l.Infow("late")
+	if n := len(p.records()); n != 0 {
+		t.Errorf("%d records posted after Close", n)
+	}
+	if got := l.Stats().Dropped; got != 1 {
+		t.Errorf("Dropped = %d, want 1", got)
+	}
+}
+
+// fakeLogger is a Logger that records the messages logged through it and
+// the fields and tag of the logger they were logged on.
+type fakeLogger struct {
+	tag     string
+	fields  []interface{}
+	entries *[]string
+}
+
+func (f fakeLogger) log(msg string, kv ...interface{}) {
+	*f.entries = append(*f.entries, fmt.Sprint(f.tag, " ", msg, " ", append(f.fields, kv...)))
+}
+
+func (f fakeLogger) Debugw(msg string, kv ...interface{}) { f.log(msg, kv...) }
+func (f fakeLogger) Infow(msg string, kv ...interface{})  { f.log(msg, kv...) }
+func (f fakeLogger) Warnw(msg string, kv ...interface{})  { f.log(msg, kv...) }
+func (f fakeLogger) Errorw(msg string, kv ...interface{}) { f.log(msg, kv...) }
+func (f fakeLogger) Close() error                         { return nil }
+
+func (f fakeLogger) With(args ...interface{}) Logger {
+	f.fields = append(append([]interface{}(nil), f.fields...), args...)
+	return f
+}
+
+func (f fakeLogger) WithTag(tag string) Logger {
+	f.tag = tag
+	return f
+}
+
+func (f fakeLogger) Ctx(context.Context) Logger { return f }
+
+// handleDelete stands for consumer code that depends on Logger only.
+func handleDelete(ctx context.Context, log Logger, user string) {
+	log.Ctx(ctx).With("user", user).WithTag("app.audit").Infow("user deleted")
+}
+
+func TestLoggerFake(t *testing.T) {
+	var entries []string
+	handleDelete(context.Background(), fakeLogger{tag: "app", entries: &entries}, "ada")
+	if want := "app.audit user deleted [user ada]"; len(entries) != 1 || entries[0] != want {
+		t.Errorf("entries = %q, want [%q]", entries, want)
+	}
+}
+
+func TestAsLogger(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{AddCaller: true})
+	handleDelete(context.Background(), AsLogger(l), "ada")
+
+	rec := p.last(t)
+	if rec["message"] != "user deleted" || rec["user"] != "ada" {
+		t.Errorf("record = %v, want the message with the user field", rec)
+	}
+	if tags := p.postedTags(); len(tags) != 1 || tags[0] != "app.audit" {
+		t.Errorf("tags = %v, want [app.audit]", tags)
+	}
+	if caller, _ := rec["caller"].(string); !strings.Contains(caller, "logger_test.go") {
+		t.Errorf("caller = %q, want the consumer's call site", caller)
+	}
+
+	derived := AsLogger(l).With("k", "v")
+	if err := derived.Close(); err != nil {
+		t.Fatalf("closing a derived Logger: %v", err)
+	}
+	l.Infow("after")
+	if p.last(t)["message"] != "after" {
+		t.Error("closing a derived Logger closed its root")
+	}
+}
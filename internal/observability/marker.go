@@ -0,0 +1,33 @@
+package observability
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const closeMarkerMessage = "logger closed"
+
+// emitCloseMarker posts the shutdown event configured by CloseMarker. It
+// bypasses the level filter so the marker is emitted whatever the current
+// level, and reports how long the logger lived and how many entries it
+// delivered.
+func (l *SugaredLogger) emitCloseMarker() error {
+	tag := l.cfg.CloseMarkerTag
+	if tag == "" {
+		tag = l.fluent.tag
+	}
+
+	now := time.Now()
+	record := l.core.encode(zapcore.Entry{
+		Level:   l.cfg.CloseMarkerLevel,
+		Time:    now,
+		Message: l.cfg.MessagePrefix + closeMarkerMessage,
+	}, []zapcore.Field{
+		zap.Float64("uptime_seconds", time.Since(l.fluent.startedAt).Seconds()),
+		zap.Uint64("emitted_total", l.fluent.emitted.Load()),
+	})
+	return l.fluent.post(context.Background(), tag, now, record)
+}
@@ -0,0 +1,47 @@
+package observability
+
+import (
+	"sync"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestCloseMarker(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{
+		CloseMarker:      true,
+		CloseMarkerTag:   "app.lifecycle",
+		CloseMarkerLevel: zapcore.WarnLevel,
+		LogLevel:         "error",
+	})
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				l.Errorw("failed")
+			}
+		}()
+	}
+	wg.Wait()
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	tags := p.postedTags()
+	if got := tags[len(tags)-1]; got != "app.lifecycle" {
+		t.Errorf("marker tag = %q, want app.lifecycle", got)
+	}
+	marker := p.last(t)
+	if marker["message"] != closeMarkerMessage || marker["severity"] != "warn" {
+		t.Errorf("marker = %v", marker)
+	}
+	if got := marker["emitted_total"]; got != uint64(400) {
+		t.Errorf("emitted_total = %v, want 400", got)
+	}
+	if up, ok := marker["uptime_seconds"].(float64); !ok || up <= 0 {
+		t.Errorf("uptime_seconds = %v", marker["uptime_seconds"])
+	}
+}
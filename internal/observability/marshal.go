@@ -0,0 +1,74 @@
+package observability
+
+import "go.uber.org/zap/zapcore"
+
+// EntryMarshaler reshapes a record before it is posted to Fluentd. It may
+// modify entry in place or return a new map.
+type EntryMarshaler func(entry map[string]interface{}) (map[string]interface{}, error)
+
+// NestUserFields returns an EntryMarshaler that keeps the standard keys
+// (timestamp, severity, message, ...) at the top level and moves every other
+// field into a sub-object under the given key, e.g.
+//
+//	{"timestamp": ..., "severity": ..., "message": ..., "data": {...}}
+//
+// The sub-object is omitted when the entry has no user fields. The standard
+// keys are those of the built-in key layout; with an EncoderConfig, use
+// NestUserFieldsFor with the same config, or SeparateBody.
+func NestUserFields(under string) EntryMarshaler {
+	return nestUserFields(under, standardKeys)
+}
+
+// NestUserFieldsFor is NestUserFields for records laid out by cfg, the
+// EncoderConfig of the logger.
+func NestUserFieldsFor(under string, cfg zapcore.EncoderConfig) EntryMarshaler {
+	return nestUserFields(under, keySet(encoderKeys(cfg)))
+}
+
+func nestUserFields(under string, standard map[string]bool) EntryMarshaler {
+	return func(entry map[string]interface{}) (map[string]interface{}, error) {
+		out := make(map[string]interface{}, len(standard)+1)
+		user := make(map[string]interface{}, len(entry))
+		for k, v := range entry {
+			if standard[k] {
+				out[k] = v
+			} else {
+				user[k] = v
+			}
+		}
+		if len(user) > 0 {
+			out[under] = user
+		}
+		return out, nil
+	}
+}
+
+// standardKeyOrder lists the record keys written by the default encoder
+// config rather than by callers, in the order OrderedFields writes them.
+var standardKeyOrder = encoderKeys(newEncoderConfig())
+
+// standardKeys is the set of standardKeyOrder.
+var standardKeys = keySet(standardKeyOrder)
+
+// encoderKeys returns the record keys cfg writes, in the order
+// OrderedFields writes them.
+func encoderKeys(cfg zapcore.EncoderConfig) []string {
+	var keys []string
+	for _, k := range []string{
+		cfg.TimeKey, cfg.LevelKey, cfg.NameKey, cfg.CallerKey,
+		cfg.FunctionKey, cfg.MessageKey, cfg.StacktraceKey,
+	} {
+		if k != "" {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func keySet(keys []string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[k] = true
+	}
+	return set
+}
@@ -0,0 +1,62 @@
+package observability
+
+import (
+	"context"
+	"runtime"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	memStatsMessage = "runtime stats"
+	// memStatsTagSuffix is appended to the logger's tag for MemStatsInterval
+	// entries, e.g. app.logs.runtime.
+	memStatsTagSuffix = ".runtime"
+)
+
+// reportMemStats posts a runtime stats entry every interval until ctx is
+// done.
+func (l *SugaredLogger) reportMemStats(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := l.postMemStats(); err != nil {
+				l.fluent.self.warn("runtime stats report failed", "error", err)
+			}
+		}
+	}
+}
+
+// postMemStats posts one debug entry with heap, GC and goroutine figures.
+// Nothing is read while debug is disabled, as runtime.ReadMemStats briefly
+// stops the world.
+func (l *SugaredLogger) postMemStats() error {
+	if !l.core.Enabled(zapcore.DebugLevel) {
+		return nil
+	}
+
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	now := time.Now()
+	record := l.core.encode(zapcore.Entry{
+		Level:   zapcore.DebugLevel,
+		Time:    now,
+		Message: l.cfg.MessagePrefix + memStatsMessage,
+	}, []zapcore.Field{
+		zap.Uint64("alloc_bytes", m.Alloc),
+		zap.Uint64("sys_bytes", m.Sys),
+		zap.Uint64("heap_inuse_bytes", m.HeapInuse),
+		zap.Uint64("heap_objects", m.HeapObjects),
+		zap.Uint32("num_gc", m.NumGC),
+		zap.Duration("gc_pause_total", time.Duration(m.PauseTotalNs)),
+		zap.Int("goroutines", runtime.NumGoroutine()),
+	})
+	return l.fluent.post(context.Background(), l.fluent.tag+memStatsTagSuffix, now, record)
+}
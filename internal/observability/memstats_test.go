@@ -0,0 +1,44 @@
+package observability
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemStatsInterval(t *testing.T) {
+	_, p := newTestLogger(t, &SugaredLoggerConfig{Tag: "app.logs", LogLevel: "debug", MemStatsInterval: 10 * time.Millisecond})
+
+	deadline := time.Now().Add(time.Second)
+	for len(p.records()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("no runtime stats posted within a second")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	rec := p.records()[0]
+	if rec["message"] != memStatsMessage {
+		t.Errorf("message = %v, want %q", rec["message"], memStatsMessage)
+	}
+	if rec["severity"] != "debug" {
+		t.Errorf("severity = %v, want debug", rec["severity"])
+	}
+	for _, key := range []string{"alloc_bytes", "sys_bytes", "heap_inuse_bytes", "heap_objects", "num_gc", "gc_pause_total", "goroutines"} {
+		if _, ok := rec[key]; !ok {
+			t.Errorf("record lacks %s: %v", key, rec)
+		}
+	}
+	if tag := p.postedTags()[0]; tag != "app.logs.runtime" {
+		t.Errorf("tag = %q, want app.logs.runtime", tag)
+	}
+}
+
+func TestMemStatsSkippedAboveDebug(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{MemStatsInterval: time.Hour})
+	if err := l.postMemStats(); err != nil {
+		t.Fatal(err)
+	}
+	if recs := p.records(); len(recs) != 0 {
+		t.Errorf("posted %v at info level", recs)
+	}
+}
@@ -0,0 +1,74 @@
+package observability
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const nestedLine = `{"level":"info","ts":"2024-05-01T10:00:00Z","message":"m",` +
+	`"tags":["a","b"],"meta":{"region":"eu","shards":[1,2],"ratio":0.5,"deep":{"ok":true}},` +
+	`"big":18446744073709551615,"id":9007199254740993}` + "\n"
+
+func TestWritePreservesNestedValues(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+	if _, err := l.fluent.Write([]byte(nestedLine)); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	rec := p.last(t)
+	if got, want := rec["tags"], []interface{}{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tags = %#v, want %#v", got, want)
+	}
+	wantMeta := map[string]interface{}{
+		"region": "eu",
+		"shards": []interface{}{int64(1), int64(2)},
+		"ratio":  0.5,
+		"deep":   map[string]interface{}{"ok": true},
+	}
+	if got := rec["meta"]; !reflect.DeepEqual(got, wantMeta) {
+		t.Errorf("meta = %#v, want %#v", got, wantMeta)
+	}
+	if got := rec["big"]; got != uint64(18446744073709551615) {
+		t.Errorf("big = %#v, want the exact uint64", got)
+	}
+	if got := rec["id"]; got != int64(9007199254740993) {
+		t.Errorf("id = %#v, want the exact int64", got)
+	}
+}
+
+func TestWriteUseNumber(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{UseNumber: true})
+	if _, err := l.fluent.Write([]byte(nestedLine)); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	rec := p.last(t)
+	if got := rec["id"]; got != json.Number("9007199254740993") {
+		t.Errorf("id = %#v, want json.Number", got)
+	}
+	meta := rec["meta"].(map[string]interface{})
+	if got := meta["ratio"]; got != json.Number("0.5") {
+		t.Errorf("meta.ratio = %#v, want json.Number", got)
+	}
+	if got, want := meta["shards"], []interface{}{json.Number("1"), json.Number("2")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("meta.shards = %#v, want %#v", got, want)
+	}
+}
+
+func TestInfowPreservesNestedValues(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+	meta := map[string]interface{}{
+		"region": "eu",
+		"deep":   map[string]interface{}{"ok": true},
+	}
+	l.Infow("m", "tags", []string{"a", "b"}, "meta", meta)
+
+	rec := p.last(t)
+	if got, want := rec["tags"], []interface{}{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tags = %#v, want %#v", got, want)
+	}
+	if got := rec["meta"]; !reflect.DeepEqual(got, meta) {
+		t.Errorf("meta = %#v, want %#v", got, meta)
+	}
+}
@@ -0,0 +1,110 @@
+package observability
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"sort"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// orderedRecord is a record that serializes its fields in a stable order:
+// the standard keys in the order of standard, then the other keys sorted.
+// Nested maps are written with sorted keys. It implements both
+// msgp.Marshaler and json.Marshaler, so the order holds whether or not the
+// transport sends records as JSON.
+type orderedRecord struct {
+	fields   map[string]interface{}
+	standard []string
+}
+
+// message returns what is handed to the transport for entry.
+func (f *FluentLogger) message(entry map[string]interface{}) interface{} {
+	if f.cfg.OrderedFields {
+		return orderedRecord{fields: entry, standard: f.standardOrder}
+	}
+	return entry
+}
+
+func (r orderedRecord) keys() []string {
+	keys := make([]string, 0, len(r.fields))
+	for _, k := range r.standard {
+		if _, ok := r.fields[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	n := len(keys)
+	for k := range r.fields {
+		if !slices.Contains(r.standard, k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys[n:])
+	return keys
+}
+
+// MarshalMsg implements msgp.Marshaler.
+func (r orderedRecord) MarshalMsg(b []byte) ([]byte, error) {
+	return appendOrdered(b, r.fields, r.keys())
+}
+
+// MarshalJSON implements json.Marshaler. encoding/json already sorts the
+// keys of nested maps.
+func (r orderedRecord) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range r.keys() {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		val, err := json.Marshal(r.fields[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func appendOrdered(b []byte, m map[string]interface{}, keys []string) ([]byte, error) {
+	b = msgp.AppendMapHeader(b, uint32(len(keys)))
+	for _, k := range keys {
+		b = msgp.AppendString(b, k)
+		var err error
+		if b, err = appendValue(b, m[k]); err != nil {
+			return b, err
+		}
+	}
+	return b, nil
+}
+
+func appendValue(b []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return appendOrdered(b, v, keys)
+	case []interface{}:
+		b = msgp.AppendArrayHeader(b, uint32(len(v)))
+		for _, e := range v {
+			var err error
+			if b, err = appendValue(b, e); err != nil {
+				return b, err
+			}
+		}
+		return b, nil
+	default:
+		return msgp.AppendIntf(b, v)
+	}
+}
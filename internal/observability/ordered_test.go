@@ -0,0 +1,103 @@
+package observability
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// msgpKeys returns the top-level keys of a msgpack map, in wire order.
+func msgpKeys(t *testing.T, b []byte) []string {
+	t.Helper()
+	n, b, err := msgp.ReadMapHeaderBytes(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := make([]string, 0, n)
+	for i := uint32(0); i < n; i++ {
+		var k string
+		if k, b, err = msgp.ReadStringBytes(b); err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, k)
+		if b, err = msgp.Skip(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return keys
+}
+
+func TestOrderedFieldsWireOrder(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{OrderedFields: true})
+	l.Infow("m", "zeta", 1, "alpha", "a", "mid", map[string]interface{}{"y": 1, "x": 2})
+
+	p.mu.Lock()
+	msg := p.messages[0]
+	p.mu.Unlock()
+	rec, ok := msg.(orderedRecord)
+	if !ok {
+		t.Fatalf("posted %T, want orderedRecord", msg)
+	}
+	b, err := rec.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := msgpKeys(t, b)
+	var std, user []string
+	for _, k := range keys {
+		if standardKeys[k] {
+			if len(user) > 0 {
+				t.Fatalf("standard key %q after user keys in %v", k, keys)
+			}
+			std = append(std, k)
+		} else {
+			user = append(user, k)
+		}
+	}
+	if want := []string{"alpha", "mid", "zeta"}; !slices.Equal(user, want) {
+		t.Errorf("user keys = %v, want %v", user, want)
+	}
+	for i := 1; i < len(std); i++ {
+		if slices.Index(standardKeyOrder, std[i-1]) > slices.Index(standardKeyOrder, std[i]) {
+			t.Errorf("standard keys %v out of order", std)
+		}
+	}
+}
+
+func TestOrderedRecordIsByteStable(t *testing.T) {
+	rec := orderedRecord{standard: standardKeyOrder, fields: map[string]interface{}{
+		"timestamp": time.Unix(1700000000, 0).UTC().Format(time.RFC3339Nano),
+		"message":   "m",
+		"severity":  "info",
+		"b":         int64(2),
+		"a":         "x",
+		"nested":    map[string]interface{}{"d": 1, "c": []interface{}{map[string]interface{}{"f": 1, "e": 2}}},
+	}}
+	first, err := rec.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	firstJSON, err := rec.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		b, _ := rec.MarshalMsg(nil)
+		if !bytes.Equal(b, first) {
+			t.Fatal("msgpack encoding differs between calls")
+		}
+		j, _ := rec.MarshalJSON()
+		if !bytes.Equal(j, firstJSON) {
+			t.Fatalf("JSON encoding differs between calls: %s vs %s", j, firstJSON)
+		}
+	}
+	const want = `{"timestamp":"2023-11-14T22:13:20Z","severity":"info","message":"m",` +
+		`"a":"x","b":2,"nested":{"c":[{"e":2,"f":1}],"d":1}}`
+	if string(firstJSON) != want {
+		t.Errorf("JSON = %s, want %s", firstJSON, want)
+	}
+}
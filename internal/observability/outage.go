@@ -0,0 +1,138 @@
+package observability
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// maxPendingOutageReports bounds the reports waiting to be posted
+	// while the connection flaps.
+	maxPendingOutageReports = 8
+
+	connectionLostMessage      = "fluent connection lost"
+	connectionRecoveredMessage = "fluent connection recovered"
+)
+
+// outage holds the state of the current delivery outage: when it started
+// and the counters at that point, so the recovery report can tell what
+// happened during it.
+type outage struct {
+	mu        sync.Mutex
+	since     time.Time
+	cause     string
+	dropped   uint64
+	fallbacks uint64
+}
+
+// outageReport describes an outage that ended.
+type outageReport struct {
+	tag       string
+	since     time.Time
+	until     time.Time
+	cause     string
+	buffered  int
+	dropped   uint64
+	fallbacks uint64
+}
+
+// connectionLost starts an outage. It is called by observe when a delivery
+// fails after a successful one.
+func (f *FluentLogger) connectionLost(tag string, err error) {
+	o := &f.outage
+	o.mu.Lock()
+	o.since = time.Now()
+	o.cause = err.Error()
+	o.dropped = f.dropped.Load()
+	o.fallbacks = f.fallbacks.Load()
+	o.mu.Unlock()
+
+	f.self.warn("connection lost", "tag", tag, "error", err)
+}
+
+// connectionRecovered ends the outage, reports it to the self-logger and,
+// with OutageReport, queues it for posting. It is called by observe when a
+// delivery succeeds after a failed one, and never blocks.
+func (f *FluentLogger) connectionRecovered(tag string) {
+	o := &f.outage
+	o.mu.Lock()
+	r := outageReport{
+		tag:       tag,
+		since:     o.since,
+		until:     time.Now(),
+		cause:     o.cause,
+		buffered:  f.buffer.len(),
+		dropped:   f.dropped.Load() - o.dropped,
+		fallbacks: f.fallbacks.Load() - o.fallbacks,
+	}
+	o.mu.Unlock()
+
+	f.self.info("connection recovered",
+		"tag", tag,
+		"outage", r.until.Sub(r.since),
+		"buffered", r.buffered,
+		"dropped", r.dropped,
+		"fallback", r.fallbacks,
+	)
+	if f.outages == nil {
+		return
+	}
+	select {
+	case f.outages <- r:
+	default:
+		f.self.warn("outage report skipped: reports pending")
+	}
+}
+
+// reportOutages posts a warn entry for the start and an info entry for the
+// end of every outage queued by connectionRecovered, until ctx is done.
+// They are posted from here rather than from the write path that noticed
+// the recovery, which holds the transport lock.
+func (l *SugaredLogger) reportOutages(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case r := <-l.fluent.outages:
+			if err := l.postOutage(r); err != nil {
+				l.fluent.self.warn("outage report failed", "error", err)
+			}
+		}
+	}
+}
+
+func (l *SugaredLogger) postOutage(r outageReport) error {
+	tag := l.cfg.OutageReportTag
+	if tag == "" {
+		tag = l.fluent.tag
+	}
+
+	lost := l.core.encode(zapcore.Entry{
+		Level:   zapcore.WarnLevel,
+		Time:    r.since,
+		Message: l.cfg.MessagePrefix + connectionLostMessage,
+	}, []zapcore.Field{
+		zap.String("tag", r.tag),
+		zap.String("error", r.cause),
+	})
+	if err := l.fluent.post(context.Background(), tag, r.since, lost); err != nil {
+		return err
+	}
+
+	recovered := l.core.encode(zapcore.Entry{
+		Level:   zapcore.InfoLevel,
+		Time:    r.until,
+		Message: l.cfg.MessagePrefix + connectionRecoveredMessage,
+	}, []zapcore.Field{
+		zap.String("tag", r.tag),
+		zap.Float64("outage_seconds", r.until.Sub(r.since).Seconds()),
+		zap.Int("buffered", r.buffered),
+		zap.Uint64("dropped", r.dropped),
+		zap.Uint64("fallback", r.fallbacks),
+	})
+	return l.fluent.post(context.Background(), tag, r.until, recovered)
+}
@@ -0,0 +1,54 @@
+package observability
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// panicPoster panics on every post, like an encoder given a value it
+// cannot handle.
+type panicPoster struct{}
+
+func (panicPoster) PostWithTime(string, time.Time, interface{}) error { panic("cannot encode value") }
+func (panicPoster) Close() error                                      { return nil }
+
+func newPanicLogger(t *testing.T, cfg *SugaredLoggerConfig) *SugaredLogger {
+	t.Helper()
+	l, _ := newTestLogger(t, cfg)
+	l.fluent.logger = panicPoster{}
+	return l
+}
+
+func TestPostPanicIsReturned(t *testing.T) {
+	l := newPanicLogger(t, nil)
+
+	err := l.core.Write(zapcore.Entry{Level: zapcore.InfoLevel, Time: time.Now(), Message: "m"}, nil)
+	if !errors.Is(err, ErrPostPanicked) {
+		t.Fatalf("Write = %v, want ErrPostPanicked", err)
+	}
+	// The sugared API does not panic either
+	l.Infow("m")
+
+	stats := l.Stats()
+	if stats.Errors != 2 || stats.Dropped != 2 {
+		t.Errorf("Errors = %d, Dropped = %d, want 2 and 2", stats.Errors, stats.Dropped)
+	}
+}
+
+func TestPostPanicGoesToFallback(t *testing.T) {
+	var buf bytes.Buffer
+	l := newPanicLogger(t, &SugaredLoggerConfig{Fallback: &buf})
+
+	l.Infow("kept", "n", 1)
+	if !strings.Contains(buf.String(), `"message":"kept"`) {
+		t.Errorf("fallback = %q, want the record", buf.String())
+	}
+	if got := l.Stats().Fallback; got != 1 {
+		t.Errorf("Fallback = %d, want 1", got)
+	}
+}
@@ -0,0 +1,104 @@
+package observability
+
+import (
+	"context"
+	"time"
+)
+
+// defaultPauseBufferSize is the number of records PauseBuffer holds when
+// PauseBufferSize is not set.
+const defaultPauseBufferSize = 10000
+
+// PausePolicy selects what happens to entries logged while the logger is
+// paused (see Pause):
+//
+//	PauseDrop    the entry is dropped and counted as such
+//	PauseBuffer  the entry is held, up to PauseBufferSize, and delivered on Resume
+//
+// When the PauseBuffer buffer is full, its oldest record is dropped.
+// Messages built by a Serializer are dropped under either policy.
+type PausePolicy int
+
+const (
+	PauseDrop PausePolicy = iota
+	PauseBuffer
+)
+
+// newPauseBuffer returns the buffer of PauseBuffer, or nil under PauseDrop.
+func newPauseBuffer(cfg *SugaredLoggerConfig) *offlineBuffer {
+	if cfg.PausePolicy != PauseBuffer {
+		return nil
+	}
+	size := cfg.PauseBufferSize
+	if size <= 0 {
+		size = defaultPauseBufferSize
+	}
+	return newOfflineBuffer(size, nil, 0)
+}
+
+// Pause stops the logger from shipping entries, at every level, until
+// Resume, e.g. during a maintenance window of the Fluentd cluster. Entries
+// logged meanwhile are dropped or held according to PausePolicy. Pausing a
+// paused logger does nothing.
+func (l *SugaredLogger) Pause() {
+	if !l.fluent.paused.Swap(true) {
+		l.fluent.self.info("logging paused")
+	}
+}
+
+// Resume ends a Pause and delivers, oldest first, the records held under
+// PauseBuffer. Entries logged while they are delivered may be interleaved
+// with them. Resuming a logger that is not paused does nothing. Close
+// resumes a paused logger, so held records are not lost.
+func (l *SugaredLogger) Resume() {
+	l.fluent.resume()
+}
+
+// Paused reports whether the logger is paused.
+func (l *SugaredLogger) Paused() bool {
+	return l.fluent.paused.Load()
+}
+
+// holdPaused applies PausePolicy to a record posted while paused. Callers
+// must hold the write lock.
+func (f *FluentLogger) holdPaused(tag string, t time.Time, entry map[string]interface{}) {
+	if f.pauseBuffer == nil {
+		f.drop(tag)
+		return
+	}
+	for _, r := range f.pauseBuffer.push(bufferedRecord{tag: tag, time: t, record: entry}) {
+		f.drop(r.tag)
+	}
+}
+
+// resume unpauses f and delivers the records held meanwhile. The flag is
+// cleared and the held records taken under f.mu, so that no write that saw
+// the logger paused can still be adding to them.
+func (f *FluentLogger) resume() {
+	if !f.cfg.SingleProducer {
+		f.mu.Lock()
+	}
+	resumed := f.paused.Swap(false)
+	held := f.pauseBuffer.take()
+	if !f.cfg.SingleProducer {
+		f.mu.Unlock()
+	}
+	if !resumed {
+		return
+	}
+
+	f.lockWrite()
+	defer f.unlockWrite()
+
+	f.self.info("logging resumed", "held", len(held))
+	for _, r := range held {
+		switch {
+		case f.closed.Load():
+			f.drop(r.tag)
+		case f.queue != nil:
+			f.enqueue(queuedRecord{tag: r.tag, time: r.time, record: r.record})
+		default:
+			_ = f.deliver(context.Background(), r.tag, r.time, r.record, false)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package observability
+
+import (
+	"sync"
+	"testing"
+)
+
+func messages(p *fakePoster) []string {
+	var out []string
+	for _, rec := range p.records() {
+		out = append(out, rec["message"].(string))
+	}
+	return out
+}
+
+func TestPauseDrop(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+	l.Infow("before")
+	l.Pause()
+	if !l.Paused() {
+		t.Fatal("Paused() = false after Pause")
+	}
+	l.Debugw("ignored below the level")
+	l.Infow("during")
+	l.Errorw("during too")
+	l.Resume()
+	if l.Paused() {
+		t.Fatal("Paused() = true after Resume")
+	}
+	l.Infow("after")
+
+	if got := messages(p); len(got) != 2 || got[0] != "before" || got[1] != "after" {
+		t.Errorf("posted %q, want before and after only", got)
+	}
+	if s := l.Stats(); s.Dropped != 2 {
+		t.Errorf("Dropped = %d, want 2", s.Dropped)
+	}
+}
+
+func TestPauseBuffer(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{PausePolicy: PauseBuffer, PauseBufferSize: 2})
+	l.Pause()
+	l.Pause()
+	l.Infow("one")
+	l.Infow("two")
+	l.Infow("three")
+	if n := len(p.records()); n != 0 {
+		t.Fatalf("%d records posted while paused", n)
+	}
+
+	l.Resume()
+	l.Infow("after")
+	want := []string{"two", "three", "after"}
+	got := messages(p)
+	if len(got) != len(want) {
+		t.Fatalf("posted %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("posted %q, want %q", got, want)
+		}
+	}
+	if s := l.Stats(); s.Dropped != 1 {
+		t.Errorf("Dropped = %d, want the oldest record over the cap", s.Dropped)
+	}
+}
+
+func TestCloseResumesPausedLogger(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{PausePolicy: PauseBuffer})
+	l.Pause()
+	l.Infow("held")
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := messages(p); len(got) != 1 || got[0] != "held" {
+		t.Errorf("posted %q, want the held record", got)
+	}
+}
+
+func TestPauseConcurrent(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{PausePolicy: PauseBuffer})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.Infow("m")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				l.Pause()
+				l.Resume()
+			}
+		}()
+	}
+	wg.Wait()
+	l.Resume()
+
+	if n := len(p.records()); n != 400 {
+		t.Errorf("%d records posted, want all 400", n)
+	}
+}
@@ -0,0 +1,90 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/fluent/fluent-logger-golang/fluent"
+)
+
+// Fluent client defaults, applied by fluent.New to unset fields.
+const (
+	defaultFluentNetwork = "tcp"
+	defaultFluentHost    = "127.0.0.1"
+	defaultFluentPort    = 24224
+)
+
+// Ping checks that Fluentd accepts connections at the address the logger
+// currently writes to (see Reconfigure) by opening a separate connection
+// and closing it again. It does not touch the connection used for logging.
+func (l *SugaredLogger) Ping(ctx context.Context) error {
+	return ping(ctx, l.fluent.currentEndpoint())
+}
+
+func ping(ctx context.Context, cfg fluent.Config) error {
+	network, address := dialTarget(cfg)
+	d := net.Dialer{Timeout: cfg.Timeout}
+	conn, err := d.DialContext(ctx, network, address)
+	if err != nil {
+		return fmt.Errorf("fluentd unreachable at %s: %w", address, err)
+	}
+	return conn.Close()
+}
+
+// defaultConnectRetryInterval is the wait before the first ConnectRetries
+// retry when ConnectRetryInterval is not set.
+const defaultConnectRetryInterval = time.Second
+
+// startupPing is the check connectOnStart runs; tests replace it.
+var startupPing = ping
+
+// connectOnStart pings target for ConnectOnStart, retrying a failed ping up
+// to ConnectRetries times. The wait starts at ConnectRetryInterval and
+// doubles with every retry, capped at a minute. Each failed attempt is
+// reported to self.
+func connectOnStart(ctx context.Context, cfg *SugaredLoggerConfig, target fluent.Config, self *selfLogger) error {
+	wait := cfg.ConnectRetryInterval
+	if wait <= 0 {
+		wait = defaultConnectRetryInterval
+	}
+
+	for attempt := 0; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, target.Timeout)
+		err := startupPing(pingCtx, target)
+		cancel()
+		if err == nil || attempt >= cfg.ConnectRetries {
+			return err
+		}
+
+		self.warn("connect on start failed, retrying", "attempt", attempt+1, "wait", wait, "error", err)
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+		wait = min(wait*2, defaultMaxRedialWait)
+	}
+}
+
+// dialTarget returns the network and address the fluent client connects
+// to for cfg, with its defaults applied.
+func dialTarget(cfg fluent.Config) (string, string) {
+	switch cfg.FluentNetwork {
+	case "unix", datagramNetwork:
+		return cfg.FluentNetwork, cfg.FluentSocketPath
+	case "", "tls":
+		cfg.FluentNetwork = defaultFluentNetwork
+	}
+	if cfg.FluentHost == "" {
+		cfg.FluentHost = defaultFluentHost
+	}
+	if cfg.FluentPort == 0 {
+		cfg.FluentPort = defaultFluentPort
+	}
+	return cfg.FluentNetwork, net.JoinHostPort(cfg.FluentHost, strconv.Itoa(cfg.FluentPort))
+}
@@ -0,0 +1,106 @@
+package observability
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fluent/fluent-logger-golang/fluent"
+)
+
+// succeedOnAttempt replaces startupPing for the test with one that fails
+// until its nth call, and returns the number of calls made.
+func succeedOnAttempt(t *testing.T, n int) *int {
+	t.Helper()
+	prev := startupPing
+	t.Cleanup(func() { startupPing = prev })
+	var calls int
+	startupPing = func(context.Context, fluent.Config) error {
+		calls++
+		if calls < n {
+			return errors.New("connection refused")
+		}
+		return nil
+	}
+	return &calls
+}
+
+func TestConnectOnStartRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		succeedOn int
+		retries   int
+		wantCalls int
+		wantErr   bool
+	}{
+		{"first attempt", 1, 0, 1, false},
+		{"third attempt", 3, 5, 3, false},
+		{"retries exhausted", 4, 2, 3, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := succeedOnAttempt(t, tt.succeedOn)
+			var buf bytes.Buffer
+			cfg := &SugaredLoggerConfig{ConnectRetries: tt.retries, ConnectRetryInterval: time.Millisecond}
+
+			err := connectOnStart(context.Background(), cfg, fluent.Config{Timeout: time.Second}, &selfLogger{w: &buf})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("connectOnStart = %v, want error %v", err, tt.wantErr)
+			}
+			if *calls != tt.wantCalls {
+				t.Errorf("%d attempts, want %d", *calls, tt.wantCalls)
+			}
+			if got := strings.Count(buf.String(), "connect on start failed, retrying"); got != tt.wantCalls-1 {
+				t.Errorf("%d retries self-logged, want %d", got, tt.wantCalls-1)
+			}
+		})
+	}
+}
+
+func TestConnectOnStartBackoff(t *testing.T) {
+	succeedOnAttempt(t, 4)
+	var buf bytes.Buffer
+	cfg := &SugaredLoggerConfig{ConnectRetries: 3, ConnectRetryInterval: time.Millisecond}
+	if err := connectOnStart(context.Background(), cfg, fluent.Config{Timeout: time.Second}, &selfLogger{w: &buf}); err != nil {
+		t.Fatal(err)
+	}
+	for i, wait := range []string{"1ms", "2ms", "4ms"} {
+		if !strings.Contains(buf.String(), `wait="`+wait+`"`) {
+			t.Errorf("retry %d: self-log %q lacks wait=%s", i+1, buf.String(), wait)
+		}
+	}
+}
+
+func TestConnectOnStartCanceled(t *testing.T) {
+	calls := succeedOnAttempt(t, 100)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cfg := &SugaredLoggerConfig{ConnectRetries: 10, ConnectRetryInterval: time.Hour}
+
+	if err := connectOnStart(ctx, cfg, fluent.Config{Timeout: time.Second}, nil); err == nil {
+		t.Fatal("connectOnStart succeeded")
+	}
+	if *calls != 1 {
+		t.Errorf("%d attempts after cancel, want 1", *calls)
+	}
+}
+
+func TestNewSugaredLoggerConnectRetries(t *testing.T) {
+	calls := succeedOnAttempt(t, 2)
+	l, err := NewSugaredLogger(&SugaredLoggerConfig{
+		ConnectOnStart:       true,
+		ConnectRetries:       1,
+		ConnectRetryInterval: time.Millisecond,
+		FluentConfig:         fluent.Config{Async: true},
+	})
+	if err != nil {
+		t.Fatalf("NewSugaredLogger: %v", err)
+	}
+	defer l.Close()
+	if *calls != 2 {
+		t.Errorf("%d attempts, want 2", *calls)
+	}
+}
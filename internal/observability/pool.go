@@ -0,0 +1,107 @@
+package observability
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// posterPool spreads posts over several transports, so that concurrent
+// writers do not all queue behind the connection lock of a single fluent
+// client. Posts go round-robin to the next healthy member; a member is
+// unhealthy from a failed post until its next successful one.
+type posterPool struct {
+	members []*pooledPoster
+	next    atomic.Uint64
+}
+
+type pooledPoster struct {
+	poster
+	down     atomic.Bool
+	failures atomic.Uint64
+}
+
+// ConnHealth describes one connection of the pool.
+type ConnHealth struct {
+	// Shard is the index of the shard (see Shards) the connection belongs
+	// to; zero without shards.
+	Shard   int    `json:"shard"`
+	Index   int    `json:"index"`
+	Healthy bool   `json:"healthy"`
+	Errors  uint64 `json:"errors"`
+}
+
+// newPosterPool builds size posters with dial. If one fails, those already
+// built are closed.
+func newPosterPool(size int, dial func() (poster, error)) (*posterPool, error) {
+	pool := &posterPool{members: make([]*pooledPoster, 0, size)}
+	for i := 0; i < size; i++ {
+		p, err := dial()
+		if err != nil {
+			pool.Close()
+			return nil, err
+		}
+		pool.members = append(pool.members, &pooledPoster{poster: p})
+	}
+	return pool, nil
+}
+
+// pick returns the next healthy member, or the next member if none is
+// healthy.
+func (p *posterPool) pick() *pooledPoster {
+	n := uint64(len(p.members))
+	start := p.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		if m := p.members[(start+i)%n]; !m.down.Load() {
+			return m
+		}
+	}
+	return p.members[start%n]
+}
+
+func (p *posterPool) PostWithTime(tag string, tm time.Time, message interface{}) error {
+	m := p.pick()
+	err := m.PostWithTime(tag, tm, message)
+	if err != nil {
+		m.failures.Add(1)
+	}
+	m.down.Store(err != nil)
+	return err
+}
+
+// Close closes every member.
+func (p *posterPool) Close() error {
+	var errs []error
+	for _, m := range p.members {
+		if err := m.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p *posterPool) health() []ConnHealth {
+	health := make([]ConnHealth, len(p.members))
+	for i, m := range p.members {
+		health[i] = ConnHealth{Index: i, Healthy: !m.down.Load(), Errors: m.failures.Load()}
+	}
+	return health
+}
+
+// ConnHealth reports the health of each connection of the pool configured
+// by ConnectionPoolSize, for every shard with Shards. Without a pool it
+// reports the single connection (of each shard), which is unhealthy from a
+// failed delivery until the next successful one.
+func (l *SugaredLogger) ConnHealth() []ConnHealth {
+	f := l.fluent
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	switch p := f.logger.(type) {
+	case *posterPool:
+		return p.health()
+	case *shardedPoster:
+		return p.connHealth()
+	}
+	return []ConnHealth{{Healthy: !f.down.Load(), Errors: f.failures.Load()}}
+}
@@ -0,0 +1,166 @@
+package observability
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fluent/fluent-logger-golang/fluent"
+	"github.com/niquet/go-fluentd-logger-poc/internal/observability/fluenttest"
+)
+
+// newFakePool returns a pool of size fakePosters, and the posters.
+func newFakePool(t *testing.T, size int) (*posterPool, []*fakePoster) {
+	t.Helper()
+	var fakes []*fakePoster
+	pool, err := newPosterPool(size, func() (poster, error) {
+		p := &fakePoster{}
+		fakes = append(fakes, p)
+		return p, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pool, fakes
+}
+
+func TestPosterPoolRoundRobin(t *testing.T) {
+	pool, fakes := newFakePool(t, 3)
+	for i := 0; i < 6; i++ {
+		if err := pool.PostWithTime("app", time.Now(), map[string]interface{}{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, p := range fakes {
+		if n := len(p.records()); n != 2 {
+			t.Errorf("member %d got %d posts, want 2", i, n)
+		}
+	}
+}
+
+func TestPosterPoolSkipsUnhealthyMember(t *testing.T) {
+	pool, fakes := newFakePool(t, 2)
+	fakes[0].setErr(errors.New("broken pipe"))
+	fakes[1].setErr(errors.New("broken pipe"))
+	for i := 0; i < 2; i++ {
+		_ = pool.PostWithTime("app", time.Now(), map[string]interface{}{})
+	}
+	fakes[1].setErr(nil)
+	_ = pool.PostWithTime("app", time.Now(), map[string]interface{}{})
+
+	// Member 1 recovered; member 0 stays down and is skipped
+	for i := 0; i < 4; i++ {
+		if err := pool.PostWithTime("app", time.Now(), map[string]interface{}{}); err != nil {
+			t.Fatalf("post %d went to the unhealthy member: %v", i, err)
+		}
+	}
+	health := pool.health()
+	if health[0].Healthy || health[0].Errors != 1 || !health[1].Healthy || health[1].Errors != 1 {
+		t.Errorf("health = %+v", health)
+	}
+}
+
+func TestPosterPoolDialFailureClosesMembers(t *testing.T) {
+	var built []*fakePoster
+	_, err := newPosterPool(3, func() (poster, error) {
+		if len(built) == 2 {
+			return nil, errors.New("connection refused")
+		}
+		p := &fakePoster{}
+		built = append(built, p)
+		return p, nil
+	})
+	if err == nil {
+		t.Fatal("newPosterPool succeeded with a failing dial")
+	}
+	for i, p := range built {
+		if !p.closed {
+			t.Errorf("member %d left open", i)
+		}
+	}
+}
+
+func TestConnHealthSingleConnection(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+	p.setErr(errors.New("connection refused"))
+	l.Infow("lost")
+
+	health := l.ConnHealth()
+	if len(health) != 1 || health[0].Healthy || health[0].Errors != 1 {
+		t.Errorf("ConnHealth = %+v", health)
+	}
+}
+
+// BenchmarkConnectionPool measures the throughput of concurrent writers
+// through a sync logger against the test server, by ConnectionPoolSize.
+func BenchmarkConnectionPool(b *testing.B) {
+	addr, _ := fluenttest.StartTestServer(b)
+	host, port, _ := net.SplitHostPort(addr)
+	portNum, _ := strconv.Atoi(port)
+
+	for _, size := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("size_%d", size), func(b *testing.B) {
+			l, err := NewSugaredLogger(&SugaredLoggerConfig{
+				ConnectionPoolSize: size,
+				FluentConfig: fluent.Config{
+					FluentHost: host,
+					FluentPort: portNum,
+				},
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer l.Close()
+
+			b.ReportAllocs()
+			b.SetParallelism(4)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					l.Infow("request served", "user", "ada", "attempt", 3)
+				}
+			})
+		})
+	}
+}
+
+func TestConnHealthShardedPools(t *testing.T) {
+	l, _ := newTestLogger(t, &SugaredLoggerConfig{ShardKeyField: "tenant"})
+	var pools [][]*fakePoster
+	sharded, err := newShardedPoster("tenant", make([]fluent.Config, 2), func(fluent.Config) (poster, error) {
+		pool, fakes := newFakePool(t, 2)
+		pools = append(pools, fakes)
+		return pool, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.fluent.logger = sharded
+	pools[1][0].setErr(errors.New("connection refused"))
+	pools[1][1].setErr(errors.New("connection refused"))
+
+	// Find a tenant routed to shard 1 and fail a post to each of its
+	// connections
+	tenant := ""
+	for i := 0; tenant == ""; i++ {
+		if k := string(rune('a' + i)); sharded.pick(map[string]interface{}{"tenant": k}) == sharded.members[1] {
+			tenant = k
+		}
+	}
+	l.Infow("lost", "tenant", tenant)
+	l.Infow("lost", "tenant", tenant)
+
+	health := l.ConnHealth()
+	if len(health) != 4 {
+		t.Fatalf("ConnHealth = %+v, want 4 connections", health)
+	}
+	for _, h := range health {
+		wantHealthy := h.Shard == 0
+		if h.Healthy != wantHealthy {
+			t.Errorf("shard %d connection %d: healthy %v, want %v", h.Shard, h.Index, h.Healthy, wantHealthy)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package observability
+
+import "fmt"
+
+// lockWrite takes f.mu for reading for a write, unless SingleProducer is
+// set. In race-enabled builds, SingleProducer writes instead check that
+// they all come from the same goroutine.
+func (f *FluentLogger) lockWrite() {
+	if !f.cfg.SingleProducer {
+		f.mu.RLock()
+		return
+	}
+	if raceEnabled {
+		f.checkProducer()
+	}
+}
+
+// unlockWrite releases what lockWrite took.
+func (f *FluentLogger) unlockWrite() {
+	if !f.cfg.SingleProducer {
+		f.mu.RUnlock()
+	}
+}
+
+// checkProducer panics if the calling goroutine is not the one that wrote
+// first. goroutineID costs a stack capture, so it only runs in race-enabled
+// builds.
+func (f *FluentLogger) checkProducer() {
+	id := goroutineID()
+	if f.producer.CompareAndSwap(0, id) {
+		return
+	}
+	if owner := f.producer.Load(); owner != id {
+		panic(fmt.Sprintf("observability: SingleProducer logger written from goroutine %d, owned by goroutine %d", id, owner))
+	}
+}
@@ -0,0 +1,70 @@
+package observability
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fluent/fluent-logger-golang/fluent"
+)
+
+func TestSingleProducer(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{SingleProducer: true})
+	for i := 0; i < 3; i++ {
+		l.Infow("m", "i", i)
+	}
+	if n := len(p.records()); n != 3 {
+		t.Errorf("%d records posted, want 3", n)
+	}
+}
+
+func TestSingleProducerSecondGoroutine(t *testing.T) {
+	if !raceEnabled {
+		t.Skip("the producer check only runs with -race")
+	}
+	l, _ := newTestLogger(t, &SugaredLoggerConfig{SingleProducer: true})
+	l.Infow("owner")
+
+	panicked := make(chan interface{})
+	go func() {
+		defer func() { panicked <- recover() }()
+		l.Infow("intruder")
+	}()
+	r := <-panicked
+	if msg, _ := r.(string); !strings.Contains(msg, "SingleProducer logger written from goroutine") {
+		t.Errorf("write from a second goroutine: recover() = %v, want the ownership panic", r)
+	}
+}
+
+func TestSingleProducerRejectsBackgroundReplacement(t *testing.T) {
+	for _, cfg := range []*SugaredLoggerConfig{
+		{SingleProducer: true, MaxConnLifetime: 1},
+		{SingleProducer: true, FluentConfig: fluent.Config{FluentNetwork: "unix", FluentSocketPath: "/tmp/fluent.sock"}},
+		{SingleProducer: true, FluentConfig: fluent.Config{Async: true}, AutoFlushInterval: time.Second},
+		{SingleProducer: true, CounterFlushInterval: time.Second},
+		{SingleProducer: true, MemStatsInterval: time.Second},
+		{SingleProducer: true, OutageReport: true},
+		{SingleProducer: true, EmitStartupBanner: true},
+		{SingleProducer: true, CloseMarker: true},
+	} {
+		if _, err := NewSugaredLogger(cfg); err == nil {
+			t.Errorf("NewSugaredLogger(%+v) accepted SingleProducer", cfg)
+		}
+	}
+}
+
+func BenchmarkSingleProducer(b *testing.B) {
+	for _, single := range []bool{false, true} {
+		name := "default"
+		if single {
+			name = "single_producer"
+		}
+		b.Run(name, func(b *testing.B) {
+			l := newBenchLogger(b, &SugaredLoggerConfig{SingleProducer: single})
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				l.Infow("request served", "user", "ada", "attempt", 3)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package observability
+
+import (
+	"context"
+	"time"
+)
+
+// queuedRecord is a record accepted by post and waiting in the queue.
+type queuedRecord struct {
+	tag    string
+	time   time.Time
+	record map[string]interface{}
+}
+
+// startQueue starts the queue configured by QueueSize and its worker. The
+// worker is not bound to the logger's lifetime like the others: it runs
+// until closeQueue, so records queued before the transport is closed are
+// still delivered.
+func (f *FluentLogger) startQueue(size int) {
+	f.queue = make(chan queuedRecord, size)
+	f.queueDone = make(chan struct{})
+	go func() {
+		defer close(f.queueDone)
+		for r := range f.queue {
+			f.mu.RLock()
+			// Errors are accounted for by deliver; there is no caller
+			// left to return them to.
+			_ = f.deliver(context.Background(), r.tag, r.time, r.record, false)
+			f.mu.RUnlock()
+		}
+	}()
+}
+
+// enqueue adds r to the queue without blocking. If the queue is full, r is
+// dropped and handed to OnOverflow. Callers must hold f.mu for reading.
+func (f *FluentLogger) enqueue(r queuedRecord) {
+	select {
+	case f.queue <- r:
+	default:
+		f.drop(r.tag)
+		if f.cfg.OnOverflow != nil {
+			f.cfg.OnOverflow(r.record)
+		}
+	}
+}
+
+// closeQueue stops the queue from accepting records. Callers must hold
+// f.mu and have set f.closed, so no post can enqueue any more.
+func (f *FluentLogger) closeQueue() {
+	if f.queue != nil {
+		close(f.queue)
+	}
+}
+
+// waitQueue waits until the queue worker delivered every queued record.
+func (f *FluentLogger) waitQueue() {
+	if f.queueDone != nil {
+		<-f.queueDone
+	}
+}
@@ -0,0 +1,76 @@
+package observability
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingPoster blocks every post until released, signaling on started
+// when one begins.
+type blockingPoster struct {
+	fakePoster
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingPoster) PostWithTime(tag string, tm time.Time, message interface{}) error {
+	p.started <- struct{}{}
+	<-p.release
+	return p.fakePoster.PostWithTime(tag, tm, message)
+}
+
+func TestQueueOverflow(t *testing.T) {
+	var mu sync.Mutex
+	var overflowed []interface{}
+	l, _ := newTestLogger(t, &SugaredLoggerConfig{
+		QueueSize: 1,
+		OnOverflow: func(entry map[string]interface{}) {
+			mu.Lock()
+			defer mu.Unlock()
+			overflowed = append(overflowed, entry["message"])
+		},
+	})
+	p := &blockingPoster{started: make(chan struct{}, 3), release: make(chan struct{})}
+	l.fluent.logger = p
+
+	l.Infow("delivering")
+	<-p.started // the worker holds the first record
+	l.Infow("queued")
+	l.Infow("dropped 1")
+	l.Infow("dropped 2")
+
+	mu.Lock()
+	got := append([]interface{}(nil), overflowed...)
+	mu.Unlock()
+	if want := []interface{}{"dropped 1", "dropped 2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("OnOverflow got %v, want %v", got, want)
+	}
+	if n := l.Stats().Dropped; n != 2 {
+		t.Errorf("Dropped = %d, want 2", n)
+	}
+
+	close(p.release)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := messagesOf(p.records()), []interface{}{"delivering", "queued"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("delivered %v, want %v", got, want)
+	}
+}
+
+func TestQueueWithoutOnOverflow(t *testing.T) {
+	l, _ := newTestLogger(t, &SugaredLoggerConfig{QueueSize: 1})
+	p := &blockingPoster{started: make(chan struct{}, 3), release: make(chan struct{})}
+	l.fluent.logger = p
+
+	l.Infow("delivering")
+	<-p.started
+	l.Infow("queued")
+	l.Infow("dropped")
+	if n := l.Stats().Dropped; n != 1 {
+		t.Errorf("Dropped = %d, want 1", n)
+	}
+	close(p.release)
+}
@@ -0,0 +1,189 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fluent/fluent-logger-golang/fluent"
+)
+
+// errNoDialer is returned by Reconnect for loggers built around a poster
+// they cannot recreate.
+var errNoDialer = errors.New("logger cannot reconnect: no dialer")
+
+// Reconnect replaces the underlying Fluent client with a fresh one built
+// from the same configuration. Writes in flight finish on the old client,
+// which is then closed (flushing what it still holds); writes that start
+// after the swap use the new client. It is safe to call concurrently with
+// logging.
+func (l *SugaredLogger) Reconnect() error {
+	return l.fluent.recycle()
+}
+
+// LastRecycledAt returns when the Fluent client was last replaced by
+// Reconnect, MaxConnLifetime or the Flush of an async logger, or the zero
+// time if it never was.
+func (l *SugaredLogger) LastRecycledAt() time.Time {
+	n := l.fluent.recycledAt.Load()
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+func (f *FluentLogger) recycle() error {
+	f.mu.RLock()
+	dial := f.dial
+	f.mu.RUnlock()
+	if dial == nil {
+		return errNoDialer
+	}
+	next, err := dial()
+	if err != nil {
+		f.self.warn("reconnect failed", "error", err)
+		return fmt.Errorf("failed to create fluent logger: %w", err)
+	}
+	if err := f.swap(next, nil, dial, nil); err != nil {
+		return err
+	}
+	f.self.info("transport recycled")
+	return nil
+}
+
+// swap replaces the client with next, built by dial, and, if urgent is not
+// nil, the SyncFlushLevels client with urgent. It closes the clients it
+// replaced, flushing what they still hold. dial becomes the one used by
+// later recycles and, if endpoint is not nil, the endpoint redials and
+// Ping check.
+func (f *FluentLogger) swap(next, urgent poster, dial func() (poster, error), endpoint *fluent.Config) error {
+	// Taking mu for writing waits for in-flight writes, so no write sees
+	// the old client closed under it.
+	f.mu.Lock()
+	if f.closed.Load() {
+		f.mu.Unlock()
+		next.Close()
+		if urgent != nil {
+			urgent.Close()
+		}
+		return ErrLoggerClosed
+	}
+	prev, prevUrgent := f.logger, f.urgent
+	f.logger = next
+	if urgent != nil {
+		f.urgent = urgent
+	}
+	f.dial = dial
+	if endpoint != nil {
+		f.endpoint = *endpoint
+	}
+	f.recycledAt.Store(time.Now().UnixNano())
+	f.mu.Unlock()
+
+	err := prev.Close()
+	if urgent != nil && prevUrgent != nil {
+		err = errors.Join(err, prevUrgent.Close())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to close previous fluent logger: %w", err)
+	}
+	return nil
+}
+
+// Reconfigure points the logger at the Fluentd endpoint described by cfg,
+// e.g. after Fluentd moved to a new address that an existing connection
+// would never pick up. It checks that the endpoint is reachable (see
+// Ping), builds a new client, swaps it in and closes the old one, flushing
+// what it still holds. The separate client of SyncFlushLevels, if any, is
+// replaced along with it. Writes in flight finish on the old client; writes
+// that start after the swap use the new one. If the endpoint cannot be
+// reached or either client cannot be built, the logger keeps its
+// connections and the error is returned.
+//
+// The logger's own Async, Timeout and WriteTimeout settings are kept;
+// ConnectionPoolSize and Dialect apply to the new client, and later
+// Reconnect calls and MaxConnLifetime recycles use cfg. Sharded loggers
+// (Shards) cannot be reconfigured. It is safe to call concurrently with
+// logging and Close; after Close it returns ErrLoggerClosed.
+func (l *SugaredLogger) Reconfigure(cfg fluent.Config) error {
+	f := l.fluent
+	if len(l.cfg.Shards) > 0 {
+		return errors.New("cannot reconfigure a sharded logger")
+	}
+	if f.closed.Load() {
+		return ErrLoggerClosed
+	}
+
+	if cfg.Timeout == 0 {
+		cfg.Timeout = f.timeout
+	}
+	l.cfg.Dialect.apply(&cfg, f.self)
+	if cfg.FluentNetwork == datagramNetwork {
+		if err := validateDatagram(cfg); err != nil {
+			return fmt.Errorf("reconfigure failed: %w", err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	err := ping(ctx, cfg)
+	cancel()
+	if err != nil {
+		f.self.warn("reconfigure failed", "address", fluentAddress(cfg), "error", err)
+		return fmt.Errorf("reconfigure failed: %w", err)
+	}
+
+	conf := *l.cfg
+	conf.FluentConfig = cfg
+	dial := newDialer(&conf, nil, f.async)
+	next, err := dial()
+	if err != nil {
+		f.self.warn("reconfigure failed", "address", fluentAddress(cfg), "error", err)
+		return fmt.Errorf("reconfigure failed: %w", err)
+	}
+	// The SyncFlushLevels client follows the endpoint too; if it cannot be
+	// built, neither client is replaced.
+	var urgent poster
+	if f.hasUrgent() {
+		if urgent, err = newDialer(&conf, nil, false)(); err != nil {
+			next.Close()
+			f.self.warn("reconfigure failed", "address", fluentAddress(cfg), "error", err)
+			return fmt.Errorf("reconfigure failed: %w", err)
+		}
+	}
+	if err := f.swap(next, urgent, dial, &cfg); err != nil {
+		return err
+	}
+	f.self.info("transport reconfigured", "address", fluentAddress(cfg))
+	return nil
+}
+
+// hasUrgent reports whether the logger has a SyncFlushLevels client.
+func (f *FluentLogger) hasUrgent() bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.urgent != nil
+}
+
+// currentEndpoint returns the FluentConfig of the current client.
+func (f *FluentLogger) currentEndpoint() fluent.Config {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.endpoint
+}
+
+// recycleEvery calls recycle every lifetime until ctx is done.
+func (f *FluentLogger) recycleEvery(ctx context.Context, lifetime time.Duration) {
+	ticker := time.NewTicker(lifetime)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := f.recycle(); err != nil && !errors.Is(err, ErrLoggerClosed) {
+				f.self.warn("connection recycle failed", "error", err)
+			}
+		}
+	}
+}
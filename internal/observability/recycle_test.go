@@ -0,0 +1,105 @@
+package observability
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/fluent/fluent-logger-golang/fluent"
+	"go.uber.org/zap/zapcore"
+)
+
+// fakeClients replaces newFluentClient for the test with one returning
+// fakePosters, failing sync clients with syncErr if it is not nil.
+func fakeClients(t *testing.T, syncErr error) *[]*fakePoster {
+	t.Helper()
+	var mu sync.Mutex
+	var built []*fakePoster
+	prev := newFluentClient
+	t.Cleanup(func() { newFluentClient = prev })
+	newFluentClient = func(c fluent.Config) (poster, error) {
+		if !c.Async && syncErr != nil {
+			return nil, syncErr
+		}
+		p := &fakePoster{}
+		mu.Lock()
+		built = append(built, p)
+		mu.Unlock()
+		return p, nil
+	}
+	return &built
+}
+
+func newSyncFlushLogger(t *testing.T) (*SugaredLogger, *fakePoster) {
+	t.Helper()
+	l, _ := newAsyncTestLogger(t, &SugaredLoggerConfig{SyncFlushLevels: []zapcore.Level{zapcore.ErrorLevel}})
+	urgent := &fakePoster{}
+	l.fluent.urgent = urgent
+	return l, urgent
+}
+
+func isClosed(p *fakePoster) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+func TestReconfigureReplacesSyncFlushClient(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fluent.sock")
+	startUnixSink(t, path)
+	l, oldUrgent := newSyncFlushLogger(t)
+	oldLogger := l.fluent.logger
+	built := fakeClients(t, nil)
+
+	if err := l.Reconfigure(unixConfig(path)); err != nil {
+		t.Fatalf("Reconfigure: %v", err)
+	}
+	if len(*built) != 2 {
+		t.Fatalf("built %d clients, want an async and a sync one", len(*built))
+	}
+	if l.fluent.logger == oldLogger || l.fluent.urgent == poster(oldUrgent) {
+		t.Fatal("Reconfigure kept a previous client")
+	}
+	if !isClosed(oldUrgent) {
+		t.Error("previous sync client left open")
+	}
+
+	l.Errorw("after")
+	if n := len(l.fluent.urgent.(*fakePoster).records()); n != 1 {
+		t.Errorf("new sync client got %d records, want 1", n)
+	}
+	if n := len(oldUrgent.records()); n != 0 {
+		t.Errorf("previous sync client got %d records, want 0", n)
+	}
+}
+
+func TestReconfigureRollsBackOnSyncClientFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fluent.sock")
+	startUnixSink(t, path)
+	l, oldUrgent := newSyncFlushLogger(t)
+	oldLogger, oldEndpoint := l.fluent.logger, l.fluent.currentEndpoint()
+	dialErr := errors.New("connection refused")
+	built := fakeClients(t, dialErr)
+
+	if err := l.Reconfigure(unixConfig(path)); !errors.Is(err, dialErr) {
+		t.Fatalf("Reconfigure = %v, want the dial error", err)
+	}
+	if l.fluent.logger != oldLogger || l.fluent.urgent != poster(oldUrgent) {
+		t.Error("Reconfigure replaced a client although the sync client failed")
+	}
+	if l.fluent.currentEndpoint().FluentSocketPath != oldEndpoint.FluentSocketPath {
+		t.Error("Reconfigure changed the endpoint although it failed")
+	}
+	if isClosed(oldUrgent) {
+		t.Error("previous sync client closed")
+	}
+	if len(*built) != 1 || !isClosed((*built)[0]) {
+		t.Error("new async client not closed after the rollback")
+	}
+
+	l.Errorw("after")
+	if n := len(oldUrgent.records()); n != 1 {
+		t.Errorf("previous sync client got %d records, want 1", n)
+	}
+}
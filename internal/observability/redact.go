@@ -0,0 +1,83 @@
+package observability
+
+import "regexp"
+
+const redactedMask = "[REDACTED]"
+
+// Built-in patterns for RedactPatterns.
+var (
+	// RedactEmails matches email addresses.
+	RedactEmails = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// RedactCreditCards matches 13 to 19 digit card numbers, optionally
+	// grouped by spaces or dashes. Only matches that pass the Luhn check
+	// are masked, so most other long numbers, such as order IDs, are kept.
+	RedactCreditCards = regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`)
+)
+
+// redactRecord masks every match of the configured patterns in the string
+// values of entry, except the trace and correlation fields attached by Ctx,
+// whose IDs would otherwise be masked when they look like card numbers.
+func (f *FluentLogger) redactRecord(entry map[string]interface{}) {
+	for k, v := range entry {
+		switch k {
+		case f.cfg.Trace.TraceIDKey, f.cfg.Trace.SpanIDKey, CorrelationIDKey:
+			continue
+		}
+		entry[k] = redact(v, f.cfg.RedactPatterns)
+	}
+}
+
+// redact masks every match of patterns in the string values of v,
+// descending into maps and slices. Maps and slices are updated in place.
+func redact(v interface{}, patterns []*regexp.Regexp) interface{} {
+	switch v := v.(type) {
+	case string:
+		for _, re := range patterns {
+			if re == RedactCreditCards {
+				v = re.ReplaceAllStringFunc(v, maskCard)
+				continue
+			}
+			v = re.ReplaceAllString(v, redactedMask)
+		}
+		return v
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = redact(e, patterns)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = redact(e, patterns)
+		}
+	}
+	return v
+}
+
+// maskCard masks a RedactCreditCards match if its digits pass the Luhn
+// check, and returns it unchanged otherwise.
+func maskCard(s string) string {
+	if luhnValid(s) {
+		return redactedMask
+	}
+	return s
+}
+
+// luhnValid reports whether the digits of s, ignoring separators, form a
+// number with a valid Luhn check digit.
+func luhnValid(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
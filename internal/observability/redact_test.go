@@ -0,0 +1,75 @@
+package observability
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRedactCreditCards(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"card 4111111111111111", "card " + redactedMask},
+		{"card 4111-1111-1111-1111 declined", "card " + redactedMask + " declined"},
+		{"card 5555 5555 5555 4444", "card " + redactedMask},
+		{"order 4111111111111112", "order 4111111111111112"},
+		{"order 1234567890123456", "order 1234567890123456"},
+		{"code 123456789012", "code 123456789012"},
+	}
+	for _, tt := range tests {
+		if got := redact(tt.in, []*regexp.Regexp{RedactCreditCards}); got != tt.want {
+			t.Errorf("redact(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRedactRecord(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{RedactPatterns: []*regexp.Regexp{RedactEmails, RedactCreditCards}})
+	l.Infow("paid with 4111 1111 1111 1111",
+		"email", "ada@example.com",
+		"order_id", "1234567890123456",
+		"items", []interface{}{map[string]interface{}{"card": "4111111111111111"}},
+	)
+
+	rec := p.last(t)
+	if rec["message"] != "paid with "+redactedMask {
+		t.Errorf("message = %v, want the card masked", rec["message"])
+	}
+	if rec["email"] != redactedMask {
+		t.Errorf("email = %v, want %q", rec["email"], redactedMask)
+	}
+	if rec["order_id"] != "1234567890123456" {
+		t.Errorf("order_id = %v, want it kept", rec["order_id"])
+	}
+	if card := rec["items"].([]interface{})[0].(map[string]interface{})["card"]; card != redactedMask {
+		t.Errorf("nested card = %v, want %q", card, redactedMask)
+	}
+}
+
+func TestRedactKeepsTraceFields(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{
+		Trace:          DatadogTraceFields(),
+		RedactPatterns: []*regexp.Regexp{RedactCreditCards},
+	})
+	// The lower 64 bits of the trace ID, 1234567890123456785 in decimal,
+	// are 19 digits that pass the Luhn check.
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{8: 0x11, 9: 0x22, 10: 0x10, 11: 0xf4, 12: 0x7d, 13: 0xe9, 14: 0x81, 15: 0x11},
+		SpanID:  trace.SpanID{0: 0x11, 1: 0x22, 2: 0x10, 3: 0xf4, 4: 0x7d, 5: 0xe9, 6: 0x81, 7: 0x11},
+	})
+	ctx, _ := ContextWithCorrelationID(trace.ContextWithSpanContext(context.Background(), sc))
+	l.Ctx(ctx).Infow("m", "other_id", "1234567890123456785")
+
+	rec := p.last(t)
+	for _, key := range []string{"dd.trace_id", "dd.span_id"} {
+		if rec[key] != "1234567890123456785" {
+			t.Errorf("%s = %v, want it kept", key, rec[key])
+		}
+	}
+	if rec["other_id"] != redactedMask {
+		t.Errorf("other_id = %v, want the Luhn-valid number masked outside the trace fields", rec["other_id"])
+	}
+}
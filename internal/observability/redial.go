@@ -0,0 +1,75 @@
+package observability
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	defaultRedialWait    = 500 * time.Millisecond
+	defaultMaxRedialWait = time.Minute
+)
+
+// signalRedial wakes the redial worker, if there is one. It never blocks.
+func (f *FluentLogger) signalRedial() {
+	select {
+	case f.redial <- struct{}{}:
+	default:
+	}
+}
+
+// redialUnix recovers a transport on a unix socket network (unix or
+// unixgram) from its socket being removed and recreated, e.g. by a Fluent
+// Bit restart, which leaves the client writing to a dead socket. After the
+// first failed delivery it checks the socket path with backoff (starting
+// at RetryWait, capped at MaxRetryWait) and replaces the client once the
+// socket accepts connections again. It stops retrying as soon as a
+// delivery succeeds.
+//
+// The socket checked is that of the current client, so redials follow
+// Reconfigure; while the logger is reconfigured to another network, there
+// is nothing to redial.
+func (f *FluentLogger) redialUnix(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-f.redial:
+		}
+
+		cfg := f.currentEndpoint()
+		if !isUnixSocket(cfg.FluentNetwork) {
+			continue
+		}
+		backoff, maxWait := defaultRedialWait, defaultMaxRedialWait
+		if cfg.RetryWait > 0 {
+			backoff = time.Duration(cfg.RetryWait) * time.Millisecond
+		}
+		if cfg.MaxRetryWait > 0 {
+			maxWait = time.Duration(cfg.MaxRetryWait) * time.Millisecond
+		}
+		for f.down.Load() {
+			// Re-read, in case Reconfigure moved the logger meanwhile
+			if cfg = f.currentEndpoint(); !isUnixSocket(cfg.FluentNetwork) {
+				break
+			}
+			err := ping(ctx, cfg)
+			if err == nil {
+				err = f.recycle()
+			}
+			if err == nil {
+				f.self.info("unix socket redialed", "path", cfg.FluentSocketPath)
+				break
+			}
+
+			timer := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			backoff = min(backoff*2, maxWait)
+		}
+	}
+}
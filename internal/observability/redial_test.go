@@ -0,0 +1,116 @@
+package observability
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fluent/fluent-logger-golang/fluent"
+)
+
+// startUnixSink listens on a unix socket at path and discards whatever is
+// written to it. The returned function stops it and removes the socket.
+func startUnixSink(t *testing.T, path string) (stop func()) {
+	t.Helper()
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, _ = io.Copy(io.Discard, conn)
+				conn.Close()
+			}()
+		}
+	}()
+
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			ln.Close()
+			wg.Wait()
+			os.Remove(path)
+		})
+	}
+	t.Cleanup(stop)
+	return stop
+}
+
+func unixConfig(path string) fluent.Config {
+	return fluent.Config{FluentNetwork: "unix", FluentSocketPath: path, Timeout: time.Second, RetryWait: 10, MaxRetryWait: 50}
+}
+
+func TestRedialFollowsReconfigure(t *testing.T) {
+	dir := t.TempDir()
+	oldPath, newPath := filepath.Join(dir, "old.sock"), filepath.Join(dir, "new.sock")
+	stopOld := startUnixSink(t, oldPath)
+	startUnixSink(t, newPath)
+
+	l, err := NewSugaredLogger(&SugaredLoggerConfig{FluentConfig: unixConfig(oldPath)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if err := l.Reconfigure(unixConfig(newPath)); err != nil {
+		t.Fatalf("Reconfigure: %v", err)
+	}
+	stopOld()
+
+	if err := l.Ping(context.Background()); err != nil {
+		t.Errorf("Ping checks the old socket: %v", err)
+	}
+
+	before := l.LastRecycledAt()
+	l.fluent.down.Store(true)
+	l.fluent.signalRedial()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !l.LastRecycledAt().After(before) {
+		if time.Now().After(deadline) {
+			t.Fatal("redial did not reconnect to the reconfigured socket")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRedialRecoversRecreatedSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fluent.sock")
+	stop := startUnixSink(t, path)
+
+	l, err := NewSugaredLogger(&SugaredLoggerConfig{FluentConfig: unixConfig(path)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	before := l.LastRecycledAt()
+
+	stop()
+	l.fluent.down.Store(true)
+	l.fluent.signalRedial()
+	time.Sleep(50 * time.Millisecond)
+	if l.LastRecycledAt().After(before) {
+		t.Fatal("redialed while the socket was gone")
+	}
+
+	startUnixSink(t, path)
+	deadline := time.Now().Add(2 * time.Second)
+	for !l.LastRecycledAt().After(before) {
+		if time.Now().After(deadline) {
+			t.Fatal("redial did not reconnect once the socket was back")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
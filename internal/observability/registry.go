@@ -0,0 +1,108 @@
+package observability
+
+import (
+	"sync"
+
+	"github.com/fluent/fluent-logger-golang/fluent"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LoggerRegistry hands out named loggers that share one Fluent transport,
+// e.g. one per subsystem of a plugin architecture. Each named logger has
+// its own level, context fields and tag; delivery, buffering and the
+// transport are shared.
+type LoggerRegistry struct {
+	root *SugaredLogger
+
+	mu      sync.Mutex
+	loggers map[string]*SugaredLogger
+}
+
+// Option configures a logger handed out by a LoggerRegistry.
+type Option func(*loggerOptions)
+
+type loggerOptions struct {
+	tag    string
+	level  zapcore.Level
+	fields []interface{}
+}
+
+// WithLevel sets the minimum level of a named logger, as accepted by
+// LogLevel. It defaults to debug.
+func WithLevel(lvl string) Option {
+	return func(o *loggerOptions) { o.level = parseLogLevel(lvl) }
+}
+
+// WithFields adds context fields, as key-value pairs, to a named logger.
+func WithFields(kv ...interface{}) Option {
+	return func(o *loggerOptions) { o.fields = append(o.fields, kv...) }
+}
+
+// WithLoggerTag sets the tag a named logger posts under. It defaults to
+// <root tag>.<name>; an invalid tag is reported to the self-logger and the
+// default is kept.
+func WithLoggerTag(tag string) Option {
+	return func(o *loggerOptions) { o.tag = tag }
+}
+
+// NewRegistry returns a registry whose loggers post through fl. The
+// connection settings (endpoint, Async, Timeout, WriteTimeout) are those fl
+// was built with, and Reconnect rebuilds the client from them; the rest of
+// the configuration is the default. The registry owns fl: close it with
+// Close.
+func NewRegistry(fl *fluent.Fluent) *LoggerRegistry {
+	cfg := &SugaredLoggerConfig{Tag: defaultFluentTag, FluentConfig: fl.Config}
+	if cfg.FluentConfig.Timeout == 0 {
+		cfg.FluentConfig.Timeout = defaultShutdownTimeout
+	}
+	cfg.Trace = cfg.Trace.withDefaults()
+
+	root := newSugaredLogger(cfg, fl)
+	root.fluent.dial = newDialer(cfg, nil, cfg.FluentConfig.Async)
+	return &LoggerRegistry{
+		root:    root,
+		loggers: make(map[string]*SugaredLogger),
+	}
+}
+
+// Logger returns the logger registered under name, creating it with opts
+// on first use; later calls return the same logger and ignore opts. Its
+// entries carry name as the logger name. SetLevel on it changes only its
+// own level. Like derived loggers it shares, but does not own, the
+// transport, so closing it is a no-op.
+func (r *LoggerRegistry) Logger(name string, opts ...Option) *SugaredLogger {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if l, ok := r.loggers[name]; ok {
+		return l
+	}
+
+	o := loggerOptions{tag: r.root.Tag() + "." + name, level: defaultLogLevel}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := ValidateTag(o.tag); err != nil {
+		r.root.fluent.self.warn("invalid tag ignored", "logger", name, "tag", o.tag, "error", err)
+		o.tag = r.root.Tag() + "." + name
+	}
+
+	level := zap.NewAtomicLevelAt(o.level)
+	s := r.root.withCore(func(c *fluentCore) *fluentCore {
+		clone := c.withTag(o.tag)
+		clone.LevelEnabler = level
+		return clone
+	})
+	l := r.root.derive(s.Named(name).With(o.fields...))
+	l.level = &level
+
+	r.loggers[name] = l
+	return l
+}
+
+// Close flushes everything the named loggers wrote and closes the shared
+// transport. It is safe to call more than once.
+func (r *LoggerRegistry) Close() error {
+	return r.root.Close()
+}
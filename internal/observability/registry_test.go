@@ -0,0 +1,78 @@
+package observability
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fluent/fluent-logger-golang/fluent"
+	"github.com/niquet/go-fluentd-logger-poc/internal/observability/fluenttest"
+)
+
+func newTestRegistry(t *testing.T, cfg fluent.Config) (*LoggerRegistry, func() []fluenttest.Event) {
+	t.Helper()
+	addr, received := fluenttest.StartTestServer(t)
+	host, port, _ := net.SplitHostPort(addr)
+	cfg.FluentHost = host
+	cfg.FluentPort, _ = strconv.Atoi(port)
+
+	fl, err := fluent.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRegistry(fl)
+	t.Cleanup(func() { _ = r.Close() })
+	return r, received
+}
+
+func TestRegistryUsesClientSettings(t *testing.T) {
+	r, _ := newTestRegistry(t, fluent.Config{Async: true, Timeout: 7 * time.Second, WriteTimeout: 2 * time.Second})
+
+	f := r.root.fluent
+	if !f.async || f.timeout != 7*time.Second || f.writeTimeout != 2*time.Second {
+		t.Errorf("async %v, timeout %v, write timeout %v; want the client's settings", f.async, f.timeout, f.writeTimeout)
+	}
+	if f.dial == nil {
+		t.Error("registry logger cannot reconnect")
+	}
+}
+
+func TestRegistryLoggers(t *testing.T) {
+	r, received := newTestRegistry(t, fluent.Config{Async: true})
+	billing := r.Logger("billing", WithLevel("warn"), WithFields("team", "payments"))
+	search := r.Logger("search", WithLoggerTag("app.search"))
+	if r.Logger("billing") != billing {
+		t.Error("Logger returned a new logger for a registered name")
+	}
+
+	billing.Infow("below level")
+	billing.Warnw("charge failed")
+	search.Infow("query")
+	if err := billing.Close(); err != nil {
+		t.Fatalf("closing a named logger: %v", err)
+	}
+	// Flush drains the async client without closing it
+	if err := r.root.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(received()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	events := received()
+	if len(events) != 2 {
+		t.Fatalf("received %d events, want 2: %v", len(events), events)
+	}
+	byTag := map[string]fluenttest.Event{}
+	for _, ev := range events {
+		byTag[ev.Tag] = ev
+	}
+	if ev, ok := byTag[defaultFluentTag+".billing"]; !ok || ev.Record["team"] != "payments" || ev.Record["logger"] != "billing" {
+		t.Errorf("billing event = %+v", ev)
+	}
+	if _, ok := byTag["app.search"]; !ok {
+		t.Errorf("no event under app.search: %v", events)
+	}
+}
@@ -0,0 +1,60 @@
+package observability
+
+import (
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"time"
+)
+
+// writeRetryWait is the wait before the first WriteRetries retry; it
+// doubles with every further one.
+const writeRetryWait = 50 * time.Millisecond
+
+// sendRetrying is send to p, retrying writes that failed with a transient
+// error up to WriteRetries times. Only sync transports retry: an async
+// transport reports errors it can do nothing about but drop. Retries stop
+// early once the logger is closing.
+func (f *FluentLogger) sendRetrying(p poster, async bool, tag string, t time.Time, entry map[string]interface{}) error {
+	err := f.send(p, tag, t, entry)
+	if async {
+		return err
+	}
+
+	wait := writeRetryWait
+	for attempt := 1; attempt <= f.cfg.WriteRetries && isTransient(err); attempt++ {
+		f.self.warn("write failed, retrying", "tag", tag, "attempt", attempt, "wait", wait, "error", err)
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-f.lifetime.Done():
+			timer.Stop()
+			return err
+		}
+		wait *= 2
+		err = f.send(p, tag, t, entry)
+	}
+	return err
+}
+
+// isTransient reports whether err is a network error a later attempt may
+// not run into: a reset, refused or broken connection, or a timeout.
+// Anything else, such as a record the transport cannot encode, is
+// permanent.
+func isTransient(err error) bool {
+	if err == nil || errors.Is(err, ErrPostPanicked) {
+		return false
+	}
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, net.ErrClosed)
+}
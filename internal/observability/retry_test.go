@@ -0,0 +1,88 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func newFlakyLogger(t *testing.T, retries int, fails int32, err error) (*SugaredLogger, *flakyPoster) {
+	t.Helper()
+	l, _ := newTestLogger(t, &SugaredLoggerConfig{WriteRetries: retries})
+	p := &flakyPoster{fails: fails, err: err}
+	l.fluent.logger = p
+	return l, p
+}
+
+func TestWriteRetries(t *testing.T) {
+	reset := fmt.Errorf("write: %w", syscall.ECONNRESET)
+	tests := []struct {
+		name      string
+		fails     int32
+		err       error
+		attempts  int32
+		delivered bool
+	}{
+		{"recovers", 2, reset, 3, true},
+		{"gives up", 5, reset, 3, false},
+		{"permanent", 1, errors.New("msgpack: unsupported type"), 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, p := newFlakyLogger(t, 2, tt.fails, tt.err)
+			l.Infow("m")
+
+			if n := p.attempts.Load(); n != tt.attempts {
+				t.Errorf("%d attempts, want %d", n, tt.attempts)
+			}
+			if got := len(p.records()) == 1; got != tt.delivered {
+				t.Errorf("delivered = %v, want %v", got, tt.delivered)
+			}
+		})
+	}
+}
+
+func TestWriteRetriesStopOnClose(t *testing.T) {
+	l, p := newFlakyLogger(t, 10, 100, syscall.ECONNREFUSED)
+	// Closing stops the background workers first, then the last writes
+	// go through.
+	l.fluent.stop()
+
+	start := time.Now()
+	l.Infow("m")
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("write kept retrying for %v after the logger closed", d)
+	}
+	if n := p.attempts.Load(); n != 1 {
+		t.Errorf("%d attempts, want 1", n)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{&net.OpError{Op: "write", Err: syscall.ECONNRESET}, true},
+		{syscall.ECONNREFUSED, true},
+		{syscall.EPIPE, true},
+		{io.EOF, true},
+		{net.ErrClosed, true},
+		{os.ErrDeadlineExceeded, true},
+		{context.Canceled, false},
+		{ErrPostPanicked, false},
+		{errors.New("msgpack: unsupported type"), false},
+	}
+	for _, tt := range tests {
+		if got := isTransient(tt.err); got != tt.want {
+			t.Errorf("isTransient(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package observability
+
+import (
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultSamplingTick = time.Second
+	samplerSlots        = 1024
+	samplerLevels       = int(zapcore.FatalLevel-zapcore.DebugLevel) + 1
+)
+
+// SamplingConfig limits the volume of repetitive entries. Within each Tick,
+// the first Initial entries with a given level and message are logged, then
+// every Thereafter-th one; the others are dropped. Thereafter zero drops all
+// of them after Initial.
+//
+// If Levels is set, each level listed in it is sampled by its own rule and
+// unlisted levels are not sampled at all; Initial and Thereafter are then
+// ignored. For example, to sample debug heavily, info lightly, and never
+// warnings or errors:
+//
+//	Levels: map[zapcore.Level]SamplingRule{
+//		zapcore.DebugLevel: {Initial: 10, Thereafter: 1000},
+//		zapcore.InfoLevel:  {Initial: 100, Thereafter: 10},
+//	}
+type SamplingConfig struct {
+	Tick       time.Duration
+	Initial    int
+	Thereafter int
+	Levels     map[zapcore.Level]SamplingRule
+}
+
+// SamplingRule is the sampling of one level in SamplingConfig.Levels.
+type SamplingRule struct {
+	Initial    int
+	Thereafter int
+}
+
+// sampler implements SamplingConfig for fluentCore, like zap's sampler but
+// shared by every logger derived from the same root, and with a switch to
+// suspend sampling. Messages are bucketed by hash, so distinct messages may
+// share a counter.
+//
+// A nil *sampler logs everything.
+type sampler struct {
+	tick time.Duration
+	// keyFields are the SampleKeyFields entries are bucketed by; entries
+	// are then sampled in Write rather than Check.
+	keyFields []string
+	// rules holds the rule of each level; nil for levels not sampled.
+	rules [samplerLevels]*sampleRule
+
+	suspended atomic.Bool
+	counts    [samplerLevels][samplerSlots]sampleCounter
+}
+
+type sampleRule struct {
+	first      uint64
+	thereafter uint64
+}
+
+type sampleCounter struct {
+	resetAt atomic.Int64
+	n       atomic.Uint64
+}
+
+func newSampler(cfg *SamplingConfig, keyFields []string) *sampler {
+	if cfg == nil {
+		return nil
+	}
+	tick := cfg.Tick
+	if tick <= 0 {
+		tick = defaultSamplingTick
+	}
+	s := &sampler{tick: tick, keyFields: keyFields}
+	for i := range s.rules {
+		lvl := zapcore.DebugLevel + zapcore.Level(i)
+		if cfg.Levels == nil {
+			s.rules[i] = &sampleRule{first: uint64(cfg.Initial), thereafter: uint64(cfg.Thereafter)}
+		} else if r, ok := cfg.Levels[lvl]; ok {
+			s.rules[i] = &sampleRule{first: uint64(r.Initial), thereafter: uint64(r.Thereafter)}
+		}
+	}
+	return s
+}
+
+// keyed reports whether entries are sampled by SampleKeyFields, i.e. in
+// Write with their fields rather than in Check.
+func (s *sampler) keyed() bool {
+	return s != nil && len(s.keyFields) > 0
+}
+
+// sample reports whether ent is to be logged, bucketing it by its message.
+func (s *sampler) sample(ent zapcore.Entry) bool {
+	return s.sampleBy(ent, func(h hash.Hash32) {
+		h.Write([]byte(ent.Message))
+	})
+}
+
+// sampleFields reports whether ent is to be logged, bucketing it by the
+// values of the SampleKeyFields among fields, in order: context fields,
+// then call-site fields, where a later field of the same key wins. Entries
+// carrying none of them are bucketed by their message.
+func (s *sampler) sampleFields(ent zapcore.Entry, fields ...[]zapcore.Field) bool {
+	values := make([]interface{}, len(s.keyFields))
+	found := false
+	for _, fs := range fields {
+		for _, f := range fs {
+			for i, key := range s.keyFields {
+				if f.Key != key {
+					continue
+				}
+				if v, ok := fieldValue(f); ok {
+					values[i], found = v, true
+				}
+			}
+		}
+	}
+	if !found {
+		return s.sample(ent)
+	}
+	return s.sampleBy(ent, func(h hash.Hash32) {
+		for _, v := range values {
+			fmt.Fprint(h, v)
+			h.Write([]byte{0})
+		}
+	})
+}
+
+// sampleBy reports whether ent is to be logged, counting it in the bucket
+// of the hash that key writes.
+func (s *sampler) sampleBy(ent zapcore.Entry, key func(hash.Hash32)) bool {
+	if s == nil || s.suspended.Load() {
+		return true
+	}
+	if ent.Level < zapcore.DebugLevel || ent.Level > zapcore.FatalLevel {
+		return true
+	}
+
+	i := ent.Level - zapcore.DebugLevel
+	r := s.rules[i]
+	if r == nil {
+		return true
+	}
+
+	h := fnv.New32a()
+	key(h)
+	c := &s.counts[i][h.Sum32()%samplerSlots]
+
+	n := c.inc(ent.Time, s.tick)
+	return n <= r.first || r.thereafter != 0 && (n-r.first)%r.thereafter == 0
+}
+
+// inc counts an entry at t and returns the count within the current tick.
+func (c *sampleCounter) inc(t time.Time, tick time.Duration) uint64 {
+	tn := t.UnixNano()
+	resetAt := c.resetAt.Load()
+	if resetAt > tn {
+		return c.n.Add(1)
+	}
+
+	c.n.Store(1)
+	if !c.resetAt.CompareAndSwap(resetAt, tn+tick.Nanoseconds()) {
+		// Another goroutine reset the counter too; count on top of it.
+		return c.n.Add(1)
+	}
+	return 1
+}
@@ -0,0 +1,74 @@
+package observability
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSamplingPerLevel(t *testing.T) {
+	cfg := &SamplingConfig{
+		Tick: time.Hour,
+		Levels: map[zapcore.Level]SamplingRule{
+			zapcore.DebugLevel: {Initial: 1, Thereafter: 0},
+			zapcore.InfoLevel:  {Initial: 2, Thereafter: 3},
+		},
+	}
+	tests := []struct {
+		level zapcore.Level
+		want  int // of 10 identical entries
+	}{
+		{zapcore.DebugLevel, 1},
+		{zapcore.InfoLevel, 4}, // 1, 2, 5, 8
+		{zapcore.WarnLevel, 10},
+		{zapcore.ErrorLevel, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			l, p := newTestLogger(t, &SugaredLoggerConfig{LogLevel: "debug", Sampling: cfg})
+			for i := 0; i < 10; i++ {
+				l.Logw(tt.level, "repeated")
+			}
+			if got := len(p.records()); got != tt.want {
+				t.Errorf("%d of 10 entries logged, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSamplingLevelsAreIndependent(t *testing.T) {
+	cfg := &SamplingConfig{Tick: time.Hour, Initial: 1}
+	l, p := newTestLogger(t, &SugaredLoggerConfig{LogLevel: "debug", Sampling: cfg})
+	for i := 0; i < 3; i++ {
+		l.Debugw("repeated")
+		l.Infow("repeated")
+		l.Infow("other")
+	}
+	if got := len(p.records()); got != 3 {
+		t.Errorf("%d entries logged, want one per level and message", got)
+	}
+}
+
+func TestSamplingTickResets(t *testing.T) {
+	s := newSampler(&SamplingConfig{Tick: time.Second, Initial: 1}, nil)
+	start := time.Now()
+	ent := zapcore.Entry{Level: zapcore.InfoLevel, Message: "m", Time: start}
+	if !s.sample(ent) {
+		t.Fatal("first entry dropped")
+	}
+	if s.sample(ent) {
+		t.Fatal("second entry in the tick logged")
+	}
+	ent.Time = start.Add(2 * time.Second)
+	if !s.sample(ent) {
+		t.Error("first entry of the next tick dropped")
+	}
+}
+
+func TestNilSamplerLogsEverything(t *testing.T) {
+	var s *sampler
+	if !s.sample(zapcore.Entry{Level: zapcore.InfoLevel}) {
+		t.Error("nil sampler dropped an entry")
+	}
+}
@@ -0,0 +1,72 @@
+package observability
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// unencodableFieldsKey lists the fields SanitizeFields replaced.
+const unencodableFieldsKey = "_unencodable_fields"
+
+// sanitize replaces the values of entry, nested ones included, that
+// neither msgpack nor JSON can encode with a string: <chan int>, <func()>
+// and the like for channels and funcs, the %+v form for others, and lists
+// their paths (dot-separated keys and indexes) under _unencodable_fields.
+// Values of other types the transport may not handle, such as structs and
+// typed maps and slices, are converted to plain maps and slices through
+// their JSON form.
+func sanitize(entry map[string]interface{}) {
+	var bad []string
+	for k, v := range entry {
+		entry[k] = sanitizeValue(v, k, &bad)
+	}
+	if len(bad) == 0 {
+		return
+	}
+	sort.Strings(bad)
+	list := make([]interface{}, len(bad))
+	for i, p := range bad {
+		list[i] = p
+	}
+	entry[unencodableFieldsKey] = list
+}
+
+func sanitizeValue(v interface{}, path string, bad *[]string) interface{} {
+	switch v := v.(type) {
+	case nil, bool, string, []byte,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64, json.Number,
+		time.Time, time.Duration, msgp.Marshaler:
+		return v
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = sanitizeValue(e, path+"."+k, bad)
+		}
+		return v
+	case []interface{}:
+		for i, e := range v {
+			v[i] = sanitizeValue(e, path+"."+strconv.Itoa(i), bad)
+		}
+		return v
+	}
+
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		// Their %v form is a bare address.
+		*bad = append(*bad, path)
+		return fmt.Sprintf("<%T>", v)
+	}
+	plain, err := reflectedValue(v)
+	if err != nil {
+		*bad = append(*bad, path)
+		return fmt.Sprintf("%+v", v)
+	}
+	return plain
+}
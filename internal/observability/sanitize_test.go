@@ -0,0 +1,51 @@
+package observability
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tinylib/msgp/msgp"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSanitizeFields(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{SanitizeFields: true})
+	err := l.Event(zapcore.InfoLevel, "", map[string]interface{}{
+		"ch":   make(chan int),
+		"fn":   func() {},
+		"ok":   "kept",
+		"meta": map[string]interface{}{"done": make(chan struct{}), "list": []interface{}{1, func(int) error { return nil }}},
+		"pair": struct{ A, B int }{1, 2},
+	})
+	if err != nil {
+		t.Fatalf("Event: %v", err)
+	}
+
+	rec := p.last(t)
+	if rec["ch"] != "<chan int>" || rec["fn"] != "<func()>" || rec["ok"] != "kept" {
+		t.Errorf("record = %v, want the channel and func stringified", rec)
+	}
+	meta := rec["meta"].(map[string]interface{})
+	if meta["done"] != "<chan struct {}>" || meta["list"].([]interface{})[1] != "<func(int) error>" {
+		t.Errorf("meta = %v, want nested values stringified", meta)
+	}
+	if want := map[string]interface{}{"A": int64(1), "B": int64(2)}; !reflect.DeepEqual(rec["pair"], want) {
+		t.Errorf("pair = %#v, want a plain map", rec["pair"])
+	}
+	want := []interface{}{"ch", "fn", "meta.done", "meta.list.1"}
+	if got := rec[unencodableFieldsKey]; !reflect.DeepEqual(got, want) {
+		t.Errorf("%s = %v, want %v", unencodableFieldsKey, got, want)
+	}
+
+	if _, err := msgp.AppendIntf(nil, rec); err != nil {
+		t.Errorf("sanitized record does not encode: %v", err)
+	}
+}
+
+func TestSanitizeFieldsCleanRecord(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{SanitizeFields: true})
+	l.Infow("m", "n", 1)
+	if _, ok := p.last(t)[unencodableFieldsKey]; ok {
+		t.Error("record without unencodable values has the marker")
+	}
+}
@@ -0,0 +1,120 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const minutesPerDay = 24 * 60
+
+// LevelWindow is an entry of SugaredLoggerConfig.LevelSchedule: from Start
+// to End (wall-clock "HH:MM", End exclusive) the level is Level. A window
+// whose End is before its Start spans midnight.
+type LevelWindow struct {
+	Start, End string
+	Level      zapcore.Level
+}
+
+// levelSchedule is a validated LevelSchedule: owner maps each minute of the
+// day to the index of the window covering it, or -1.
+type levelSchedule struct {
+	windows []LevelWindow
+	base    zapcore.Level
+	loc     *time.Location
+	owner   [minutesPerDay]int
+}
+
+// newLevelSchedule validates windows: times must be HH:MM, windows may not
+// be empty and may not overlap.
+func newLevelSchedule(windows []LevelWindow, base zapcore.Level, loc *time.Location) (*levelSchedule, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	s := &levelSchedule{windows: windows, base: base, loc: loc}
+	for m := range s.owner {
+		s.owner[m] = -1
+	}
+
+	for i, w := range windows {
+		start, err := parseClock(w.Start)
+		if err != nil {
+			return nil, fmt.Errorf("level window %d: %w", i, err)
+		}
+		end, err := parseClock(w.End)
+		if err != nil {
+			return nil, fmt.Errorf("level window %d: %w", i, err)
+		}
+		if start == end {
+			return nil, fmt.Errorf("level window %d: empty window %s-%s", i, w.Start, w.End)
+		}
+		for m := start; m != end; m = (m + 1) % minutesPerDay {
+			if j := s.owner[m]; j >= 0 {
+				return nil, fmt.Errorf("level window %d overlaps window %d", i, j)
+			}
+			s.owner[m] = i
+		}
+	}
+	return s, nil
+}
+
+// parseClock parses "HH:MM" into minutes since midnight.
+func parseClock(v string) (int, error) {
+	t, err := time.Parse("15:04", v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", v)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func minuteOfDay(t time.Time) int {
+	return t.Hour()*60 + t.Minute()
+}
+
+// at returns the level in effect at t.
+func (s *levelSchedule) at(t time.Time) zapcore.Level {
+	if i := s.owner[minuteOfDay(t.In(s.loc))]; i >= 0 {
+		return s.windows[i].Level
+	}
+	return s.base
+}
+
+// next returns the start of the first minute after t at which the window in
+// effect changes.
+func (s *levelSchedule) next(t time.Time) time.Time {
+	t = t.In(s.loc)
+	m := minuteOfDay(t)
+	start := t.Truncate(time.Minute)
+	for k := 1; k < minutesPerDay; k++ {
+		if s.owner[(m+k)%minutesPerDay] != s.owner[m] {
+			return start.Add(time.Duration(k) * time.Minute)
+		}
+	}
+	return start.Add(minutesPerDay * time.Minute)
+}
+
+// followSchedule applies the level of the schedule when it starts and on
+// every transition, until ctx is done. Between transitions the level may
+// be changed by SetLevel or BoostVerbosity.
+func (l *SugaredLogger) followSchedule(ctx context.Context, s *levelSchedule) {
+	applied := false
+	var last zapcore.Level
+	for {
+		now := time.Now()
+		if lvl := s.at(now); !applied || lvl != last {
+			l.fluent.self.info("scheduled level applied", "level", lvl)
+			l.SetLevel(lvl)
+			applied, last = true, lvl
+		}
+
+		timer := time.NewTimer(time.Until(s.next(now)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
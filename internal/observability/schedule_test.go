@@ -0,0 +1,76 @@
+package observability
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fluent/fluent-logger-golang/fluent"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLevelScheduleValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		windows []LevelWindow
+		err     string
+	}{
+		{"adjacent", []LevelWindow{{Start: "22:00", End: "06:00"}, {Start: "06:00", End: "09:00"}}, ""},
+		{"overlap", []LevelWindow{{Start: "08:00", End: "12:00"}, {Start: "11:59", End: "13:00"}}, "window 1 overlaps window 0"},
+		{"overlap across midnight", []LevelWindow{{Start: "22:00", End: "06:00"}, {Start: "05:00", End: "07:00"}}, "window 1 overlaps window 0"},
+		{"contained", []LevelWindow{{Start: "01:00", End: "02:00"}, {Start: "23:00", End: "03:00"}}, "window 1 overlaps window 0"},
+		{"empty", []LevelWindow{{Start: "10:00", End: "10:00"}}, "empty window"},
+		{"invalid time", []LevelWindow{{Start: "25:00", End: "10:00"}}, "invalid time"},
+	}
+	for _, tt := range tests {
+		_, err := newLevelSchedule(tt.windows, zapcore.InfoLevel, time.UTC)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%s: error %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestLevelScheduleAt(t *testing.T) {
+	s, err := newLevelSchedule([]LevelWindow{
+		{Start: "22:00", End: "06:00", Level: zapcore.WarnLevel},
+		{Start: "12:00", End: "13:00", Level: zapcore.DebugLevel},
+	}, zapcore.InfoLevel, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		clock string
+		level zapcore.Level
+		next  string
+	}{
+		{"21:59", zapcore.InfoLevel, "22:00"},
+		{"22:00", zapcore.WarnLevel, "06:00"},
+		{"03:30", zapcore.WarnLevel, "06:00"},
+		{"06:00", zapcore.InfoLevel, "12:00"},
+		{"12:59", zapcore.DebugLevel, "13:00"},
+	}
+	for _, tt := range tests {
+		c, _ := time.Parse("15:04", tt.clock)
+		now := day.Add(time.Duration(c.Hour())*time.Hour + time.Duration(c.Minute())*time.Minute + 30*time.Second)
+		if lvl := s.at(now); lvl != tt.level {
+			t.Errorf("at %s: %v, want %v", tt.clock, lvl, tt.level)
+		}
+		if next := s.next(now).Format("15:04"); next != tt.next {
+			t.Errorf("next after %s: %s, want %s", tt.clock, next, tt.next)
+		}
+	}
+}
+
+func TestNewSugaredLoggerRejectsOverlappingSchedule(t *testing.T) {
+	_, err := NewSugaredLogger(&SugaredLoggerConfig{
+		LevelSchedule: []LevelWindow{{Start: "08:00", End: "12:00"}, {Start: "10:00", End: "11:00"}},
+		FluentConfig:  fluent.Config{Async: true},
+	})
+	if err == nil || !strings.Contains(err.Error(), "overlaps") {
+		t.Errorf("NewSugaredLogger = %v, want an overlap error", err)
+	}
+}
@@ -0,0 +1,36 @@
+package observability
+
+import "fmt"
+
+// SchemaValidator checks records against one version of the record schema
+// consumers expect; see SugaredLoggerConfig.SchemaValidator.
+type SchemaValidator interface {
+	// Version is the schema version the validator checks against.
+	Version() string
+	// Validate returns an error describing why record, as it is about to
+	// be posted, does not conform to the schema. It must not modify
+	// record.
+	Validate(record map[string]interface{}) error
+}
+
+// RequiredFields is a SchemaValidator for schemas that only demand the
+// presence of some fields.
+type RequiredFields struct {
+	// SchemaVersion is returned by Version.
+	SchemaVersion string
+	// Fields are the keys every record must carry.
+	Fields []string
+}
+
+// Version implements SchemaValidator.
+func (r RequiredFields) Version() string { return r.SchemaVersion }
+
+// Validate implements SchemaValidator.
+func (r RequiredFields) Validate(record map[string]interface{}) error {
+	for _, k := range r.Fields {
+		if _, ok := record[k]; !ok {
+			return fmt.Errorf("missing field %q", k)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package observability
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fluent/fluent-logger-golang/fluent"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestNewSugaredLoggerSchemaVersionConsistency(t *testing.T) {
+	tests := []struct {
+		version   string
+		validator SchemaValidator
+		ok        bool
+	}{
+		{"2", nil, true},
+		{"2", RequiredFields{SchemaVersion: "2"}, true},
+		{"1", RequiredFields{SchemaVersion: "2"}, false},
+		{"", RequiredFields{SchemaVersion: "2"}, false},
+	}
+	for _, tt := range tests {
+		l, err := NewSugaredLogger(&SugaredLoggerConfig{
+			SchemaVersion:   tt.version,
+			SchemaValidator: tt.validator,
+			FluentConfig:    fluent.Config{Async: true},
+		})
+		if err == nil {
+			l.Close()
+		}
+		if (err == nil) != tt.ok {
+			t.Errorf("SchemaVersion %q, validator %v: err = %v, want ok = %v", tt.version, tt.validator, err, tt.ok)
+		}
+	}
+}
+
+func TestSchemaValidator(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{
+		SchemaVersion:   "2",
+		SchemaValidator: RequiredFields{SchemaVersion: "2", Fields: []string{"message", "user"}},
+	})
+	l.Infow("valid", "user", "ada")
+	err := l.Event(zapcore.InfoLevel, "", map[string]interface{}{"message": "invalid"})
+	if !errors.Is(err, ErrSchemaViolation) || !strings.Contains(err.Error(), `"user"`) {
+		t.Errorf("Event = %v, want a schema violation naming the field", err)
+	}
+
+	recs := p.records()
+	if len(recs) != 1 || recs[0]["message"] != "valid" {
+		t.Fatalf("records = %v, want only the valid one", recs)
+	}
+	if recs[0][schemaVersionKey] != "2" {
+		t.Errorf("%s = %v, want 2", schemaVersionKey, recs[0][schemaVersionKey])
+	}
+	if s := l.Stats(); s.Dropped != 1 {
+		t.Errorf("Dropped = %d, want the invalid record", s.Dropped)
+	}
+}
+
+func TestSchemaVersionTraceability(t *testing.T) {
+	cfg := &SugaredLoggerConfig{SchemaVersion: "2", CloseMarker: true}
+	l, p := newTestLogger(t, cfg)
+	var self bytes.Buffer
+	cfg.InternalDebug = true
+	l.fluent.self = newSelfLogger(cfg)
+	l.fluent.self.w = &self
+
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.last(t)[schemaVersionKey]; got != "2" {
+		t.Errorf("close marker %s = %v, want 2", schemaVersionKey, got)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(self.String()), "\n") {
+		if !strings.Contains(line, `schema_version="2"`) {
+			t.Errorf("self-log line %q lacks the schema version", line)
+		}
+	}
+}
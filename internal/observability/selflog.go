@@ -0,0 +1,60 @@
+package observability
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// selfLogger reports the package's own lifecycle events (connect, delivery
+// failures, flush, close) on stderr. It never touches the Fluent transport,
+// so it keeps working when the transport is what is being diagnosed.
+//
+// A nil *selfLogger discards everything.
+type selfLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+	// kv is appended to every line, e.g. the SchemaVersion.
+	kv []interface{}
+}
+
+func newSelfLogger(cfg *SugaredLoggerConfig) *selfLogger {
+	if !cfg.InternalDebug {
+		return nil
+	}
+	s := &selfLogger{w: os.Stderr}
+	if cfg.SchemaVersion != "" {
+		s.kv = []interface{}{schemaVersionKey, cfg.SchemaVersion}
+	}
+	return s
+}
+
+func (s *selfLogger) info(msg string, kv ...interface{}) { s.log("INFO", msg, kv) }
+func (s *selfLogger) warn(msg string, kv ...interface{}) { s.log("WARN", msg, kv) }
+
+// log writes one line: timestamp, level, message and key=value pairs.
+func (s *selfLogger) log(level, msg string, kv []interface{}) {
+	if s == nil {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(time.Now().UTC().Format(time.RFC3339Nano))
+	b.WriteString(" fluentlogger ")
+	b.WriteString(level)
+	b.WriteByte(' ')
+	b.WriteString(msg)
+	for _, pairs := range [][]interface{}{kv, s.kv} {
+		for i := 0; i+1 < len(pairs); i += 2 {
+			fmt.Fprintf(&b, " %v=%q", pairs[i], fmt.Sprint(pairs[i+1]))
+		}
+	}
+	b.WriteByte('\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, _ = io.WriteString(s.w, b.String())
+}
@@ -0,0 +1,91 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Serializer builds the message posted for entries of one event type. It
+// is an advanced optimization hook for hot, fixed-shape events: an entry
+// whose EventTypeKey field is a string naming a registered Serializer is
+// handed to it with its context and call-site fields, and whatever it
+// returns (ideally a type implementing msgp.Marshaler) is posted as is.
+//
+// This bypasses the generic path entirely: the standard keys, DebugField,
+// ValueTransformer, SetEncoder and the record-level options (OmitEmpty,
+// MaxFields, EntryMarshaler, Dialect, OrderedFields) do not apply, and the
+// offline buffer does not hold these messages; undeliverable ones go
+// straight to the Fallback. Entries of other types take the generic path.
+type Serializer func(ent zapcore.Entry, fields []zapcore.Field) interface{}
+
+// serializer returns the Serializer registered for the event type of an
+// entry, if any. Call-site fields take precedence over context fields.
+func (c *fluentCore) serializer(fields []zapcore.Field) (Serializer, bool) {
+	if len(c.cfg.Serializers) == 0 || c.cfg.EventTypeKey == "" {
+		return nil, false
+	}
+	for _, fs := range [][]zapcore.Field{fields, c.fields} {
+		for i := len(fs) - 1; i >= 0; i-- {
+			if f := fs[i]; f.Key == c.cfg.EventTypeKey && f.Type == zapcore.StringType {
+				s, ok := c.cfg.Serializers[f.String]
+				return s, ok
+			}
+		}
+	}
+	return nil, false
+}
+
+// writeSerialized posts the message s builds for ent.
+func (c *fluentCore) writeSerialized(s Serializer, ent zapcore.Entry, fields []zapcore.Field) error {
+	base := c.out.baseFields()
+	all := make([]zapcore.Field, 0, len(base)+len(c.fields)+len(fields))
+	all = append(append(append(all, base...), c.fields...), fields...)
+	return c.out.postMessage(c.ctx, c.tag, ent.Time, s(ent, all))
+}
+
+// postMessage is post for messages built by a Serializer. Only the breaker,
+// the deadline of ctx and the fallback sink apply, and Pause, under which
+// messages are dropped whatever the PausePolicy.
+func (f *FluentLogger) postMessage(ctx context.Context, tag string, t time.Time, msg interface{}) error {
+	f.lockWrite()
+	defer f.unlockWrite()
+
+	if f.closed.Load() {
+		f.drop(tag)
+		return ErrLoggerClosed
+	}
+	if f.paused.Load() {
+		f.drop(tag)
+		return nil
+	}
+	if !f.breaker.allow() {
+		if !f.divert(tag, t, msg) {
+			return ErrCircuitOpen
+		}
+		return nil
+	}
+
+	var err error
+	if deadline, ok := ctx.Deadline(); ok && !f.async {
+		err = f.postBefore(f.logger, deadline, tag, t, msg)
+	} else {
+		err = f.sendMessage(f.logger, tag, t, msg)
+	}
+	f.breaker.record(err == nil)
+	if errors.Is(err, ErrWriteAbandoned) {
+		f.drop(tag)
+		return err
+	}
+	f.observe(tag, err)
+	if err != nil {
+		if f.divert(tag, t, msg) {
+			return nil
+		}
+		return fmt.Errorf("log delivery failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package observability
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tinylib/msgp/msgp"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// loginEvent is the fixed-shape message of the serializer under test.
+type loginEvent struct {
+	user string
+	ok   bool
+}
+
+func (e loginEvent) MarshalMsg(b []byte) ([]byte, error) {
+	b = msgp.AppendMapHeader(b, 2)
+	b = msgp.AppendString(b, "user")
+	b = msgp.AppendString(b, e.user)
+	b = msgp.AppendString(b, "ok")
+	return msgp.AppendBool(b, e.ok), nil
+}
+
+func serializeLogin(_ zapcore.Entry, fields []zapcore.Field) interface{} {
+	var e loginEvent
+	for _, f := range fields {
+		switch f.Key {
+		case "user":
+			e.user = f.String
+		case "ok":
+			e.ok = f.Integer == 1
+		}
+	}
+	return e
+}
+
+func serializerConfig() *SugaredLoggerConfig {
+	return &SugaredLoggerConfig{
+		EventTypeKey: "event",
+		Serializers:  map[string]Serializer{"login": serializeLogin},
+	}
+}
+
+func TestSerializer(t *testing.T) {
+	l, p := newTestLogger(t, serializerConfig())
+	l.Infow("m", "event", "login", "user", "ada", "ok", true)
+	l.With("event", "login").Infow("m", "user", "bob")
+	l.Infow("m", "event", "logout", "user", "ada")
+
+	p.mu.Lock()
+	messages := append([]interface{}(nil), p.messages...)
+	p.mu.Unlock()
+	if len(messages) != 3 {
+		t.Fatalf("got %d messages, want 3", len(messages))
+	}
+	if got, want := messages[0], (loginEvent{user: "ada", ok: true}); got != want {
+		t.Errorf("call-site event type: posted %#v, want %#v", got, want)
+	}
+	if got, want := messages[1], (loginEvent{user: "bob"}); got != want {
+		t.Errorf("context event type: posted %#v, want %#v", got, want)
+	}
+	if rec, ok := messages[2].(map[string]interface{}); !ok || rec["event"] != "logout" || rec["message"] != "m" {
+		t.Errorf("unregistered event type: posted %#v, want a generic record", messages[2])
+	}
+}
+
+func BenchmarkSerializer(b *testing.B) {
+	ent := zapcore.Entry{Level: zapcore.InfoLevel, Time: time.Now(), Message: "m"}
+	fields := []zapcore.Field{zap.String("event", "login"), zap.String("user", "ada"), zap.Bool("ok", true)}
+
+	b.Run("serializer", func(b *testing.B) {
+		l := newBenchLogger(b, serializerConfig())
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = l.core.Write(ent, fields)
+		}
+	})
+	b.Run("generic", func(b *testing.B) {
+		l := newBenchLogger(b, nil)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = l.core.Write(ent, fields)
+		}
+	})
+}
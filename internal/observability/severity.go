@@ -0,0 +1,45 @@
+package observability
+
+import "go.uber.org/zap/zapcore"
+
+// SeverityScheme selects the numbers written to SeverityNumberKey:
+//
+//	level   OTel  Syslog
+//	debug      5       7
+//	info       9       6
+//	warn      13       4
+//	error     17       3
+//	dpanic    18       2
+//	panic     19       1
+//	fatal     21       0
+//
+// OTel numbers follow the OpenTelemetry log data model (1-24, higher is more
+// severe); syslog numbers follow RFC 5424 (0-7, lower is more severe).
+type SeverityScheme int
+
+const (
+	SeverityOTel SeverityScheme = iota
+	SeveritySyslog
+)
+
+var severityNumbers = map[SeverityScheme][]int{
+	//              debug info warn error dpanic panic fatal
+	SeverityOTel:   {5, 9, 13, 17, 18, 19, 21},
+	SeveritySyslog: {7, 6, 4, 3, 2, 1, 0},
+}
+
+// number returns the severity number of lvl in the scheme.
+func (s SeverityScheme) number(lvl zapcore.Level) int {
+	numbers, ok := severityNumbers[s]
+	if !ok {
+		numbers = severityNumbers[SeverityOTel]
+	}
+	i := int(lvl - zapcore.DebugLevel)
+	switch {
+	case i < 0:
+		i = 0
+	case i >= len(numbers):
+		i = len(numbers) - 1
+	}
+	return numbers[i]
+}
@@ -0,0 +1,76 @@
+package observability
+
+import (
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSeverityNumber(t *testing.T) {
+	tests := []struct {
+		level        zapcore.Level
+		otel, syslog int
+	}{
+		{zapcore.DebugLevel, 5, 7},
+		{zapcore.InfoLevel, 9, 6},
+		{zapcore.WarnLevel, 13, 4},
+		{zapcore.ErrorLevel, 17, 3},
+		{zapcore.DPanicLevel, 18, 2},
+		{zapcore.PanicLevel, 19, 1},
+		{zapcore.FatalLevel, 21, 0},
+		{zapcore.DebugLevel - 1, 5, 7},
+	}
+	for _, tt := range tests {
+		if got := SeverityOTel.number(tt.level); got != tt.otel {
+			t.Errorf("OTel %v = %d, want %d", tt.level, got, tt.otel)
+		}
+		if got := SeveritySyslog.number(tt.level); got != tt.syslog {
+			t.Errorf("Syslog %v = %d, want %d", tt.level, got, tt.syslog)
+		}
+	}
+}
+
+func TestSeverityNumberKey(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{SeverityNumberKey: "severity_number", SeverityScheme: SeveritySyslog})
+	l.Warnw("m")
+
+	rec := p.last(t)
+	if rec["severity_number"] != 4 {
+		t.Errorf("severity_number = %#v, want 4", rec["severity_number"])
+	}
+	if rec["severity"] != "warn" {
+		t.Errorf("severity = %#v, want warn", rec["severity"])
+	}
+}
+
+func TestSeverityNumberKeyUnset(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+	l.Warnw("m")
+	if _, ok := p.last(t)["severity_number"]; ok {
+		t.Error("severity_number written without SeverityNumberKey")
+	}
+}
+
+func TestUppercaseLevel(t *testing.T) {
+	tests := []struct {
+		upper bool
+		enc   *zapcore.EncoderConfig
+		want  string
+	}{
+		{false, nil, "error"},
+		{true, nil, "ERROR"},
+		{true, func() *zapcore.EncoderConfig { c := ECSEncoderConfig(); return &c }(), "ERROR"},
+	}
+	for _, tt := range tests {
+		l, p := newTestLogger(t, &SugaredLoggerConfig{UppercaseLevel: tt.upper, EncoderConfig: tt.enc})
+		l.Errorw("m")
+
+		key := "severity"
+		if tt.enc != nil {
+			key = tt.enc.LevelKey
+		}
+		if got := p.last(t)[key]; got != tt.want {
+			t.Errorf("UppercaseLevel=%v, encoder config %v: %s = %v, want %q", tt.upper, tt.enc != nil, key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package observability
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync/atomic"
+	"time"
+
+	"github.com/fluent/fluent-logger-golang/fluent"
+)
+
+// shardedPoster routes each record to one of several transports by a hash
+// of its ShardKeyField, so that the records of a tenant always land on the
+// same cluster. Routing uses rendezvous hashing: adding or removing a
+// shard only moves the keys of that shard.
+type shardedPoster struct {
+	key     string
+	members []*shardMember
+}
+
+type shardMember struct {
+	poster
+	address   string
+	down      atomic.Bool
+	failures  atomic.Uint64
+	delivered atomic.Uint64
+}
+
+// ShardHealth describes one shard configured by Shards.
+type ShardHealth struct {
+	Index     int    `json:"index"`
+	Address   string `json:"address"`
+	Healthy   bool   `json:"healthy"`
+	Errors    uint64 `json:"errors"`
+	Delivered uint64 `json:"delivered"`
+}
+
+// shardConfigs returns the shard configs of cfg, adjusted like
+// FluentConfig: a missing Timeout is taken from it and the dialect applies.
+func shardConfigs(cfg *SugaredLoggerConfig) []fluent.Config {
+	if len(cfg.Shards) == 0 {
+		return nil
+	}
+	self := newSelfLogger(cfg)
+	shards := make([]fluent.Config, len(cfg.Shards))
+	for i, shard := range cfg.Shards {
+		if shard.Timeout == 0 {
+			shard.Timeout = cfg.FluentConfig.Timeout
+		}
+		cfg.Dialect.apply(&shard, self)
+		shards[i] = shard
+	}
+	return shards
+}
+
+// newShardedPoster builds a transport for each of cfgs with dial. If one
+// fails, those already built are closed.
+func newShardedPoster(key string, cfgs []fluent.Config, dial func(fluent.Config) (poster, error)) (*shardedPoster, error) {
+	s := &shardedPoster{key: key, members: make([]*shardMember, 0, len(cfgs))}
+	for i, cfg := range cfgs {
+		p, err := dial(cfg)
+		if err != nil {
+			s.Close()
+			return nil, fmt.Errorf("shard %d: %w", i, err)
+		}
+		s.members = append(s.members, &shardMember{poster: p, address: fluentAddress(cfg)})
+	}
+	return s, nil
+}
+
+// pick returns the shard for message. Messages without the key field, and
+// those that are not records, go to shard 0.
+func (s *shardedPoster) pick(message interface{}) *shardMember {
+	var record map[string]interface{}
+	switch m := message.(type) {
+	case map[string]interface{}:
+		record = m
+	case orderedRecord:
+		record = m.fields
+	}
+	v, ok := record[s.key]
+	if !ok || len(s.members) == 1 {
+		return s.members[0]
+	}
+
+	key := fmt.Sprint(v)
+	var best *shardMember
+	var bestScore uint64
+	for i, m := range s.members {
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		_ = binary.Write(h, binary.BigEndian, uint32(i))
+		if score := h.Sum64(); best == nil || score > bestScore {
+			best, bestScore = m, score
+		}
+	}
+	return best
+}
+
+func (s *shardedPoster) PostWithTime(tag string, tm time.Time, message interface{}) error {
+	m := s.pick(message)
+	err := m.PostWithTime(tag, tm, message)
+	if err != nil {
+		m.failures.Add(1)
+	} else {
+		m.delivered.Add(1)
+	}
+	m.down.Store(err != nil)
+	return err
+}
+
+// Close closes every shard, flushing what each still holds.
+func (s *shardedPoster) Close() error {
+	var errs []error
+	for _, m := range s.members {
+		if err := m.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *shardedPoster) health() []ShardHealth {
+	health := make([]ShardHealth, len(s.members))
+	for i, m := range s.members {
+		health[i] = ShardHealth{
+			Index:     i,
+			Address:   m.address,
+			Healthy:   !m.down.Load(),
+			Errors:    m.failures.Load(),
+			Delivered: m.delivered.Load(),
+		}
+	}
+	return health
+}
+
+// connHealth reports the connections of every shard, in shard order.
+func (s *shardedPoster) connHealth() []ConnHealth {
+	var health []ConnHealth
+	for i, m := range s.members {
+		if pool, ok := m.poster.(*posterPool); ok {
+			for _, h := range pool.health() {
+				h.Shard = i
+				health = append(health, h)
+			}
+			continue
+		}
+		health = append(health, ConnHealth{Shard: i, Healthy: !m.down.Load(), Errors: m.failures.Load()})
+	}
+	return health
+}
+
+// ShardHealth reports the health and delivery counts of each shard
+// configured by Shards, or nil if the logger is not sharded.
+func (l *SugaredLogger) ShardHealth() []ShardHealth {
+	f := l.fluent
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if s, ok := f.logger.(*shardedPoster); ok {
+		return s.health()
+	}
+	return nil
+}
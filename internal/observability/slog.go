@@ -0,0 +1,177 @@
+package observability
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// slogLevelKey carries the original level of records logged at a custom
+// slog level.
+const slogLevelKey = "slog.level"
+
+// Slog returns a *slog.Logger whose records go through this logger's core:
+// the same level filter, field handling and Fluent transport. Groups become
+// nested objects. The context passed to the slog methods is applied as by
+// Ctx, so entries carry its trace.
+//
+// slog levels map to the nearest Zap level at or below them (DEBUG, INFO,
+// WARN, ERROR; anything above ERROR is logged at ERROR). Records at a
+// custom level, such as slog.LevelInfo+2, also carry the slog name of their
+// level in a slog.level field.
+func (l *SugaredLogger) Slog() *slog.Logger {
+	return slog.New(&slogHandler{l: l})
+}
+
+// slogHandler is the slog.Handler behind Slog. fields holds the attributes
+// added by WithAttrs, with groups as zap namespaces; groups holds the groups
+// opened since, which are only written once they get attributes.
+type slogHandler struct {
+	l      *SugaredLogger
+	fields []zapcore.Field
+	groups []string
+}
+
+func (h *slogHandler) Enabled(_ context.Context, lvl slog.Level) bool {
+	return h.l.Desugar().Core().Enabled(zapLevel(lvl))
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	l := h.l
+	if ctx != nil {
+		l = l.Ctx(ctx)
+	}
+
+	ent := zapcore.Entry{
+		Level:   zapLevel(r.Level),
+		Time:    r.Time,
+		Message: r.Message,
+	}
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		ent.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+		ent.Caller.Function = frame.Function
+	}
+
+	ce := l.Desugar().Core().Check(ent, nil)
+	if ce == nil {
+		return nil
+	}
+
+	attrs := make([]zapcore.Field, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = appendAttr(attrs, a)
+		return true
+	})
+
+	fields := make([]zapcore.Field, 0, len(h.fields)+len(h.groups)+len(attrs)+1)
+	if slog.Level(ent.Level*4) != r.Level {
+		fields = append(fields, zap.String(slogLevelKey, r.Level.String()))
+	}
+	ce.Write(h.appendFields(fields, attrs)...)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var added []zapcore.Field
+	for _, a := range attrs {
+		added = appendAttr(added, a)
+	}
+	if len(added) == 0 {
+		return h
+	}
+	return &slogHandler{l: h.l, fields: h.appendFields(nil, added)}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	groups := append(h.groups[:len(h.groups):len(h.groups)], name)
+	return &slogHandler{l: h.l, fields: h.fields, groups: groups}
+}
+
+// appendFields appends the handler's fields and then attrs to dst, opening
+// the pending groups in between if attrs is not empty.
+func (h *slogHandler) appendFields(dst, attrs []zapcore.Field) []zapcore.Field {
+	dst = append(dst, h.fields...)
+	if len(attrs) == 0 {
+		return dst
+	}
+	for _, g := range h.groups {
+		dst = append(dst, zap.Namespace(g))
+	}
+	return append(dst, attrs...)
+}
+
+// zapLevel maps a slog level to the nearest Zap level at or below it. The
+// standard slog levels are the Zap levels times four.
+func zapLevel(lvl slog.Level) zapcore.Level {
+	switch {
+	case lvl < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case lvl < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case lvl < slog.LevelError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+// appendAttr converts a as a slog.Handler should: empty attributes are
+// ignored and groups without a key are inlined.
+func appendAttr(fields []zapcore.Field, a slog.Attr) []zapcore.Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return fields
+		}
+		if a.Key == "" {
+			for _, ga := range attrs {
+				fields = appendAttr(fields, ga)
+			}
+			return fields
+		}
+		return append(fields, zap.Object(a.Key, slogGroup(attrs)))
+	case slog.KindString:
+		return append(fields, zap.String(a.Key, a.Value.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(a.Key, a.Value.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(a.Key, a.Value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(a.Key, a.Value.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(a.Key, a.Value.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(a.Key, a.Value.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(a.Key, a.Value.Time()))
+	default:
+		return append(fields, zap.Any(a.Key, a.Value.Any()))
+	}
+}
+
+// slogGroup encodes the attributes of a slog group as a nested object.
+type slogGroup []slog.Attr
+
+func (g slogGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	var fields []zapcore.Field
+	for _, a := range g {
+		fields = appendAttr(fields, a)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package observability
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+func TestSlogLevels(t *testing.T) {
+	tests := []struct {
+		level     slog.Level
+		severity  string
+		slogLevel interface{}
+	}{
+		{slog.LevelDebug - 4, "debug", "DEBUG-4"},
+		{slog.LevelDebug, "debug", nil},
+		{slog.LevelInfo, "info", nil},
+		{slog.LevelInfo + 2, "info", "INFO+2"},
+		{slog.LevelWarn, "warn", nil},
+		{slog.LevelError, "error", nil},
+		{slog.LevelError + 4, "error", "ERROR+4"},
+	}
+	for _, tt := range tests {
+		l, p := newTestLogger(t, &SugaredLoggerConfig{LogLevel: "debug"})
+		l.Slog().Log(context.Background(), tt.level, "m")
+
+		rec := p.last(t)
+		if rec["severity"] != tt.severity || rec[slogLevelKey] != tt.slogLevel {
+			t.Errorf("slog level %v: severity %v, %s %v; want %s, %v",
+				tt.level, rec["severity"], slogLevelKey, rec[slogLevelKey], tt.severity, tt.slogLevel)
+		}
+	}
+}
+
+func TestSlogEnabled(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{LogLevel: "warn"})
+	s := l.Slog()
+	if s.Enabled(context.Background(), slog.LevelInfo) || !s.Enabled(context.Background(), slog.LevelWarn) {
+		t.Error("Enabled does not follow the logger's level")
+	}
+	s.Info("filtered")
+	s.Warn("kept")
+	if got := messagesOf(p.records()); !reflect.DeepEqual(got, []interface{}{"kept"}) {
+		t.Errorf("posted %v, want [kept]", got)
+	}
+}
+
+func TestSlogGroups(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+	s := l.Slog().With("service", "api").WithGroup("req").With("id", 7).WithGroup("empty")
+
+	s.Info("m", "status", 200, slog.Group("user", "name", "ada"), slog.Group("", "inline", true))
+	rec := p.last(t)
+	if rec["service"] != "api" {
+		t.Errorf("service = %v, want the top-level attribute", rec["service"])
+	}
+	want := map[string]interface{}{
+		"id": int64(7),
+		"empty": map[string]interface{}{
+			"status": int64(200),
+			"user":   map[string]interface{}{"name": "ada"},
+			"inline": true,
+		},
+	}
+	if !reflect.DeepEqual(rec["req"], want) {
+		t.Errorf("req = %v, want %v", rec["req"], want)
+	}
+
+	// A group without attributes is left out
+	l.Slog().WithGroup("unused").Info("bare")
+	if _, ok := p.last(t)["unused"]; ok {
+		t.Error("empty group written")
+	}
+}
@@ -0,0 +1,69 @@
+package observability
+
+import (
+	"runtime"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	stackFramesKey = "stack_frames"
+	maxStackFrames = 64
+)
+
+// StackFrames returns a stack_frames field holding the calling goroutine's
+// stack as an array of {func, file, line} objects, innermost first, so a
+// backend can group entries by their top frame. Frames of the runtime
+// and of StackFrames itself are left out. Called from a deferred recover
+// handler, the frames of the handler are left out too, so the first frame
+// is where the panic happened:
+//
+//	defer func() {
+//		if r := recover(); r != nil {
+//			logger.Errorw("panic", "recover", r, observability.StackFrames())
+//		}
+//	}()
+func StackFrames() zap.Field {
+	pcs := make([]uintptr, maxStackFrames)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var stack stackFrames
+	for {
+		frame, more := frames.Next()
+		switch {
+		case frame.Function == "runtime.gopanic":
+			// The frames so far belong to the recover handler.
+			stack = stack[:0]
+		case !strings.HasPrefix(frame.Function, "runtime."):
+			stack = append(stack, frame)
+		}
+		if !more {
+			break
+		}
+	}
+	return zap.Array(stackFramesKey, stack)
+}
+
+// stackFrames encodes frames as an array of objects.
+type stackFrames []runtime.Frame
+
+func (s stackFrames) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, f := range s {
+		if err := enc.AppendObject(stackFrame(f)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type stackFrame runtime.Frame
+
+func (f stackFrame) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("func", f.Function)
+	enc.AddString("file", f.File)
+	enc.AddInt("line", f.Line)
+	return nil
+}
@@ -0,0 +1,89 @@
+package observability
+
+import "sync"
+
+// LoggerStats is a point-in-time snapshot of a logger's state, suitable for
+// serving as JSON from a diagnostics endpoint.
+type LoggerStats struct {
+	Level string `json:"level"`
+	Tag   string `json:"tag"`
+
+	// Emitted counts records accepted by the transport, Dropped those
+	// that were lost, and Fallback those written to the fallback sink.
+	Emitted  uint64 `json:"emitted"`
+	Dropped  uint64 `json:"dropped"`
+	Fallback uint64 `json:"fallback"`
+	// DroppedByTag breaks Dropped down by tag.
+	DroppedByTag map[string]uint64 `json:"dropped_by_tag,omitempty"`
+	// Errors counts failed delivery attempts.
+	Errors uint64 `json:"errors"`
+	// FieldLimitHits counts records trimmed by MaxFields.
+	FieldLimitHits uint64 `json:"field_limit_hits"`
+
+	// Buffered is the number of records held by the offline buffer.
+	Buffered int `json:"buffered"`
+	// BufferedBytes is the approximate encoded size of those records.
+	BufferedBytes int64 `json:"buffered_bytes"`
+	// Connected is false after a failed delivery until the next one
+	// succeeds.
+	Connected    bool   `json:"connected"`
+	CircuitState string `json:"circuit_state"`
+	Closed       bool   `json:"closed"`
+
+	// WriteDuration is the histogram of transport write durations.
+	WriteDuration HistogramSnapshot `json:"write_duration_seconds"`
+}
+
+// Stats returns a snapshot of the logger's counters and state. Every value
+// is read atomically; the snapshot as a whole is not taken under a lock, so
+// counters may be a write apart from each other under concurrent logging.
+func (l *SugaredLogger) Stats() LoggerStats {
+	f := l.fluent
+	return LoggerStats{
+		Level:          l.Level().String(),
+		Tag:            l.Tag(),
+		Emitted:        f.emitted.Load(),
+		Dropped:        f.dropped.Load(),
+		DroppedByTag:   f.droppedByTag.snapshot(),
+		Fallback:       f.fallbacks.Load(),
+		Errors:         f.failures.Load(),
+		FieldLimitHits: f.fieldLimitHits.Load(),
+		Buffered:       f.buffer.len(),
+		BufferedBytes:  f.buffer.size(),
+		Connected:      !f.down.Load(),
+		CircuitState:   f.breaker.State(),
+		Closed:         f.closed.Load(),
+		WriteDuration:  f.writeDurations.snapshot(),
+	}
+}
+
+// tagCounter counts events per tag. The zero value is ready to use.
+type tagCounter struct {
+	mu     sync.Mutex
+	counts map[string]uint64
+}
+
+func (c *tagCounter) add(tag string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.counts == nil {
+		c.counts = make(map[string]uint64)
+	}
+	c.counts[tag]++
+}
+
+// snapshot returns a copy of the counts, or nil if there are none.
+func (c *tagCounter) snapshot() map[string]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.counts) == 0 {
+		return nil
+	}
+	counts := make(map[string]uint64, len(c.counts))
+	for tag, n := range c.counts {
+		counts[tag] = n
+	}
+	return counts
+}
@@ -0,0 +1,86 @@
+package observability
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// maxStringerDepth bounds how deep preferStringer descends into nested
+// slices and maps, so a self-referencing value cannot recurse forever.
+const maxStringerDepth = 16
+
+// preferStringer replaces v, or the elements of v if it is a slice, array
+// or string-keyed map, with their String() output where they implement
+// fmt.Stringer. Values that define their own JSON or text encoding (such as
+// time.Time) keep it. Anything else is returned as is.
+func preferStringer(v interface{}, depth int) interface{} {
+	if v == nil || depth > maxStringerDepth {
+		return v
+	}
+	if s, ok := asStringer(v); ok {
+		return callString(s)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return v
+		}
+		out := make([]interface{}, rv.Len())
+		for i := range out {
+			out[i] = preferStringer(rv.Index(i).Interface(), depth+1)
+		}
+		return out
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String || rv.IsNil() {
+			return v
+		}
+		out := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			out[iter.Key().String()] = preferStringer(iter.Value().Interface(), depth+1)
+		}
+		return out
+	}
+	return v
+}
+
+// asStringer returns v as a fmt.Stringer, also when only *T implements it
+// for a v of type T. Types with their own JSON or text encoding are left
+// alone.
+func asStringer(v interface{}) (fmt.Stringer, bool) {
+	switch v.(type) {
+	case json.Marshaler, encoding.TextMarshaler:
+		return nil, false
+	case fmt.Stringer:
+		return v.(fmt.Stringer), true
+	}
+
+	rv := reflect.ValueOf(v)
+	p := reflect.New(rv.Type())
+	p.Elem().Set(rv)
+	switch pv := p.Interface().(type) {
+	case json.Marshaler, encoding.TextMarshaler:
+		return nil, false
+	case fmt.Stringer:
+		return pv, true
+	}
+	return nil, false
+}
+
+// callString calls s.String(), reporting a nil pointer receiver as <nil>
+// and a panic as <PANIC=...>, as zap.Stringer does.
+func callString(s fmt.Stringer) (str string) {
+	if rv := reflect.ValueOf(s); rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return "<nil>"
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			str = fmt.Sprintf("<PANIC=%v>", r)
+		}
+	}()
+	return s.String()
+}
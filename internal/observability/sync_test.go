@@ -0,0 +1,105 @@
+package observability
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fluent/fluent-logger-golang/fluent"
+)
+
+// countingPoster counts Close calls and blocks them until released.
+type countingPoster struct {
+	fakePoster
+	closes  atomic.Int32
+	release chan struct{}
+	err     error
+}
+
+func (p *countingPoster) Close() error {
+	p.closes.Add(1)
+	<-p.release
+	return p.err
+}
+
+func newCountingLogger(t *testing.T, closeErr error) (*SugaredLogger, *countingPoster) {
+	t.Helper()
+	l, _ := newTestLogger(t, &SugaredLoggerConfig{FluentConfig: fluent.Config{Timeout: time.Minute}})
+	p := &countingPoster{release: make(chan struct{}), err: closeErr}
+	l.fluent.logger = p
+	return l, p
+}
+
+func TestSyncAndCloseConcurrent(t *testing.T) {
+	closeErr := errors.New("connection reset")
+	l, p := newCountingLogger(t, closeErr)
+	l.Infow("m")
+
+	var wg sync.WaitGroup
+	syncErrs := make(chan error, 8)
+	closeErrs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			syncErrs <- l.Sync()
+		}()
+		go func() {
+			defer wg.Done()
+			closeErrs <- l.Close()
+		}()
+	}
+	close(p.release)
+	wg.Wait()
+	close(syncErrs)
+	close(closeErrs)
+
+	if n := p.closes.Load(); n != 1 {
+		t.Errorf("transport closed %d times, want once", n)
+	}
+	for err := range syncErrs {
+		if !errors.Is(err, closeErr) {
+			t.Errorf("Sync = %v, want the close error", err)
+		}
+	}
+	var failed int
+	for err := range closeErrs {
+		if err != nil {
+			failed++
+			if !errors.Is(err, closeErr) {
+				t.Errorf("Close = %v, want the close error", err)
+			}
+		}
+	}
+	if failed != 1 {
+		t.Errorf("%d Close calls reported the error, want only the first", failed)
+	}
+}
+
+func TestSyncWaitsForClose(t *testing.T) {
+	l, p := newCountingLogger(t, nil)
+
+	closed := make(chan error, 1)
+	go func() { closed <- l.Close() }()
+	for p.closes.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	synced := make(chan error, 1)
+	go func() { synced <- l.Sync() }()
+	select {
+	case err := <-synced:
+		t.Fatalf("Sync returned %v while Close was in progress", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(p.release)
+	if err := <-synced; err != nil {
+		t.Errorf("Sync = %v, want nil", err)
+	}
+	if err := <-closed; err != nil {
+		t.Errorf("Close = %v, want nil", err)
+	}
+}
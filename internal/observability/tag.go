@@ -0,0 +1,32 @@
+package observability
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidateTag reports whether tag is usable for routing in Fluentd: one or
+// more dot-separated parts made of letters, digits, '_' and '-'.
+func ValidateTag(tag string) error {
+	if tag == "" {
+		return fmt.Errorf("tag must not be empty")
+	}
+	for _, part := range strings.Split(tag, ".") {
+		if part == "" {
+			return fmt.Errorf("tag %q has an empty part", tag)
+		}
+		for _, r := range part {
+			if !isTagRune(r) {
+				return fmt.Errorf("tag %q contains invalid character %q", tag, r)
+			}
+		}
+	}
+	return nil
+}
+
+func isTagRune(r rune) bool {
+	return r >= 'a' && r <= 'z' ||
+		r >= 'A' && r <= 'Z' ||
+		r >= '0' && r <= '9' ||
+		r == '_' || r == '-'
+}
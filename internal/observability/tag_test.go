@@ -0,0 +1,55 @@
+package observability
+
+import "testing"
+
+func TestValidateTag(t *testing.T) {
+	tests := []struct {
+		tag   string
+		valid bool
+	}{
+		{"app.logs", true},
+		{"app", true},
+		{"App_1.worker-2", true},
+		{"", false},
+		{".app", false},
+		{"app.", false},
+		{"app..logs", false},
+		{"app logs", false},
+		{"app/logs", false},
+		{"app.lögs", false},
+	}
+	for _, tt := range tests {
+		err := ValidateTag(tt.tag)
+		if (err == nil) != tt.valid {
+			t.Errorf("ValidateTag(%q) = %v, want valid=%v", tt.tag, err, tt.valid)
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"debug", "debug", false},
+		{"INFO", "info", false},
+		{"warn", "warn", false},
+		{"WARNING", "warn", false},
+		{"warning", "warn", false},
+		{"error", "error", false},
+		{"loud", "", true},
+	}
+	for _, tt := range tests {
+		lvl, err := ParseLevel(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseLevel(%q) accepted", tt.in)
+			}
+			continue
+		}
+		if err != nil || lvl.String() != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %s", tt.in, lvl, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package observability
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestWriteTimestamp(t *testing.T) {
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		cfg     SugaredLoggerConfig
+		line    string
+		want    time.Time
+		wantErr error
+	}{
+		{"default key", SugaredLoggerConfig{}, `{"timestamp":"2024-05-01T10:00:00Z","message":"m"}`, at, nil},
+		{"unix nanos", SugaredLoggerConfig{}, `{"timestamp":1714557600000000000,"message":"m"}`, at, nil},
+		{"renamed key", SugaredLoggerConfig{TimestampKey: "ts"}, `{"ts":"2024-05-01T10:00:00Z","message":"m"}`, at, nil},
+		{"renamed key, default missing", SugaredLoggerConfig{TimestampKey: "ts", RequireTimestamp: true}, `{"timestamp":"2024-05-01T10:00:00Z","message":"m"}`, time.Time{}, ErrMissingTimestamp},
+		{"missing", SugaredLoggerConfig{}, `{"message":"m"}`, time.Time{}, nil},
+		{"missing, required", SugaredLoggerConfig{RequireTimestamp: true}, `{"message":"m"}`, time.Time{}, ErrMissingTimestamp},
+		{"unreadable, required", SugaredLoggerConfig{RequireTimestamp: true}, `{"timestamp":"yesterday","message":"m"}`, time.Time{}, ErrMissingTimestamp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, p := newTestLogger(t, &tt.cfg)
+			before := time.Now()
+			_, err := l.fluent.Write([]byte(tt.line + "\n"))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Write = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if n := len(p.records()); n != 0 {
+					t.Errorf("%d records posted for a rejected entry", n)
+				}
+				return
+			}
+
+			p.mu.Lock()
+			got := p.times[0]
+			p.mu.Unlock()
+			if tt.want.IsZero() {
+				if got.Before(before) || got.After(time.Now()) {
+					t.Errorf("time = %v, want the current time", got)
+				}
+			} else if !got.Equal(tt.want) {
+				t.Errorf("time = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDualTimestamp(t *testing.T) {
+	epoch := newEncoderConfig()
+	epoch.EncodeTime = zapcore.EpochTimeEncoder
+	tests := []struct {
+		name     string
+		cfg      SugaredLoggerConfig
+		key      string
+		wantUnix bool
+	}{
+		{"string primary", SugaredLoggerConfig{DualTimestamp: true}, "ts", true},
+		{"renamed", SugaredLoggerConfig{DualTimestamp: true, DualTimestampKey: "epoch"}, "epoch", true},
+		{"numeric primary", SugaredLoggerConfig{DualTimestamp: true, EncoderConfig: &epoch}, "ts", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, p := newTestLogger(t, &tt.cfg)
+			before := time.Now()
+			l.Infow("m")
+			after := time.Now()
+
+			rec := p.last(t)
+			primary, second := rec["timestamp"], rec[tt.key]
+			if tt.wantUnix {
+				if _, err := time.Parse(time.RFC3339Nano, primary.(string)); err != nil {
+					t.Errorf("timestamp = %v, want RFC 3339: %v", primary, err)
+				}
+				secs, ok := second.(float64)
+				if !ok || secs < float64(before.Unix()) || secs > float64(after.Unix()+1) {
+					t.Errorf("%s = %#v, want Unix seconds", tt.key, second)
+				}
+				return
+			}
+			if _, ok := primary.(float64); !ok {
+				t.Errorf("timestamp = %#v, want Unix seconds", primary)
+			}
+			ts, err := time.Parse(time.RFC3339Nano, second.(string))
+			if err != nil || ts.Before(before.Truncate(time.Second)) || ts.After(after) {
+				t.Errorf("%s = %v, want the RFC 3339 entry time", tt.key, second)
+			}
+		})
+	}
+}
+
+func TestDualTimestampExemptFromMaxFields(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{DualTimestamp: true, MaxFields: 1})
+	l.Infow("m", "a", 1, "b", 2)
+	if _, ok := p.last(t)["ts"]; !ok {
+		t.Error("MaxFields dropped the dual timestamp")
+	}
+}
+
+func TestTimeFieldsUseTimestampFormat(t *testing.T) {
+	at := time.Date(2024, 5, 1, 10, 0, 0, 123456789, time.UTC)
+	epoch := newEncoderConfig()
+	epoch.EncodeTime = zapcore.EpochTimeEncoder
+	for _, tt := range []struct {
+		name string
+		enc  *zapcore.EncoderConfig
+		want interface{}
+	}{
+		{"default", nil, at.Format(time.RFC3339Nano)},
+		{"epoch", &epoch, float64(at.UnixNano()) / float64(time.Second)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			l, p := newTestLogger(t, &SugaredLoggerConfig{EncoderConfig: tt.enc})
+			l.Desugar().Info("m",
+				zap.Time("deadline", at),
+				zap.Reflect("nested", map[string]interface{}{"at": at, "ptr": &at}),
+			)
+			l.Infow("m", "deadline", at)
+			if err := l.Event(zapcore.InfoLevel, "", map[string]interface{}{
+				"deadline": at,
+				"nested":   map[string]interface{}{"at": at, "ptr": &at},
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			recs := p.records()
+			stamp := recs[0]["timestamp"]
+			if reflect.TypeOf(stamp) != reflect.TypeOf(tt.want) {
+				t.Fatalf("timestamp = %#v, want the same type as %#v", stamp, tt.want)
+			}
+			for i, rec := range recs {
+				if got := rec["deadline"]; got != tt.want {
+					t.Errorf("record %d: deadline = %#v, want %#v", i, got, tt.want)
+				}
+			}
+			for _, i := range []int{0, 2} {
+				nested, _ := recs[i]["nested"].(map[string]interface{})
+				if nested["at"] != tt.want || nested["ptr"] != tt.want {
+					t.Errorf("record %d: nested = %#v, want both times as %#v", i, nested, tt.want)
+				}
+			}
+		})
+	}
+}
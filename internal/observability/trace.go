@@ -0,0 +1,113 @@
+package observability
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultTraceIDKey = "trace_id"
+	defaultSpanIDKey  = "span_id"
+)
+
+// TraceFields controls the names and formatting of the correlation fields
+// attached by Ctx. Empty keys fall back to trace_id/span_id and nil
+// formatters render the IDs as lowercase hex.
+type TraceFields struct {
+	TraceIDKey    string
+	SpanIDKey     string
+	FormatTraceID func(trace.TraceID) interface{}
+	FormatSpanID  func(trace.SpanID) interface{}
+}
+
+// DatadogTraceFields returns the dd.trace_id/dd.span_id layout. Datadog
+// correlates on the decimal form of the lower 64 bits of the trace ID.
+func DatadogTraceFields() TraceFields {
+	return TraceFields{
+		TraceIDKey: "dd.trace_id",
+		SpanIDKey:  "dd.span_id",
+		FormatTraceID: func(id trace.TraceID) interface{} {
+			return strconv.FormatUint(binary.BigEndian.Uint64(id[8:]), 10)
+		},
+		FormatSpanID: func(id trace.SpanID) interface{} {
+			return strconv.FormatUint(binary.BigEndian.Uint64(id[:]), 10)
+		},
+	}
+}
+
+// GCPTraceFields returns the Cloud Logging layout, which expects the trace
+// as a resource name: projects/PROJECT/traces/TRACE.
+func GCPTraceFields(projectID string) TraceFields {
+	return TraceFields{
+		TraceIDKey: "logging.googleapis.com/trace",
+		SpanIDKey:  "logging.googleapis.com/spanId",
+		FormatTraceID: func(id trace.TraceID) interface{} {
+			return fmt.Sprintf("projects/%s/traces/%s", projectID, id)
+		},
+	}
+}
+
+func (t TraceFields) withDefaults() TraceFields {
+	if t.TraceIDKey == "" {
+		t.TraceIDKey = defaultTraceIDKey
+	}
+	if t.SpanIDKey == "" {
+		t.SpanIDKey = defaultSpanIDKey
+	}
+	if t.FormatTraceID == nil {
+		t.FormatTraceID = func(id trace.TraceID) interface{} { return id.String() }
+	}
+	if t.FormatSpanID == nil {
+		t.FormatSpanID = func(id trace.SpanID) interface{} { return id.String() }
+	}
+	return t
+}
+
+// Ctx returns a derived logger carrying the trace correlation fields of the
+// span stored in ctx, if any, and a correlation ID: the one set by
+// ContextWithCorrelationID, or else a new one generated for the derived
+// logger. Entries of loggers derived from the same ctx only share a
+// generated ID if ctx was prepared with ContextWithCorrelationID, so call
+// it once per logical operation. If ctx carries a tag set by
+// ContextWithTag, the derived logger posts under it. The derived logger
+// shares the transport of its parent and does not own it, so closing it is
+// a no-op.
+//
+// In sync mode, writes through the derived logger are bounded by the
+// deadline of ctx: an entry that cannot be delivered before the deadline (or
+// the configured WriteTimeout, whichever is sooner) is abandoned with
+// ErrWriteAbandoned. This is intentional log loss to protect latency SLOs.
+func (l *SugaredLogger) Ctx(ctx context.Context) *SugaredLogger {
+	tag, hasTag := tagFromContext(ctx)
+	if hasTag {
+		if err := ValidateTag(tag); err != nil {
+			l.fluent.self.warn("invalid context tag ignored", "tag", tag, "error", err)
+			hasTag = false
+		}
+	}
+	s := l.withCore(func(c *fluentCore) *fluentCore {
+		c = c.withContext(ctx)
+		if hasTag {
+			c = c.withContextTag(tag)
+		}
+		return c
+	})
+
+	var fields []interface{}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(fields,
+			l.cfg.Trace.TraceIDKey, l.cfg.Trace.FormatTraceID(sc.TraceID()),
+			l.cfg.Trace.SpanIDKey, l.cfg.Trace.FormatSpanID(sc.SpanID()),
+		)
+	}
+	id, ok := CorrelationIDFromContext(ctx)
+	if !ok {
+		id = newUUID()
+	}
+	fields = append(fields, CorrelationIDKey, id)
+	return l.derive(s.With(fields...))
+}
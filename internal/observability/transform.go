@@ -0,0 +1,182 @@
+package observability
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	droppedFieldsKey    = "__dropped_fields"
+	messageTruncatedKey = "message_truncated"
+	stackTruncatedLine  = "...(truncated)"
+	ellipsis            = "…"
+)
+
+// prepare applies the record-level options to entry, in order: field
+// sanitization, value redaction, message and stacktrace truncation, key
+// length limits, removal of empty fields, field limits, the EMF envelope,
+// the SeparateBody split, then the EntryMarshaler, which always sees the
+// final fields, the dialect's shape requirements, the SchemaValidator and
+// finally the ChecksumField, computed over the record as posted.
+func (f *FluentLogger) prepare(entry map[string]interface{}, t time.Time) (map[string]interface{}, error) {
+	if f.cfg.SanitizeFields {
+		sanitize(entry)
+	}
+	if len(f.cfg.RedactPatterns) > 0 {
+		f.redactRecord(entry)
+	}
+	if f.cfg.MaxMessageBytes > 0 {
+		truncateMessage(entry, f.messageKey, f.cfg.MaxMessageBytes)
+	}
+	if f.cfg.MaxStacktraceLines > 0 {
+		truncateStacktrace(entry, f.stacktraceKey, f.cfg.MaxStacktraceLines)
+	}
+	if f.cfg.MaxKeyLength > 0 {
+		f.limitKeys(entry)
+	}
+	if f.cfg.OmitEmpty {
+		omitEmpty(entry, f.standard)
+	}
+	if f.cfg.MaxFields > 0 {
+		f.limitFields(entry)
+	}
+	if f.cfg.EMF != nil {
+		addEMF(entry, f.cfg.EMF, t)
+	}
+	if f.cfg.SeparateBody != "" {
+		f.separateBody(entry)
+	}
+
+	if f.cfg.EntryMarshaler != nil {
+		var err error
+		if entry, err = f.cfg.EntryMarshaler(entry); err != nil {
+			return nil, fmt.Errorf("log marshal failed: %w", err)
+		}
+	}
+
+	f.cfg.Dialect.shape(entry)
+	if v := f.cfg.SchemaValidator; v != nil {
+		if err := v.Validate(entry); err != nil {
+			return nil, fmt.Errorf("%w: version %s: %w", ErrSchemaViolation, v.Version(), err)
+		}
+	}
+	if f.cfg.ChecksumField != "" {
+		if err := addChecksum(entry, f.cfg.ChecksumField); err != nil {
+			// The record is worth more than its checksum
+			f.self.warn("record posted without checksum", "error", err)
+		}
+	}
+	return entry, nil
+}
+
+// limitFields keeps the first MaxFields user fields in key order and
+// replaces the rest with a count, guarding the index mapping against
+// cardinality explosions. Standard keys do not count towards the limit.
+func (f *FluentLogger) limitFields(entry map[string]interface{}) {
+	user := make([]string, 0, len(entry))
+	for k := range entry {
+		if !f.standard[k] && k != f.cfg.IncludeTagField {
+			user = append(user, k)
+		}
+	}
+	if len(user) <= f.cfg.MaxFields {
+		return
+	}
+
+	sort.Strings(user)
+	for _, k := range user[f.cfg.MaxFields:] {
+		delete(entry, k)
+	}
+	entry[droppedFieldsKey] = len(user) - f.cfg.MaxFields
+	f.fieldLimitHits.Add(1)
+}
+
+// separateBody moves the user fields of entry into an object under
+// SeparateBody, leaving the standard keys, the fields the logger adds
+// itself (IncludeTagField, IncludeSequence) and the EMF envelope at the top
+// level. Entries without user fields get no body.
+func (f *FluentLogger) separateBody(entry map[string]interface{}) {
+	body := make(map[string]interface{})
+	for k, v := range entry {
+		if f.standard[k] || k == emfKey || k == f.cfg.IncludeTagField || f.cfg.IncludeSequence && k == f.sequenceKey() {
+			continue
+		}
+		body[k] = v
+		delete(entry, k)
+	}
+	if len(body) > 0 {
+		entry[f.cfg.SeparateBody] = body
+	}
+}
+
+// omitEmpty removes the user fields whose value is nil or an empty string,
+// slice or map. Zero values such as 0 and false are kept, as are the
+// standard keys.
+func omitEmpty(entry map[string]interface{}, standard map[string]bool) {
+	for k, v := range entry {
+		if !standard[k] && isEmpty(v) {
+			delete(entry, k)
+		}
+	}
+}
+
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		return rv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return false
+	}
+}
+
+// truncateMessage shortens the message of entry, under key, to at most max
+// bytes, ending in an ellipsis, and marks the entry with message_truncated.
+// It cuts at a UTF-8 character boundary.
+func truncateMessage(entry map[string]interface{}, key string, max int) {
+	msg, ok := entry[key].(string)
+	if !ok || len(msg) <= max {
+		return
+	}
+
+	cut := max - len(ellipsis)
+	suffix := ellipsis
+	if cut < 0 {
+		cut, suffix = max, ""
+	}
+	for cut > 0 && !utf8.RuneStart(msg[cut]) {
+		cut--
+	}
+	entry[key] = msg[:cut] + suffix
+	entry[messageTruncatedKey] = true
+}
+
+// truncateStacktrace keeps the first max lines of the stacktrace under key
+// and appends a line marking the cut.
+func truncateStacktrace(entry map[string]interface{}, key string, max int) {
+	stack, ok := entry[key].(string)
+	if !ok {
+		return
+	}
+	i := 0
+	for n := 0; n < max; n++ {
+		j := strings.IndexByte(stack[i:], '\n')
+		if j < 0 {
+			return
+		}
+		i += j + 1
+	}
+	if i == len(stack) {
+		return
+	}
+	entry[key] = stack[:i] + stackTruncatedLine
+}
@@ -0,0 +1,202 @@
+package observability
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestOmitEmpty(t *testing.T) {
+	var nilPtr *int
+	var nilSlice []string
+	for _, tt := range []struct {
+		name  string
+		value interface{}
+		keep  bool
+	}{
+		{"nil", nil, false},
+		{"empty string", "", false},
+		{"empty slice", []interface{}{}, false},
+		{"nil slice", nilSlice, false},
+		{"empty map", map[string]interface{}{}, false},
+		{"nil pointer", nilPtr, false},
+		{"zero int", 0, true},
+		{"zero float", 0.0, true},
+		{"false", false, true},
+		{"space", " ", true},
+		{"slice", []interface{}{""}, true},
+		{"map", map[string]interface{}{"k": nil}, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := map[string]interface{}{"field": tt.value, "message": ""}
+			omitEmpty(entry, standardKeys)
+			if _, ok := entry["field"]; ok != tt.keep {
+				t.Errorf("kept = %v, want %v", ok, tt.keep)
+			}
+			if _, ok := entry["message"]; !ok {
+				t.Error("standard key removed")
+			}
+		})
+	}
+}
+
+func TestOmitEmptyWritePath(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{OmitEmpty: true})
+	err := l.Event(zapcore.InfoLevel, "", map[string]interface{}{
+		"error": nil,
+		"user":  "",
+		"tags":  []string{},
+		"count": int64(0),
+		"ok":    false,
+	})
+	if err != nil {
+		t.Fatalf("Event: %v", err)
+	}
+	l.Infow("m", "error", nil, "user", "", "count", 0)
+
+	for i, rec := range p.records() {
+		for _, k := range []string{"error", "user", "tags"} {
+			if _, ok := rec[k]; ok {
+				t.Errorf("record %d: empty field %q kept: %v", i, k, rec)
+			}
+		}
+		if rec["count"] != int64(0) {
+			t.Errorf("record %d: count = %#v, want 0", i, rec["count"])
+		}
+	}
+}
+
+func TestTruncateMessage(t *testing.T) {
+	tests := []struct {
+		msg, want string
+		max       int
+	}{
+		{"abcdefghij", "abcdefghij", 10},
+		{"abcdefghijk", "abcdefg…", 10},
+		{"abcdef", "ab", 2},
+		{"abcdef", "…", 3},
+		{"ééééé", "éé…", 8},
+		{"", "", 0},
+	}
+	for _, tt := range tests {
+		entry := map[string]interface{}{"message": tt.msg, "user": "ada"}
+		truncateMessage(entry, "message", tt.max)
+
+		got := entry["message"].(string)
+		if got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.msg, tt.max, got, tt.want)
+		}
+		if len(got) > tt.max {
+			t.Errorf("truncate(%q, %d) is %d bytes", tt.msg, tt.max, len(got))
+		}
+		_, marked := entry[messageTruncatedKey]
+		if marked != (got != tt.msg) {
+			t.Errorf("truncate(%q, %d): marker %v", tt.msg, tt.max, marked)
+		}
+		if entry["user"] != "ada" {
+			t.Errorf("truncate(%q, %d) changed another field", tt.msg, tt.max)
+		}
+	}
+}
+
+func TestMaxMessageBytes(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{MaxMessageBytes: 8})
+	l.Infow("a very long message", "user", "ada")
+
+	rec := p.last(t)
+	if rec["message"] != "a ver…" || rec[messageTruncatedKey] != true || rec["user"] != "ada" {
+		t.Errorf("record = %v", rec)
+	}
+}
+
+// logDeep logs msg from depth nested calls, with a stacktrace.
+func logDeep(l *zap.Logger, depth int, msg string) {
+	if depth > 0 {
+		logDeep(l, depth-1, msg)
+		return
+	}
+	l.Error(msg)
+}
+
+func TestMaxStacktraceLines(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{MaxStacktraceLines: 6})
+	logDeep(l.Desugar().WithOptions(zap.AddStacktrace(zapcore.ErrorLevel)), 100, "deep")
+
+	stack, _ := p.last(t)["stacktrace"].(string)
+	lines := strings.Split(stack, "\n")
+	if len(lines) != 7 || lines[6] != stackTruncatedLine {
+		t.Fatalf("stacktrace = %q, want 6 lines and the marker", stack)
+	}
+	if !strings.Contains(lines[0], "logDeep") {
+		t.Errorf("first frame = %q, want the innermost call", lines[0])
+	}
+}
+
+func TestMaxStacktraceLinesCallerField(t *testing.T) {
+	tests := []struct {
+		stack, want string
+	}{
+		{"a\nb\nc\nd", "a\nb\n" + stackTruncatedLine},
+		{"a\nb", "a\nb"},
+		{"a\nb\n", "a\nb\n"},
+	}
+	for _, tt := range tests {
+		l, p := newTestLogger(t, &SugaredLoggerConfig{MaxStacktraceLines: 2})
+		l.Errorw("m", "stacktrace", tt.stack)
+		if got := p.last(t)["stacktrace"]; got != tt.want {
+			t.Errorf("stacktrace %q = %q, want %q", tt.stack, got, tt.want)
+		}
+	}
+}
+
+func TestMaxStacktraceLinesUnset(t *testing.T) {
+	l, p := newTestLogger(t, nil)
+	logDeep(l.Desugar().WithOptions(zap.AddStacktrace(zapcore.ErrorLevel)), 100, "deep")
+
+	stack, _ := p.last(t)["stacktrace"].(string)
+	if strings.Contains(stack, stackTruncatedLine) || strings.Count(stack, "logDeep") < 100 {
+		t.Errorf("stacktrace cut without MaxStacktraceLines: %d lines", strings.Count(stack, "\n")+1)
+	}
+}
+
+func TestSeparateBody(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{
+		SeparateBody:    "body",
+		IncludeSequence: true,
+		IncludeTagField: "tag",
+		RedactPatterns:  []*regexp.Regexp{RedactEmails},
+	})
+	l.WithComponent("billing").Infow("charged", "email", "ada@example.com", "amount", 12)
+
+	rec := p.last(t)
+	want := map[string]interface{}{
+		"component": "billing",
+		"email":     redactedMask,
+		"amount":    int64(12),
+	}
+	if got := rec["body"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("body = %#v, want %#v", got, want)
+	}
+	for _, k := range []string{"message", "severity", "timestamp", "tag", defaultSequenceKey} {
+		if _, ok := rec[k]; !ok {
+			t.Errorf("top-level key %q missing from %v", k, rec)
+		}
+	}
+	for _, k := range []string{"email", "amount", "component"} {
+		if _, ok := rec[k]; ok {
+			t.Errorf("user field %q left at the top level", k)
+		}
+	}
+}
+
+func TestSeparateBodyWithoutUserFields(t *testing.T) {
+	l, p := newTestLogger(t, &SugaredLoggerConfig{SeparateBody: "body"})
+	l.Infow("m")
+	if _, ok := p.last(t)["body"]; ok {
+		t.Error("record without user fields has a body")
+	}
+}
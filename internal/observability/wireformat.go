@@ -0,0 +1,44 @@
+package observability
+
+import (
+	"go.uber.org/zap/zapcore"
+)
+
+// encoderHolder boxes a zapcore.Encoder so it can be swapped atomically.
+type encoderHolder struct {
+	enc zapcore.Encoder
+}
+
+// SetEncoder switches the format entries are rendered in, for this logger
+// and every logger derived from the same root, without restarting it.
+// Entries already being written finish with the previous encoder. Passing
+// nil restores the built-in layout.
+//
+// enc must produce one JSON object per entry, e.g. CompactEncoder or
+// VerboseEncoder; the output is decoded back into a record before it is
+// posted. While an encoder is set, every entry costs one atomic load to
+// find it plus that encode/decode round trip, which the built-in layout
+// avoids by building records directly.
+func (l *SugaredLogger) SetEncoder(enc zapcore.Encoder) {
+	if enc == nil {
+		l.fluent.encoder.Store(nil)
+		return
+	}
+	l.fluent.encoder.Store(&encoderHolder{enc: enc})
+}
+
+// CompactEncoder returns a JSON encoder with the same keys as the built-in
+// layout.
+func CompactEncoder() zapcore.Encoder {
+	return zapcore.NewJSONEncoder(newEncoderConfig())
+}
+
+// VerboseEncoder returns a JSON encoder for debugging: it adds the calling
+// function and reports the full caller path and upper-case levels.
+func VerboseEncoder() zapcore.Encoder {
+	cfg := newEncoderConfig()
+	cfg.FunctionKey = "function"
+	cfg.EncodeLevel = zapcore.CapitalLevelEncoder
+	cfg.EncodeCaller = zapcore.FullCallerEncoder
+	return zapcore.NewJSONEncoder(cfg)
+}